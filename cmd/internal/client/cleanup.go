@@ -0,0 +1,44 @@
+package client
+
+import (
+	"github.com/fuseml/fuseml/cli/paas"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// CmdCleanup implements the fuseml-installer cleanup command
+var CmdCleanup = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Find and remove the FuseML resources left behind by removed components and extensions",
+	Long: `List the resources labeled by the installer with the component (fuse.ml/component) or the extension
+(fuse.ml/extension) owning them, whose owner is no longer installed. With --delete, the listed resources are removed.`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remove, err := cmd.Flags().GetBool("delete")
+		if err != nil {
+			return errors.Wrap(err, "could not read the delete flag")
+		}
+
+		install_client, install_cleanup, err := paas.NewInstallClient(cmd.Flags(), nil)
+		defer func() {
+			if install_cleanup != nil {
+				install_cleanup()
+			}
+		}()
+
+		if err != nil {
+			return errors.Wrap(err, "error initializing cli")
+		}
+
+		if err := install_client.Cleanup(cmd.Context(), remove); err != nil {
+			return errors.Wrap(err, "error cleaning up FuseML resources")
+		}
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func init() {
+	CmdCleanup.Flags().Bool("delete", false, "Remove the orphaned resources, instead of only listing them")
+}
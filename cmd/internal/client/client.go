@@ -0,0 +1,113 @@
+package client
+
+import (
+	"github.com/fuseml/fuseml/cli/paas"
+	"github.com/fuseml/fuseml/cli/paas/config"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// CmdClient implements the fuseml-installer client command
+var CmdClient = &cobra.Command{
+	Use:   "client",
+	Short: "Manage the fuseml command line client",
+	Long: `Install, upgrade and show the version of the fuseml command line client. The latest client version compatible with
+the installer is used, unless --version is given. The client is downloaded from the fuseml-core GitHub releases, or from
+the mirror given by --mirror, serving the list of releases at <mirror>/releases and the archives (with their SHA-256
+checksums) at <mirror>/download/<version>/<archive>.`,
+	// the cluster is not needed
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	SilenceErrors:     true,
+	SilenceUsage:      true,
+}
+
+var cmdClientInstall = &cobra.Command{
+	Use:   "install",
+	Short: "Install the fuseml command line client",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, opts, err := coreClientOptions(cmd)
+		if err != nil {
+			return err
+		}
+		if err := paas.InstallCoreClient(cmd.Context(), ui.NewUI(), cfg, opts); err != nil {
+			return errors.Wrap(err, "error installing the fuseml client")
+		}
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+var cmdClientUpgrade = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade the fuseml command line client installed by the installer",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, opts, err := coreClientOptions(cmd)
+		if err != nil {
+			return err
+		}
+		if err := paas.UpgradeCoreClient(cmd.Context(), ui.NewUI(), cfg, opts); err != nil {
+			return errors.Wrap(err, "error upgrading the fuseml client")
+		}
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+var cmdClientVersion = &cobra.Command{
+	Use:   "version",
+	Short: "Show the version of the installed fuseml client and the one compatible with the installer",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cmd.Flags())
+		if err != nil {
+			return errors.Wrap(err, "error loading the configuration")
+		}
+		mirror, err := cmd.Flags().GetString("mirror")
+		if err != nil {
+			return errors.Wrap(err, "could not read the mirror flag")
+		}
+		return paas.ShowCoreClientVersion(cmd.Context(), ui.NewUI(), cfg, mirror)
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func init() {
+	CmdClient.PersistentFlags().String("mirror", "", "Mirror of the fuseml-core releases (default: GitHub)")
+
+	for _, cmd := range []*cobra.Command{cmdClientInstall, cmdClientUpgrade} {
+		cmd.Flags().String("version", "", "Client version to install (default: the latest one compatible with the installer)")
+		cmd.Flags().Bool("skip-checksum", false, "Install the client even when its checksum is not published")
+		CmdClient.AddCommand(cmd)
+	}
+	cmdClientInstall.Flags().String("bin-dir", "", "Directory to install the client into (default: current directory)")
+	cmdClientUpgrade.Flags().String("bin-dir", "", "Directory to install the client into (default: the one it was installed into)")
+	CmdClient.AddCommand(cmdClientVersion)
+}
+
+func coreClientOptions(cmd *cobra.Command) (*config.Config, paas.CoreClientOptions, error) {
+	opts := paas.CoreClientOptions{}
+	cfg, err := config.Load(cmd.Flags())
+	if err != nil {
+		return nil, opts, errors.Wrap(err, "error loading the configuration")
+	}
+	if opts.Version, err = cmd.Flags().GetString("version"); err != nil {
+		return nil, opts, errors.Wrap(err, "could not read the version flag")
+	}
+	if opts.BinDir, err = cmd.Flags().GetString("bin-dir"); err != nil {
+		return nil, opts, errors.Wrap(err, "could not read the bin-dir flag")
+	}
+	if opts.Mirror, err = cmd.Flags().GetString("mirror"); err != nil {
+		return nil, opts, errors.Wrap(err, "could not read the mirror flag")
+	}
+	if opts.SkipChecksum, err = cmd.Flags().GetBool("skip-checksum"); err != nil {
+		return nil, opts, errors.Wrap(err, "could not read the skip-checksum flag")
+	}
+	opts.SkipChecksumFlag = "skip-checksum"
+	return cfg, opts, nil
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/paas/config"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// CmdConfig implements the fuseml-installer config command
+var CmdConfig = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the profiles in the configuration file",
+	Long: `Manage the named profiles holding the settings for the clusters with FuseML: the kubeconfig and its context,
+system_domain, extensions_repository, extensions and the TLS settings. The current profile (or the one given by
+--profile) is used by all commands, the command line flags take precedence over it.`,
+	// the profiles are managed here, not applied
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	SilenceErrors:     true,
+	SilenceUsage:      true,
+}
+
+var cmdConfigUse = &cobra.Command{
+	Use:   "use PROFILE",
+	Short: "Make the profile the current one",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cmd.Flags())
+		if err != nil {
+			return errors.Wrap(err, "error loading the configuration")
+		}
+		if err := cfg.UseProfile(args[0]); err != nil {
+			return err
+		}
+		ui.NewUI().Success().Msg(fmt.Sprintf("Using profile %s", cfg.CurrentProfile))
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+var cmdConfigGet = &cobra.Command{
+	Use:   "get [KEY]",
+	Short: "Show the settings of the profile",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cmd.Flags())
+		if err != nil {
+			return errors.Wrap(err, "error loading the configuration")
+		}
+		name, profile, err := cfg.Profile()
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			return errors.New("no profile selected, use the --profile flag")
+		}
+
+		keys := config.ProfileKeys
+		if len(args) == 1 {
+			keys = args
+		}
+		msg := ui.NewUI().Normal()
+		for _, key := range keys {
+			msg = msg.WithStringValue(key, profileValue(profile, key))
+		}
+		msg.Msg(fmt.Sprintf("Profile %s", name))
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+var cmdConfigSet = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Change the setting of the profile (empty value removes it)",
+	Long: fmt.Sprintf(`Change the setting of the current profile, or the one given by --profile (which is created when it does not exist).
+Empty value removes the setting, extensions are separated by commas. The settings are: %s.`, strings.Join(config.ProfileKeys, ", ")),
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cmd.Flags())
+		if err != nil {
+			return errors.Wrap(err, "error loading the configuration")
+		}
+		// the profile is removed together with its last setting, so it is not resolved again
+		name := cfg.SelectedProfileName()
+		if err := cfg.SetProfileValue(args[0], args[1]); err != nil {
+			return err
+		}
+		profile, ok := cfg.Profiles[name]
+		if !ok {
+			ui.NewUI().Success().Msg(fmt.Sprintf("Profile %s removed, it has no settings left", name))
+			return nil
+		}
+		ui.NewUI().Success().WithStringValue(args[0], profileValue(profile, args[0])).Msg(fmt.Sprintf("Profile %s updated", name))
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+var cmdConfigList = &cobra.Command{
+	Use:   "list",
+	Short: "List the profiles",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cmd.Flags())
+		if err != nil {
+			return errors.Wrap(err, "error loading the configuration")
+		}
+
+		msg := ui.NewUI().Normal().WithTable("Profile", "Current", "Kubeconfig", "Context", "System Domain")
+		for _, name := range cfg.ProfileNames() {
+			profile := cfg.Profiles[name]
+			current := ""
+			if name == cfg.CurrentProfile {
+				current = "*"
+			}
+			msg = msg.WithTableRow(name, current, profile.String("kubeconfig"), profile.String("context"), profile.String("system_domain"))
+		}
+		msg.Msg("Profiles:")
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func init() {
+	CmdConfig.AddCommand(cmdConfigUse)
+	CmdConfig.AddCommand(cmdConfigGet)
+	CmdConfig.AddCommand(cmdConfigSet)
+	CmdConfig.AddCommand(cmdConfigList)
+}
+
+// profileValue returns the setting as it is entered by config set
+func profileValue(profile config.Profile, key string) string {
+	switch value := profile[key].(type) {
+	case string:
+		return value
+	case []interface{}:
+		items := []string{}
+		for _, item := range value {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ",")
+	case []string:
+		return strings.Join(value, ",")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fuseml/fuseml/cli/paas"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// CmdDiagnose implements the fuseml-installer diagnose command
+var CmdDiagnose = &cobra.Command{
+	Use:   "diagnose",
+	Short: "Collect the diagnostic information about FuseML installation",
+	Long: `Collect the pod logs, events, helm releases, ingress and Istio objects, Tekton task runs of all FuseML namespaces and
+registered extensions, together with the installer configuration and platform information, into a single tarball.
+Passwords, tokens and other secrets are redacted.`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return errors.Wrap(err, "could not read the output flag")
+		}
+		if output == "" {
+			output = fmt.Sprintf("fuseml-diagnose-%s.tar.gz", time.Now().Format("20060102-150405"))
+		}
+
+		install_client, install_cleanup, err := paas.NewInstallClient(cmd.Flags(), nil)
+		defer func() {
+			if install_cleanup != nil {
+				install_cleanup()
+			}
+		}()
+
+		if err != nil {
+			return errors.Wrap(err, "error initializing cli")
+		}
+
+		err = install_client.Diagnose(cmd.Context(), output)
+		if err != nil {
+			return errors.Wrap(err, "error collecting the diagnostic information")
+		}
+
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func init() {
+	CmdDiagnose.Flags().StringP("output", "o", "", "Path of the support bundle (default fuseml-diagnose-<timestamp>.tar.gz)")
+}
@@ -14,6 +14,15 @@ var extensionsOptions = kubernetes.InstallationOptions{
 		Description: "The domain you are using for FuseML. Should be pointing to the load balancer public IP (Leave empty to use a nip.io domain).",
 		Type:        kubernetes.StringType,
 		Default:     "",
+		Validators:  []kubernetes.OptionValidator{kubernetes.DNSName()},
+		Value:       "",
+	},
+	{
+		Name:        "extensions_repository",
+		Description: "Path to extensions repository. Could be local directory or URL",
+		Type:        kubernetes.StringType,
+		Default:     config.DefaultExtensionsLocation(),
+		Validators:  []kubernetes.OptionValidator{kubernetes.URLOrDirectory()},
 		Value:       "",
 	},
 	{
@@ -52,15 +61,18 @@ var extensionsOptions = kubernetes.InstallationOptions{
 		Value:       true,
 	},
 	{
-		Name:        "extensions_repository",
-		Description: "Path to extensions repository. Could be local directory or URL",
-		Type:        kubernetes.StringType,
-		Default:     config.DefaultExtensionsLocation(),
-		Value:       "",
+		Name:        "ignore_compatibility",
+		Description: "Only warn about the versions incompatible with the installer, fuseml-core, Kubernetes or the extensions",
+		Type:        kubernetes.BooleanType,
+		Default:     false,
+		Value:       false,
 	},
 }
 
 func init() {
+	CmdExtensions.Flags().BoolP("interactive", "i", false, "Whether to ask the user or not (default not)")
+	CmdExtensions.Flags().String("answers-file", "", "Read the options from the answers saved by the interactive run")
+
 	extensionsOptions.AsCobraFlagsFor(CmdExtensions)
 }
 
@@ -86,7 +98,7 @@ func Extensions(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "error initializing cli")
 	}
 
-	err = install_client.Extensions(cmd, &extensionsOptions)
+	err = install_client.Extensions(cmd.Context(), cmd, &extensionsOptions)
 	if err != nil {
 		return errors.Wrap(err, "error when handling FuseML extensions")
 	}
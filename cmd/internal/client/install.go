@@ -1,6 +1,7 @@
 package client
 
 import (
+	"github.com/fuseml/fuseml/cli/deployments"
 	"github.com/fuseml/fuseml/cli/kubernetes"
 	"github.com/fuseml/fuseml/cli/paas"
 	"github.com/fuseml/fuseml/cli/paas/config"
@@ -14,6 +15,15 @@ var InstallOptions = kubernetes.InstallationOptions{
 		Description: "The domain you are planning to use for FuseML. Should be pointing to the load balancer public IP (Leave empty to use a nip.io domain).",
 		Type:        kubernetes.StringType,
 		Default:     "",
+		Validators:  []kubernetes.OptionValidator{kubernetes.DNSName()},
+		Value:       "",
+	},
+	{
+		Name:        "extensions_repository",
+		Description: "Path to extensions repository. Could be local directory or URL",
+		Type:        kubernetes.StringType,
+		Default:     config.DefaultExtensionsLocation(),
+		Validators:  []kubernetes.OptionValidator{kubernetes.URLOrDirectory()},
 		Value:       "",
 	},
 	{
@@ -24,10 +34,65 @@ var InstallOptions = kubernetes.InstallationOptions{
 		Value:       []string{},
 	},
 	{
-		Name:        "extensions_repository",
-		Description: "Path to extensions repository. Could be local directory or URL",
+		Name:        "tls",
+		Description: "TLS mode for FuseML endpoints: none, self-signed, custom (uses tls-cert-file and tls-key-file) or cert-manager (uses tls-issuer)",
 		Type:        kubernetes.StringType,
-		Default:     config.DefaultExtensionsLocation(),
+		Default:     "none",
+		Validators:  []kubernetes.OptionValidator{kubernetes.OneOf(deployments.TLSModeNone, deployments.TLSModeSelfSigned, deployments.TLSModeCustom, deployments.TLSModeCertManager)},
+		Value:       "",
+	},
+	{
+		Name:        "tls_cert_file",
+		Description: "Path to the PEM encoded wildcard certificate for the system domain (custom TLS mode)",
+		Type:        kubernetes.StringType,
+		Default:     "",
+		Validators:  []kubernetes.OptionValidator{kubernetes.FileExists(), kubernetes.RequiredWhen("tls", deployments.TLSModeCustom)},
+		Value:       "",
+	},
+	{
+		Name:        "tls_key_file",
+		Description: "Path to the PEM encoded key of the certificate (custom TLS mode)",
+		Type:        kubernetes.StringType,
+		Default:     "",
+		Validators:  []kubernetes.OptionValidator{kubernetes.FileExists(), kubernetes.RequiredWhen("tls", deployments.TLSModeCustom)},
+		Value:       "",
+	},
+	{
+		Name:        "tls_issuer",
+		Description: "Name of the cert-manager ClusterIssuer used to issue certificates (cert-manager TLS mode)",
+		Type:        kubernetes.StringType,
+		Default:     "",
+		Validators:  []kubernetes.OptionValidator{kubernetes.RequiredWhen("tls", deployments.TLSModeCertManager)},
+		Value:       "",
+	},
+	{
+		Name:        "mesh",
+		Description: "Istio service mesh: none, bundled (installed with FuseML) or existing (installed in the cluster beforehand, never modified by FuseML). Leave empty to detect",
+		Type:        kubernetes.StringType,
+		Default:     "",
+		Validators:  []kubernetes.OptionValidator{kubernetes.OneOf("", deployments.MeshNone, deployments.MeshBundled, deployments.MeshExisting)},
+		Value:       "",
+	},
+	{
+		Name:        "ingress",
+		Description: "Ingress used for exposing FuseML services: istio, traefik, nginx or existing (uses ingress-class and ingress-service). Leave empty to detect",
+		Type:        kubernetes.StringType,
+		Default:     "",
+		Validators:  []kubernetes.OptionValidator{kubernetes.OneOf("", deployments.IngressIstio, deployments.IngressTraefik, deployments.IngressNginx, deployments.IngressExisting)},
+		Value:       "",
+	},
+	{
+		Name:        "ingress_class",
+		Description: "IngressClass of the existing ingress controller (Leave empty to use the cluster default)",
+		Type:        kubernetes.StringType,
+		Default:     "",
+		Value:       "",
+	},
+	{
+		Name:        "ingress_service",
+		Description: "Name of the LoadBalancer service of the existing ingress controller, used for detecting system_domain",
+		Type:        kubernetes.StringType,
+		Default:     "",
 		Value:       "",
 	},
 	{
@@ -37,6 +102,13 @@ var InstallOptions = kubernetes.InstallationOptions{
 		Default:     false,
 		Value:       true,
 	},
+	{
+		Name:        "ignore_compatibility",
+		Description: "Only warn about the versions incompatible with the installer, fuseml-core, Kubernetes or the extensions",
+		Type:        kubernetes.BooleanType,
+		Default:     false,
+		Value:       false,
+	},
 }
 
 const (
@@ -55,6 +127,12 @@ var CmdInstall = &cobra.Command{
 
 func init() {
 	CmdInstall.Flags().BoolP("interactive", "i", false, "Whether to ask the user or not (default not)")
+	CmdInstall.Flags().String("answers-file", "", "Read the options from the answers saved by the interactive installation")
+	CmdInstall.Flags().Bool("skip-preflight", false, "Do not run the preflight checks before installing")
+	CmdInstall.Flags().Bool("resume", false, "Continue the interrupted installation, skipping the steps it completed (after verifying them)")
+	CmdInstall.Flags().String("client-bin-dir", "", "Directory to install the fuseml command line client into (default: current directory)")
+	CmdInstall.Flags().String("client-mirror", "", "Mirror of the fuseml-core releases to download the command line client from (default: GitHub)")
+	CmdInstall.Flags().Bool("client-skip-checksum", false, "Install the command line client even when its checksum is not published")
 
 	InstallOptions.AsCobraFlagsFor(CmdInstall)
 }
@@ -72,7 +150,7 @@ func Install(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "error initializing cli")
 	}
 
-	err = install_client.Install(cmd, &InstallOptions)
+	err = install_client.Install(cmd.Context(), cmd, &InstallOptions)
 	if err != nil {
 		return errors.Wrap(err, "error installing FuseML")
 	}
@@ -1 +1,126 @@
 package client
+
+import (
+	"fmt"
+
+	"github.com/fuseml/fuseml/cli/paas"
+	"github.com/fuseml/fuseml/cli/paas/login"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// CmdLogin implements the fuseml-installer login command
+var CmdLogin = &cobra.Command{
+	Use:   "login",
+	Short: "Point the fuseml client to the FuseML server installed in the cluster",
+	Long: `Find the URL of the FuseML server installed in the cluster, check that it responds and write it, with the Gitea URL
+and the organization, to the configuration file of the fuseml client. The servers are stored under their names (the
+profile name or the system domain by default), so the client can be switched between them by 'login use'.`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := paas.LoginOptions{}
+		var err error
+		if opts.ConfigFile, err = cmd.Flags().GetString("client-config"); err != nil {
+			return errors.Wrap(err, "could not read the client-config flag")
+		}
+		if opts.Name, err = cmd.Flags().GetString("name"); err != nil {
+			return errors.Wrap(err, "could not read the name flag")
+		}
+		if opts.URL, err = cmd.Flags().GetString("url"); err != nil {
+			return errors.Wrap(err, "could not read the url flag")
+		}
+		if opts.Insecure, err = cmd.Flags().GetBool("insecure-skip-tls-verify"); err != nil {
+			return errors.Wrap(err, "could not read the insecure-skip-tls-verify flag")
+		}
+
+		install_client, install_cleanup, err := paas.NewInstallClient(cmd.Flags(), nil)
+		defer func() {
+			if install_cleanup != nil {
+				install_cleanup()
+			}
+		}()
+
+		if err != nil {
+			return errors.Wrap(err, "error initializing cli")
+		}
+
+		err = install_client.Login(cmd.Context(), opts)
+		if err != nil {
+			return errors.Wrap(err, "error logging in to FuseML server")
+		}
+
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+var cmdLoginUse = &cobra.Command{
+	Use:   "use NAME",
+	Short: "Switch the fuseml client to the stored server",
+	Args:  cobra.ExactArgs(1),
+	// the cluster is not needed
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadClientConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if err := cfg.Use(args[0]); err != nil {
+			return err
+		}
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+		ui.NewUI().Success().WithStringValue("Server", cfg.URL).Msg(fmt.Sprintf("The fuseml client uses the server %s", cfg.CurrentServer))
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+var cmdLoginList = &cobra.Command{
+	Use:               "list",
+	Short:             "List the servers stored in the fuseml client configuration",
+	Args:              cobra.ExactArgs(0),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadClientConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		msg := ui.NewUI().Normal().WithTable("Name", "Current", "Server", "Gitea", "Organization")
+		for _, name := range cfg.ServerNames() {
+			server := cfg.Servers[name]
+			current := ""
+			if name == cfg.CurrentServer {
+				current = "*"
+			}
+			msg = msg.WithTableRow(name, current, server.URL, server.GiteaURL, server.Org)
+		}
+		msg.Msg("Servers:")
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func init() {
+	CmdLogin.PersistentFlags().String("client-config", login.DefaultLocation(), "Path of the fuseml client configuration file")
+	CmdLogin.Flags().String("name", "", "Name to store the server under (default: the profile name or the system domain)")
+	CmdLogin.Flags().String("url", "", "URL of the FuseML server (default: discovered in the cluster)")
+	CmdLogin.Flags().Bool("insecure-skip-tls-verify", false, "Do not verify the server certificate, e.g. the self-signed one")
+
+	CmdLogin.AddCommand(cmdLoginUse)
+	CmdLogin.AddCommand(cmdLoginList)
+}
+
+func loadClientConfig(cmd *cobra.Command) (*login.ClientConfig, error) {
+	path, err := cmd.Flags().GetString("client-config")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the client-config flag")
+	}
+	return login.Load(path)
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// PreflightOptions are the install options the preflight checks depend on
+var PreflightOptions = kubernetes.InstallationOptions{}
+
+// CmdPreflight implements the fuseml-installer preflight command
+var CmdPreflight = &cobra.Command{
+	Use:   "preflight",
+	Short: "Check whether FuseML can be installed in your configured kubernetes cluster",
+	Long:  `Check the Kubernetes version, permissions, storage, load balancer, service mesh, ingress, node capacity and the required local tools.`,
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		install_client, install_cleanup, err := paas.NewInstallClient(cmd.Flags(), nil)
+		defer func() {
+			if install_cleanup != nil {
+				install_cleanup()
+			}
+		}()
+
+		if err != nil {
+			return errors.Wrap(err, "error initializing cli")
+		}
+
+		options, err := PreflightOptions.Populate(kubernetes.NewCLIOptionsReader(cmd))
+		if err != nil {
+			return err
+		}
+
+		err = install_client.Preflight(cmd.Context(), *options)
+		if err != nil {
+			return errors.Wrap(err, "FuseML can't be installed")
+		}
+
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func init() {
+	for _, opt := range InstallOptions {
+		switch opt.Name {
+		case "system_domain", "mesh", "ingress", "ingress_class", "ingress_service":
+			PreflightOptions = append(PreflightOptions, opt)
+		}
+	}
+	PreflightOptions.AsCobraFlagsFor(CmdPreflight)
+}
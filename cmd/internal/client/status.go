@@ -0,0 +1,35 @@
+package client
+
+import (
+	"github.com/fuseml/fuseml/cli/paas"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// CmdStatus implements the fuseml-installer status command
+var CmdStatus = &cobra.Command{
+	Use:   "status",
+	Short: "Show the installed FuseML components and the permissions granted to the workloads",
+	Long: `Show which FuseML components are installed, and the rules of the workloads service account
+together with the extensions which granted them.`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		install_client, install_cleanup, err := paas.NewInstallClient(cmd.Flags(), nil)
+		defer func() {
+			if install_cleanup != nil {
+				install_cleanup()
+			}
+		}()
+
+		if err != nil {
+			return errors.Wrap(err, "error initializing cli")
+		}
+
+		if err := install_client.Status(cmd.Context()); err != nil {
+			return errors.Wrap(err, "error retrieving FuseML status")
+		}
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
@@ -1,6 +1,9 @@
 package client
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/fuseml/fuseml/cli/kubernetes"
 	"github.com/fuseml/fuseml/cli/paas"
 	"github.com/fuseml/fuseml/cli/paas/config"
@@ -14,6 +17,15 @@ var uninstallOptions = kubernetes.InstallationOptions{
 		Description: "The domain used by FuseML. Should be pointing to the load balancer public IP (Leave empty to use a nip.io domain).",
 		Type:        kubernetes.StringType,
 		Default:     "",
+		Validators:  []kubernetes.OptionValidator{kubernetes.DNSName()},
+		Value:       "",
+	},
+	{
+		Name:        "extensions_repository",
+		Description: "Path to extensions repository. Could be local directory or URL",
+		Type:        kubernetes.StringType,
+		Default:     config.DefaultExtensionsLocation(),
+		Validators:  []kubernetes.OptionValidator{kubernetes.URLOrDirectory()},
 		Value:       "",
 	},
 	{
@@ -24,11 +36,19 @@ var uninstallOptions = kubernetes.InstallationOptions{
 		Value:       []string{},
 	},
 	{
-		Name:        "extensions_repository",
-		Description: "Path to extensions repository. Could be local directory or URL",
-		Type:        kubernetes.StringType,
-		Default:     config.DefaultExtensionsLocation(),
-		Value:       "",
+		Name:        "keep_data",
+		Description: "Keep the Gitea repositories and Registry images (their persistent volumes) for the next installation",
+		Type:        kubernetes.BooleanType,
+		Default:     false,
+		Value:       false,
+	},
+	{
+		Name:        "components",
+		Description: fmt.Sprintf("Remove only the listed components (%s), keeping the rest of FuseML", strings.Join(paas.ComponentNames(), ", ")),
+		Type:        kubernetes.ListType,
+		Default:     []string{},
+		Validators:  []kubernetes.OptionValidator{kubernetes.SubsetOf(paas.ComponentNames()...)},
+		Value:       []string{},
 	},
 }
 
@@ -43,6 +63,9 @@ var CmdUninstall = &cobra.Command{
 }
 
 func init() {
+	CmdUninstall.Flags().BoolP("interactive", "i", false, "Whether to ask the user or not (default not)")
+	CmdUninstall.Flags().String("answers-file", "", "Read the options from the answers saved by the interactive uninstallation")
+
 	uninstallOptions.AsCobraFlagsFor(CmdUninstall)
 }
 
@@ -53,7 +76,7 @@ func Uninstall(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "error initializing cli")
 	}
 
-	err = installClient.Uninstall(cmd, &uninstallOptions)
+	err = installClient.Uninstall(cmd.Context(), cmd, &uninstallOptions)
 	if err != nil {
 		return err
 	}
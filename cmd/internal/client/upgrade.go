@@ -13,8 +13,16 @@ var upgradeOptions = kubernetes.InstallationOptions{
 		Description: "The domain you are planning to use for FuseML. Should be pointing to the load balancer public IP (Leave empty to use a nip.io domain).",
 		Type:        kubernetes.StringType,
 		Default:     "",
+		Validators:  []kubernetes.OptionValidator{kubernetes.DNSName()},
 		Value:       "",
 	},
+	{
+		Name:        "ignore_compatibility",
+		Description: "Only warn about the versions incompatible with the installer, fuseml-core, Kubernetes or the extensions",
+		Type:        kubernetes.BooleanType,
+		Default:     false,
+		Value:       false,
+	},
 }
 
 func init() {
@@ -43,7 +51,7 @@ func Upgrade(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "error initializing cli")
 	}
 
-	err = install_client.Upgrade(cmd, &upgradeOptions)
+	err = install_client.Upgrade(cmd.Context(), cmd, &upgradeOptions)
 	if err != nil {
 		return errors.Wrap(err, "error upgrading FuseML")
 	}
@@ -1,23 +1,36 @@
 package cmd
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/fuseml/fuseml/cli/cmd/internal/client"
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
 	"github.com/fuseml/fuseml/cli/kubernetes/config"
+	"github.com/fuseml/fuseml/cli/paas/audit"
 	pconfig "github.com/fuseml/fuseml/cli/paas/config"
 	"github.com/fuseml/fuseml/cli/paas/version"
 	"github.com/kyokomi/emoji"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
 	flagConfigFile string
+	flagLogFile    string
+	flagProfile    string
 	kubeconfig     string
+
+	// removes the kubeconfig written for the context of the profile
+	kubeconfigCleanup = func() {}
 )
 
 // Execute adds all child commands to the root command sets flags appropriately.
@@ -26,13 +39,16 @@ func Execute() {
 	ExitfIfError(checkDependencies(), "Cannot operate")
 
 	rootCmd := &cobra.Command{
-		Use:           "fuseml-installer",
-		Short:         "FuseML installer",
-		Long:          `fuseml-installer cli is the official installation tool for FuseML `,
-		Version:       version.Version,
-		SilenceErrors: true,
+		Use:               "fuseml-installer",
+		Short:             "FuseML installer",
+		Long:              `fuseml-installer cli is the official installation tool for FuseML `,
+		Version:           version.Version,
+		SilenceErrors:     true,
+		PersistentPreRunE: applyProfile,
 	}
 
+	cobra.OnInitialize(openAuditLog)
+
 	pf := rootCmd.PersistentFlags()
 	argToEnv := map[string]string{}
 
@@ -41,6 +57,16 @@ func Execute() {
 	viper.BindPFlag("config-file", pf.Lookup("config-file"))
 	argToEnv["config-file"] = "FUSEML_CONFIG"
 
+	pf.StringVarP(&flagLogFile, "log-file", "", "",
+		"set path of the log file recording the commands and changes made by the installer (default: logs directory next to the configuration file)")
+	viper.BindPFlag("log-file", pf.Lookup("log-file"))
+	argToEnv["log-file"] = "FUSEML_LOG_FILE"
+
+	pf.StringVarP(&flagProfile, "profile", "", "",
+		"use the named profile from the configuration file (default: the current profile, see 'config use')")
+	viper.BindPFlag("profile", pf.Lookup("profile"))
+	argToEnv["profile"] = "FUSEML_PROFILE"
+
 	config.KubeConfigFlags(pf, argToEnv)
 	config.LoggerFlags(pf, argToEnv)
 
@@ -52,18 +78,119 @@ func Execute() {
 
 	rootCmd.AddCommand(CmdCompletion)
 	rootCmd.AddCommand(client.CmdInstall)
+	rootCmd.AddCommand(client.CmdPreflight)
+	rootCmd.AddCommand(client.CmdDiagnose)
 	rootCmd.AddCommand(client.CmdUninstall)
+	rootCmd.AddCommand(client.CmdCleanup)
 	rootCmd.AddCommand(client.CmdUpgrade)
 	rootCmd.AddCommand(client.CmdExtensions)
 	rootCmd.AddCommand(client.CmdInfo)
+	rootCmd.AddCommand(client.CmdStatus)
 	rootCmd.AddCommand(client.CmdVersion)
+	rootCmd.AddCommand(client.CmdConfig)
+	rootCmd.AddCommand(client.CmdLogin)
+	rootCmd.AddCommand(client.CmdClient)
 
-	if err := rootCmd.Execute(); err != nil {
+	ctx, cancel := interruptibleContext()
+	defer cancel()
+
+	start := time.Now()
+	err := rootCmd.ExecuteContext(ctx)
+	kubeconfigCleanup()
+	audit.Finish(time.Since(start), err)
+	logFile := audit.Path()
+	audit.Close()
+
+	if err != nil {
 		fmt.Println(err)
+		if logFile != "" {
+			fmt.Println(emoji.Sprintf(":scroll:See %s for the details of what was done", logFile))
+		}
 		os.Exit(-1)
 	}
 }
 
+// openAuditLog starts writing the log of the installer run. It is called for every command
+// after the flags are parsed, also for the commands overriding PersistentPreRunE.
+// The run continues without the log when it can't be written.
+func openAuditLog() {
+	// nothing is done for the shell completion requests
+	if len(os.Args) > 1 && (os.Args[1] == cobra.ShellCompRequestCmd || os.Args[1] == cobra.ShellCompNoDescRequestCmd) {
+		return
+	}
+	logFile := viper.GetString("log-file")
+	if logFile == "" {
+		logFile = audit.DefaultLocation(viper.GetString("config-file"), time.Now())
+	}
+	if err := audit.Open(logFile); err != nil {
+		fmt.Println(emoji.Sprintf(":warning:Not writing the log file: %s", err))
+		return
+	}
+	helpers.CommandHook = audit.Command
+	kubernetes.TransportHook = func(rt http.RoundTripper) http.RoundTripper {
+		return audit.Transport("k8s", true, rt)
+	}
+	audit.Start(os.Args)
+}
+
+// applyProfile selects the cluster of the profile (unless --kubeconfig is given). The kubeconfig
+// is passed to kubectl and helm too. The installation options of the profile are used by the
+// commands themselves.
+func applyProfile(cmd *cobra.Command, args []string) error {
+	cfg, err := pconfig.Load(cmd.Flags())
+	if err != nil {
+		return err
+	}
+	name, profile, err := cfg.Profile()
+	if err != nil {
+		return err
+	}
+
+	if name != "" && !cmd.Flags().Changed("kubeconfig") {
+		if path := profile.String("kubeconfig"); path != "" {
+			viper.Set("kubeconfig", os.ExpandEnv(path))
+		}
+		if context := profile.String("context"); context != "" {
+			path, cleanup, err := config.WithContext(viper.GetString("kubeconfig"), context)
+			if err != nil {
+				return errors.Wrapf(err, "can't use the context of profile '%s'", name)
+			}
+			kubeconfigCleanup = cleanup
+			viper.Set("kubeconfig", path)
+		}
+	}
+
+	if path := viper.GetString("kubeconfig"); path != "" {
+		os.Setenv("KUBECONFIG", path)
+	}
+	return nil
+}
+
+// interruptibleContext returns a context cancelled by the first Ctrl-C (or SIGTERM),
+// so the running step can stop cleanly. The second one exits immediately.
+func interruptibleContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			fmt.Println(emoji.Sprint("\n:stop_sign:Interrupted, stopping the running step (press Ctrl-C again to exit immediately)..."))
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+		<-signals
+		os.Exit(130)
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
 func checkDependencies() error {
 	ok := true
 
@@ -0,0 +1,106 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The installer records each completed installation step (the time it was completed)
+// in a ConfigMap next to its settings, so an interrupted installation can be resumed.
+const checkpointsConfigMapName = "fuseml-install-checkpoints"
+
+// ExtensionCheckpoint returns the step recorded when the extension is installed and registered
+func ExtensionCheckpoint(name string) string {
+	return "extension-" + name
+}
+
+// extensionStepCheckpoint returns the step recorded when the install step (by its index) of the extension is completed
+func extensionStepCheckpoint(name string, step int) string {
+	return fmt.Sprintf("%s.step-%d", ExtensionCheckpoint(name), step)
+}
+
+// Checkpoints are the installation steps completed so far. All methods can be called
+// on nil Checkpoints, which do not record anything.
+type Checkpoints struct {
+	cluster   *kubernetes.Cluster
+	completed map[string]string
+}
+
+// LoadCheckpoints reads the installation steps completed by the previous runs of the installer
+func LoadCheckpoints(ctx context.Context, c *kubernetes.Cluster) (*Checkpoints, error) {
+	checkpoints := &Checkpoints{cluster: c, completed: map[string]string{}}
+
+	cm, err := c.Kubectl.CoreV1().ConfigMaps(systemNamespace).Get(ctx, checkpointsConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return checkpoints, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading installation checkpoints")
+	}
+	for step, completed := range cm.Data {
+		checkpoints.completed[step] = completed
+	}
+	return checkpoints, nil
+}
+
+// Empty returns true when no step was completed yet
+func (cp *Checkpoints) Empty() bool {
+	return cp == nil || len(cp.completed) == 0
+}
+
+// Completed returns true when the step was recorded as completed
+func (cp *Checkpoints) Completed(step string) bool {
+	if cp == nil {
+		return false
+	}
+	_, ok := cp.completed[step]
+	return ok
+}
+
+// Record marks the step as completed
+func (cp *Checkpoints) Record(ctx context.Context, step string) error {
+	if cp == nil {
+		return nil
+	}
+	if err := createSystemNamespace(ctx, cp.cluster); err != nil {
+		return errors.Wrapf(err, "failed creating namespace %s", systemNamespace)
+	}
+	cp.completed[step] = time.Now().UTC().Format(time.RFC3339)
+
+	configMaps := cp.cluster.Kubectl.CoreV1().ConfigMaps(systemNamespace)
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: checkpointsConfigMapName,
+		},
+		Data: cp.completed,
+	}
+	_, err := configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed recording completion of %s", step)
+	}
+	return nil
+}
+
+// Reset forgets all completed steps, so the installation starts from the beginning
+func (cp *Checkpoints) Reset(ctx context.Context) error {
+	if cp == nil {
+		return nil
+	}
+	cp.completed = map[string]string{}
+
+	err := cp.cluster.Kubectl.CoreV1().ConfigMaps(systemNamespace).Delete(ctx, checkpointsConfigMapName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed removing installation checkpoints")
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package deployments_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Checkpoints", func() {
+	var (
+		ctx     context.Context
+		kube    *fake.Clientset
+		cluster *kubernetes.Cluster
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		kube = fake.NewSimpleClientset()
+		cluster = &kubernetes.Cluster{Kubectl: kube}
+	})
+
+	It("starts empty", func() {
+		checkpoints, err := LoadCheckpoints(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoints.Empty()).To(BeTrue())
+		Expect(checkpoints.Completed("gitea")).To(BeFalse())
+	})
+
+	It("keeps the completed steps for the next run", func() {
+		checkpoints, err := LoadCheckpoints(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoints.Record(ctx, "istio")).To(Succeed())
+		Expect(checkpoints.Record(ctx, "gitea")).To(Succeed())
+
+		checkpoints, err = LoadCheckpoints(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoints.Empty()).To(BeFalse())
+		Expect(checkpoints.Completed("istio")).To(BeTrue())
+		Expect(checkpoints.Completed("gitea")).To(BeTrue())
+		Expect(checkpoints.Completed("registry")).To(BeFalse())
+
+		cm, err := kube.CoreV1().ConfigMaps("fuseml-system").Get(ctx, "fuseml-install-checkpoints", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cm.Data).To(HaveKey("gitea"))
+	})
+
+	It("forgets the completed steps on reset", func() {
+		checkpoints, err := LoadCheckpoints(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoints.Record(ctx, "istio")).To(Succeed())
+		Expect(checkpoints.Reset(ctx)).To(Succeed())
+		Expect(checkpoints.Completed("istio")).To(BeFalse())
+
+		checkpoints, err = LoadCheckpoints(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoints.Empty()).To(BeTrue())
+	})
+
+	It("records nothing when nil", func() {
+		var checkpoints *Checkpoints
+		Expect(checkpoints.Record(ctx, "istio")).To(Succeed())
+		Expect(checkpoints.Completed("istio")).To(BeFalse())
+		Expect(checkpoints.Empty()).To(BeTrue())
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
 
 	"github.com/fuseml/fuseml/cli/helpers"
 	"github.com/fuseml/fuseml/cli/kubernetes"
@@ -11,10 +12,8 @@ import (
 	"github.com/kyokomi/emoji"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
-	v1beta1 "k8s.io/api/extensions/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type Core struct {
@@ -27,36 +26,40 @@ const (
 	// The deployment ID used for the fuseml-core service
 	CoreDeploymentID        = "fuseml-core"
 	coreDeploymentNamespace = "fuseml-core"
-	coreIngressName         = "fuseml-core-ingress"
 	coreServiceName         = "fuseml-core"
 	coreServicePort         = 80
 	coreSecretName          = "fuseml-core-gitea"
 	coreConfigMapName       = "config-fuseml-core"
 	coreDeploymentYamlPath  = "fuseml-core-deployment.yaml"
-	coreVersion             = "0.1"
+	coreImage               = "ghcr.io/fuseml/fuseml-core"
+
+	// name of the core ingress created by the older installers
+	legacyCoreIngressName = "fuseml-core-ingress"
 )
 
+var coreImagePattern = regexp.MustCompile(`image:\s*` + regexp.QuoteMeta(coreImage) + `:(\S+)`)
+
 func (core *Core) ID() string {
 	return CoreDeploymentID
 }
 
-func (core *Core) Backup(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (core *Core) Backup(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
-func (core *Core) Restore(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (core *Core) Restore(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
 func (core Core) Describe() string {
-	return emoji.Sprintf(":cloud:Core version: %s", coreVersion)
+	return emoji.Sprintf(":cloud:Core version: %s", core.GetVersion())
 }
 
 // Delete removes Core component from kubernetes cluster
-func (core Core) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
+func (core Core) Delete(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
 	ui.Note().KeeplineUnder(1).Msg("Removing Core component...")
 
-	existsAndOwned, err := c.NamespaceExistsAndOwned(coreDeploymentNamespace)
+	existsAndOwned, err := c.NamespaceExistsAndOwned(ctx, coreDeploymentNamespace)
 	if err != nil {
 		return errors.Wrapf(err, "failed to check if namespace '%s' is owned or not", coreDeploymentNamespace)
 	}
@@ -65,21 +68,21 @@ func (core Core) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 		return nil
 	}
 
-	if out, err := helpers.KubectlDeleteEmbeddedYaml(coreDeploymentYamlPath, true); err != nil {
+	if out, err := helpers.KubectlDeleteEmbeddedYaml(ctx, coreDeploymentYamlPath, true); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Deleting %s failed:\n%s", coreDeploymentYamlPath, out))
 	}
-	if err = c.Kubectl.CoreV1().Secrets(coreDeploymentNamespace).Delete(context.Background(), coreSecretName, metav1.DeleteOptions{}); err != nil {
+	if err = c.Kubectl.CoreV1().Secrets(coreDeploymentNamespace).Delete(ctx, coreSecretName, metav1.DeleteOptions{}); err != nil {
 		return errors.Wrapf(err, "Failed deleting secret %s", coreSecretName)
 	}
 
-	if err = c.Kubectl.CoreV1().ConfigMaps(coreDeploymentNamespace).Delete(context.Background(), coreConfigMapName, metav1.DeleteOptions{}); err != nil {
+	if err = c.Kubectl.CoreV1().ConfigMaps(coreDeploymentNamespace).Delete(ctx, coreConfigMapName, metav1.DeleteOptions{}); err != nil {
 		return errors.Wrapf(err, "Failed deleting configMap %s", coreConfigMapName)
 	}
 
 	message := "Deleting Core component namespace " + coreDeploymentNamespace
 	_, err = helpers.WaitForCommandCompletion(ui, message,
 		func() (string, error) {
-			return "", c.DeleteNamespace(coreDeploymentNamespace)
+			return "", c.DeleteNamespace(ctx, coreDeploymentNamespace)
 		},
 	)
 	if err != nil {
@@ -92,7 +95,7 @@ func (core Core) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 			var err error
 			for err == nil {
 				_, err = c.Kubectl.CoreV1().Namespaces().Get(
-					context.Background(),
+					ctx,
 					coreDeploymentNamespace,
 					metav1.GetOptions{},
 				)
@@ -118,40 +121,10 @@ func (core Core) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	return nil
 }
 
-func createCoreIngress(c *kubernetes.Cluster, subdomain string) error {
-	_, err := c.Kubectl.ExtensionsV1beta1().Ingresses(coreDeploymentNamespace).Create(
-		context.Background(),
-		&v1beta1.Ingress{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      coreIngressName,
-				Namespace: coreDeploymentNamespace,
-				Annotations: map[string]string{
-					"kubernetes.io/ingress.class": "traefik",
-				},
-			},
-			Spec: v1beta1.IngressSpec{
-				Rules: []v1beta1.IngressRule{{
-					Host: subdomain,
-					IngressRuleValue: v1beta1.IngressRuleValue{
-						HTTP: &v1beta1.HTTPIngressRuleValue{
-							Paths: []v1beta1.HTTPIngressPath{{
-								Path: "/",
-								Backend: v1beta1.IngressBackend{
-									ServiceName: coreServiceName,
-									ServicePort: intstr.IntOrString{
-										Type:   intstr.Int,
-										IntVal: 80,
-									},
-								}}}}}}}}},
-		metav1.CreateOptions{},
-	)
-	return err
-}
-
 // Create kubernetes namespace for core component
-func (core Core) createNamespace(c *kubernetes.Cluster, ui *ui.UI) error {
+func (core Core) createNamespace(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
 	if _, err := c.Kubectl.CoreV1().Namespaces().Create(
-		context.Background(),
+		ctx,
 		&corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: coreDeploymentNamespace,
@@ -165,19 +138,19 @@ func (core Core) createNamespace(c *kubernetes.Cluster, ui *ui.UI) error {
 		return nil
 	}
 
-	if err := c.LabelNamespace(coreDeploymentNamespace, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue); err != nil {
+	if err := c.LabelNamespace(ctx, coreDeploymentNamespace, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (core *Core) fetchGiteaCredSecret(c *kubernetes.Cluster) error {
+func (core *Core) fetchGiteaCredSecret(ctx context.Context, c *kubernetes.Cluster) error {
 	if core.giteaCredSecret != nil {
 		return nil
 	}
 	giteaCredSecret, err := c.Kubectl.CoreV1().Secrets("fuseml-workloads").
-		Get(context.Background(), "gitea-creds", metav1.GetOptions{})
+		Get(ctx, "gitea-creds", metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
@@ -188,11 +161,11 @@ func (core *Core) fetchGiteaCredSecret(c *kubernetes.Cluster) error {
 // Create secret to store gitea credentials required by fuseml-core deployment
 // It is possible for user to provide access to different gitea instance than the one deployed by fuseml-installer,
 // however if GITEA_ADMIN_USERNAME, GITEA_ADMIN_PASSWORD, GITEA_URL variables are not set, internal gitea will be used
-func (core Core) createCoreCredsSecret(c *kubernetes.Cluster) error {
+func (core Core) createCoreCredsSecret(ctx context.Context, c *kubernetes.Cluster) error {
 
 	giteaUsername, exists := os.LookupEnv("GITEA_ADMIN_USERNAME")
 	if !exists {
-		if err := core.fetchGiteaCredSecret(c); err != nil {
+		if err := core.fetchGiteaCredSecret(ctx, c); err != nil {
 			return errors.Wrap(err, "value for gitea admin user name (GITEA_ADMIN_USERNAME) was not provided neither found in installed gitea instance")
 		}
 		giteaUsername = string(core.giteaCredSecret.Data["username"])
@@ -200,13 +173,13 @@ func (core Core) createCoreCredsSecret(c *kubernetes.Cluster) error {
 
 	giteaPassword, exists := os.LookupEnv("GITEA_ADMIN_PASSWORD")
 	if !exists {
-		if err := core.fetchGiteaCredSecret(c); err != nil {
+		if err := core.fetchGiteaCredSecret(ctx, c); err != nil {
 			return errors.Wrap(err, "value for gitea admin user password (GITEA_ADMIN_PASSWORD) was not provided neither found in installed gitea instance")
 		}
 		giteaPassword = string(core.giteaCredSecret.Data["password"])
 	}
 
-	_, err := c.Kubectl.CoreV1().Secrets(coreDeploymentNamespace).Create(context.Background(),
+	_, err := c.Kubectl.CoreV1().Secrets(coreDeploymentNamespace).Create(ctx,
 		&corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: coreSecretName,
@@ -224,18 +197,19 @@ func (core Core) createCoreCredsSecret(c *kubernetes.Cluster) error {
 	return nil
 }
 
-func (core Core) createCoreConfigMap(c *kubernetes.Cluster, domain string) error {
+func (core Core) createCoreConfigMap(ctx context.Context, c *kubernetes.Cluster, domain string) error {
 
+	scheme := URLScheme(ctx, c)
 	giteaURL, exists := os.LookupEnv("GITEA_URL")
 	if !exists {
-		giteaURL = "http://gitea." + domain
+		giteaURL = scheme + "://gitea." + domain
 	}
 	tektonURL, exists := os.LookupEnv("TEKTON_DASHBOARD_URL")
 	if !exists {
-		tektonURL = "http://tekton." + domain
+		tektonURL = scheme + "://tekton." + domain
 	}
 
-	_, err := c.Kubectl.CoreV1().ConfigMaps(coreDeploymentNamespace).Create(context.Background(),
+	_, err := c.Kubectl.CoreV1().ConfigMaps(coreDeploymentNamespace).Create(ctx,
 		&corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: coreConfigMapName,
@@ -254,7 +228,7 @@ func (core Core) createCoreConfigMap(c *kubernetes.Cluster, domain string) error
 }
 
 // Create fuseml-core deployment using the embedded template
-func (core *Core) createCoreDeployment() error {
+func (core *Core) createCoreDeployment(ctx context.Context) error {
 
 	yamlPathOnDisk, err := helpers.ExtractFile(coreDeploymentYamlPath)
 	if err != nil {
@@ -262,7 +236,7 @@ func (core *Core) createCoreDeployment() error {
 	}
 	defer os.Remove(yamlPathOnDisk)
 
-	out, err := helpers.Kubectl(fmt.Sprintf("apply --filename %s", yamlPathOnDisk))
+	out, err := helpers.Kubectl(ctx, fmt.Sprintf("apply --filename %s", yamlPathOnDisk))
 
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("kubectl apply failed:\n%s", out))
@@ -271,25 +245,12 @@ func (core *Core) createCoreDeployment() error {
 }
 
 // Install fuseml-core component
-func (core Core) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
+func (core Core) apply(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
 	if !upgrade {
-		if err := core.createNamespace(c, ui); err != nil {
+		if err := core.createNamespace(ctx, c, ui); err != nil {
 			return errors.Wrap(err, "Failed creating namespace for Core component")
 		}
 	}
-	_, err := c.Kubectl.AppsV1().Deployments(coreDeploymentNamespace).Get(
-		context.Background(),
-		CoreDeploymentID,
-		metav1.GetOptions{})
-
-	if !upgrade && err == nil {
-
-		ui.Exclamation().Msg(
-			fmt.Sprintf("%s already present under %s namespace, skipping installation",
-				CoreDeploymentID, coreDeploymentNamespace))
-		return nil
-	}
-
 	domain, err := options.GetString("system_domain", CoreDeploymentID)
 	if err != nil {
 		return err
@@ -297,82 +258,137 @@ func (core Core) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Inst
 	subdomain := CoreDeploymentID + "." + domain
 
 	// delete existing secret and configMap to ensure we have the latest one after upgrade
-	if upgrade {
-		err = c.Kubectl.CoreV1().Secrets(coreDeploymentNamespace).Delete(context.Background(), coreSecretName, metav1.DeleteOptions{})
-		if err != nil && !apierrors.IsNotFound(err) {
-			return errors.Wrapf(err, "Failed deleting secret %s", coreSecretName)
-		}
-		err = c.Kubectl.CoreV1().ConfigMaps(coreDeploymentNamespace).Delete(context.Background(), coreConfigMapName, metav1.DeleteOptions{})
-		if err != nil && !apierrors.IsNotFound(err) {
-			return errors.Wrapf(err, "Failed deleting configMap %s", coreConfigMapName)
-		}
+	// (or after the interrupted installation)
+	err = c.Kubectl.CoreV1().Secrets(coreDeploymentNamespace).Delete(ctx, coreSecretName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "Failed deleting secret %s", coreSecretName)
+	}
+	err = c.Kubectl.CoreV1().ConfigMaps(coreDeploymentNamespace).Delete(ctx, coreConfigMapName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "Failed deleting configMap %s", coreConfigMapName)
 	}
 
-	if err := core.createCoreCredsSecret(c); err != nil {
+	if err := core.createCoreCredsSecret(ctx, c); err != nil {
 		return errors.Wrap(err, "Failed creating secret for Core component")
 	}
-	if err := core.createCoreConfigMap(c, domain); err != nil {
+	if err := core.createCoreConfigMap(ctx, c, domain); err != nil {
 		return errors.Wrap(err, "Failed creating configMap for Core component")
 	}
 
 	// create new deployment or upgrade existing one
-	if err := core.createCoreDeployment(); err != nil {
+	if err := core.createCoreDeployment(ctx); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Installing %s failed", coreDeploymentYamlPath))
 	}
+	if err := labelEmbeddedYaml(ctx, kubernetes.ComponentOwner(CoreDeploymentID), coreDeploymentYamlPath); err != nil {
+		return err
+	}
 
-	if err := c.WaitUntilPodBySelectorExist(ui, coreDeploymentNamespace, "app.kubernetes.io/name="+CoreDeploymentID, core.Timeout); err != nil {
+	if err := c.WaitUntilPodBySelectorExist(ctx, ui, coreDeploymentNamespace, "app.kubernetes.io/name="+CoreDeploymentID, core.Timeout); err != nil {
 		return errors.Wrap(err, "failed waiting fuseml-core deployment to exist")
 	}
-	if err := c.WaitForPodBySelectorRunning(ui, coreDeploymentNamespace, "app.kubernetes.io/name="+CoreDeploymentID, core.Timeout); err != nil {
+	if err := c.WaitForPodBySelectorRunning(ctx, ui, coreDeploymentNamespace, "app.kubernetes.io/name="+CoreDeploymentID, core.Timeout); err != nil {
 		return errors.Wrap(err, "failed waiting for fuseml-core deployment to come up")
 	}
 
+	provider, err := IngressProviderFor(ctx, c)
+	if err != nil {
+		return err
+	}
 	if upgrade {
+		if err := core.renameLegacyIngress(ctx, c, ui, provider, subdomain, domain); err != nil {
+			return err
+		}
 		ui.Success().Msg("FuseML core component successfully upgraded.")
 		return nil
 	}
-	if c.HasIstio() {
-		message := "Creating istio ingress gateway"
-		out, err := helpers.WaitForCommandCompletion(ui, message,
-			func() (string, error) {
-				return helpers.CreateIstioIngressGateway(CoreDeploymentID, coreDeploymentNamespace, subdomain, coreServiceName, coreServicePort)
-			},
-		)
-		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("%s failed:\n%s", message, out))
-		}
-	} else {
-		message := "Creating ingress for fuseml-core"
-		_, err = helpers.WaitForCommandCompletion(ui, message,
-			func() (string, error) {
-				return "", createCoreIngress(c, subdomain)
-			},
-		)
+	err = provider.Expose(ctx, c, ui, ExposedService{
+		Name:        CoreDeploymentID,
+		Namespace:   coreDeploymentNamespace,
+		Host:        subdomain,
+		Domain:      domain,
+		ServiceName: coreServiceName,
+		ServicePort: coreServicePort,
+		Owner:       kubernetes.ComponentOwner(CoreDeploymentID),
+	}, core.Timeout)
+	if err != nil {
+		return errors.Wrap(err, "Failed exposing Core component")
 	}
 
-	ui.Success().Msg(fmt.Sprintf("FuseML core component deployed (http://%s).", subdomain))
+	ui.Success().Msg(fmt.Sprintf("FuseML core component deployed (%s://%s).", URLScheme(ctx, c), subdomain))
 
 	return nil
 }
 
+// renameLegacyIngress replaces the core ingress created by the older installers under a different name
+func (core Core) renameLegacyIngress(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, provider IngressProvider, subdomain, domain string) error {
+	// istio gateways were always named after the deployment
+	if provider.Name() == IngressIstio {
+		return nil
+	}
+	ingresses, err := c.ListIngressRoutes(ctx, coreDeploymentNamespace, legacyCoreIngressName)
+	if err != nil {
+		return err
+	}
+	if len(ingresses) == 0 {
+		return nil
+	}
+	err = provider.Expose(ctx, c, ui, ExposedService{
+		Name:        CoreDeploymentID,
+		Namespace:   coreDeploymentNamespace,
+		Host:        subdomain,
+		Domain:      domain,
+		ServiceName: coreServiceName,
+		ServicePort: coreServicePort,
+		Owner:       kubernetes.ComponentOwner(CoreDeploymentID),
+	}, core.Timeout)
+	if err != nil {
+		return errors.Wrap(err, "Failed exposing Core component")
+	}
+	return c.DeleteIngress(ctx, coreDeploymentNamespace, legacyCoreIngressName)
+}
+
+// GetVersion returns the version (image tag) of fuseml-core deployed by the installer
 func (core Core) GetVersion() string {
-	return coreVersion
+	data, err := helpers.ReadEmbeddedFile(coreDeploymentYamlPath)
+	if err != nil {
+		return "unknown"
+	}
+	return imageTag(string(data))
 }
 
-func (core Core) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+// InstalledVersion returns the version (image tag) of fuseml-core running in the cluster
+func (core Core) InstalledVersion(ctx context.Context, c *kubernetes.Cluster) (string, error) {
+	deployment, err := c.Kubectl.AppsV1().Deployments(coreDeploymentNamespace).Get(ctx, CoreDeploymentID, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == CoreDeploymentID {
+			return imageTag("image: " + container.Image), nil
+		}
+	}
+	return "unknown", nil
+}
 
-	_, err := c.Kubectl.CoreV1().Namespaces().Get(
-		context.Background(),
-		coreDeploymentNamespace,
-		metav1.GetOptions{},
-	)
-	if err == nil {
-		ui.Note().Msg("Namespace " + coreDeploymentNamespace + " already present")
+// imageTag finds the tag of the core image in the manifest
+func imageTag(manifest string) string {
+	match := coreImagePattern.FindStringSubmatch(manifest)
+	if match == nil {
+		return "unknown"
 	}
+	return match[1]
+}
+
+// Verify checks that fuseml-core is running
+func (core Core) Verify(ctx context.Context, c *kubernetes.Cluster) error {
+	return c.PodsReady(ctx, coreDeploymentNamespace, "app.kubernetes.io/name="+CoreDeploymentID)
+}
+
+func (core Core) Deploy(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 
 	ui.Note().KeeplineUnder(1).Msg("Deploying Core...")
 
-	err = core.apply(c, ui, options, false)
+	err := core.apply(ctx, c, ui, options, false)
 	if err != nil {
 		return err
 	}
@@ -381,10 +397,10 @@ func (core Core) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Ins
 }
 
 // Check if Core service is installed
-func (core Core) Installed(c *kubernetes.Cluster) bool {
+func (core Core) Installed(ctx context.Context, c *kubernetes.Cluster) bool {
 
 	_, err := c.Kubectl.CoreV1().Namespaces().Get(
-		context.Background(),
+		ctx,
 		coreDeploymentNamespace,
 		metav1.GetOptions{},
 	)
@@ -392,18 +408,27 @@ func (core Core) Installed(c *kubernetes.Cluster) bool {
 		return false
 	}
 	_, err = c.Kubectl.AppsV1().Deployments(coreDeploymentNamespace).Get(
-		context.Background(),
+		ctx,
 		CoreDeploymentID,
 		metav1.GetOptions{})
 	return err == nil
 }
 
-func (core Core) Upgrade(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
-	if !core.Installed(c) {
+// Host returns the host name the installed core component is exposed at
+func (core Core) Host(ctx context.Context, c *kubernetes.Cluster) (string, error) {
+	provider, err := IngressProviderFor(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	return provider.Host(ctx, c, coreDeploymentNamespace, CoreDeploymentID)
+}
+
+func (core Core) Upgrade(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+	if !core.Installed(ctx, c) {
 		ui.Exclamation().Msg(
 			fmt.Sprintf("%s not found in namespace %s. Upgrade not possible",
 				CoreDeploymentID, coreDeploymentNamespace))
 		return nil
 	}
-	return core.apply(c, ui, options, true)
+	return core.apply(ctx, c, ui, options, true)
 }
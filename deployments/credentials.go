@@ -0,0 +1,175 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+)
+
+const defaultGeneratedLength = 32
+
+// generatedValuesSecret is the name of the secret with the generated credential values of the extension.
+// It is kept by the uninstallation, so the values stay the same when the extension is installed again.
+// The secret is in the namespace of fuseml-core, it is lost when fuseml-core is uninstalled.
+func generatedValuesSecret(extension string) string {
+	return fmt.Sprintf("fuseml-extension-%s-generated", extension)
+}
+
+// generatedValues are the random credential values of the extension, persisted in the secret
+type generatedValues struct {
+	secret  *corev1.Secret
+	exists  bool
+	changed bool
+}
+
+func loadGeneratedValues(ctx context.Context, c *kubernetes.Cluster, extension string) (*generatedValues, error) {
+	name := generatedValuesSecret(extension)
+	secret, err := c.Kubectl.CoreV1().Secrets(coreDeploymentNamespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		return &generatedValues{secret: secret, exists: true}, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "failed to get secret %s", name)
+	}
+	owner := kubernetes.ExtensionOwner(extension)
+	return &generatedValues{secret: &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: coreDeploymentNamespace,
+			Labels:    map[string]string{owner.LabelKey: owner.Name},
+		},
+		Data: map[string][]byte{},
+	}}, nil
+}
+
+// value returns the persisted value of the key, or generates a new one
+func (g *generatedValues) value(key string, length int) (string, error) {
+	if value, ok := g.secret.Data[key]; ok {
+		return string(value), nil
+	}
+	if length <= 0 {
+		length = defaultGeneratedLength
+	}
+	value, err := helpers.RandomString(length)
+	if err != nil {
+		return "", err
+	}
+	g.secret.Data[key] = []byte(value)
+	g.changed = true
+	return value, nil
+}
+
+// save persists the newly generated values
+func (g *generatedValues) save(ctx context.Context, c *kubernetes.Cluster) error {
+	if !g.changed {
+		return nil
+	}
+	var err error
+	if g.exists {
+		_, err = c.Kubectl.CoreV1().Secrets(coreDeploymentNamespace).Update(ctx, g.secret, metav1.UpdateOptions{})
+	} else {
+		_, err = c.Kubectl.CoreV1().Secrets(coreDeploymentNamespace).Create(ctx, g.secret, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the generated values to secret %s", g.secret.Name)
+	}
+	g.exists, g.changed = true, false
+	return nil
+}
+
+// generatedCredentials returns the generated values of the credentials by the service, credentials and
+// config value. The missing values are generated and persisted when requested, otherwise only the persisted
+// values are returned.
+func (e *Extension) generatedCredentials(ctx context.Context, c *kubernetes.Cluster, generate bool) (map[string]map[string]map[string]string, error) {
+	generated, err := loadGeneratedValues(ctx, c, e.Name)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]map[string]map[string]string{}
+	for _, service := range e.Desc.ServiceCredentials {
+		for _, cred := range service.Credentials {
+			for _, transform := range cred.Transform {
+				if !transform.Generate {
+					continue
+				}
+				key := generatedValueKey(service.ServiceID, cred.ID, transform.ConfigValue)
+				if _, ok := generated.secret.Data[key]; !ok && !generate {
+					continue
+				}
+				value, err := generated.value(key, transform.Length)
+				if err != nil {
+					return nil, err
+				}
+				if values[service.ServiceID] == nil {
+					values[service.ServiceID] = map[string]map[string]string{}
+				}
+				if values[service.ServiceID][cred.ID] == nil {
+					values[service.ServiceID][cred.ID] = map[string]string{}
+				}
+				values[service.ServiceID][cred.ID][transform.ConfigValue] = value
+			}
+		}
+	}
+	return values, generated.save(ctx, c)
+}
+
+// generatedValueKey is the key of the generated value in the secret
+func generatedValueKey(serviceID, credentialID, configValue string) string {
+	return strings.Join([]string{serviceID, credentialID, configValue}, ".")
+}
+
+// value finds the value of the credential from its source, the generated values are stored by the key
+func (t credentialTransformValue) value(ctx context.Context, c *kubernetes.Cluster, ic installContext, generated *generatedValues, key string) (string, error) {
+	ns := t.Namespace
+	if ns == "" {
+		ns = ic.Namespace
+	}
+
+	switch {
+	case t.Generate:
+		return generated.value(key, t.Length)
+	case t.Secret != "":
+		secret, err := c.GetSecret(ctx, ns, t.Secret)
+		if err != nil {
+			return "", err
+		}
+		value, ok := secret.Data[t.SecretValue]
+		if !ok {
+			return "", errors.New(fmt.Sprintf("key %s not found in secret %s/%s", t.SecretValue, ns, t.Secret))
+		}
+		return string(value), nil
+	case t.ConfigMap != "":
+		configMap, err := c.Kubectl.CoreV1().ConfigMaps(ns).Get(ctx, t.ConfigMap, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get config map")
+		}
+		value, ok := configMap.Data[t.ConfigMapValue]
+		if !ok {
+			return "", errors.New(fmt.Sprintf("key %s not found in config map %s/%s", t.ConfigMapValue, ns, t.ConfigMap))
+		}
+		return value, nil
+	case t.JSONPath != "":
+		value, err := c.GetJSONPathValue(ctx, t.Kind, ns, t.Name, t.JSONPath)
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			return "", errors.New(fmt.Sprintf("%s of %s %s is empty", t.JSONPath, t.Kind, t.Name))
+		}
+		return value, nil
+	case t.Value != "":
+		return t.Value, nil
+	}
+	return "", errors.New("no source of the value given")
+}
@@ -0,0 +1,37 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+	"github.com/pkg/errors"
+)
+
+// retainData keeps the persistent volumes of the component's namespace when it is deleted
+func retainData(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, name, namespace string) error {
+	volumes, err := c.RetainVolumes(ctx, namespace)
+	if err != nil {
+		return errors.Wrapf(err, "Failed keeping %s data", name)
+	}
+	if len(volumes) > 0 {
+		ui.Note().Msg(fmt.Sprintf("Keeping %s data in persistent volumes %s, they are re-attached by the next installation",
+			name, strings.Join(volumes, ", ")))
+	}
+	return nil
+}
+
+// reattachData binds the persistent volumes kept by the uninstallation to the claims of the component
+func reattachData(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, name, namespace string) error {
+	volumes, err := c.ReattachVolumes(ctx, namespace)
+	if err != nil {
+		return errors.Wrapf(err, "Failed re-attaching %s data", name)
+	}
+	if len(volumes) > 0 {
+		ui.Note().Msg(fmt.Sprintf("Re-attaching %s data kept by the previous uninstallation (persistent volumes %s)",
+			name, strings.Join(volumes, ", ")))
+	}
+	return nil
+}
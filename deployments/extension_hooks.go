@@ -0,0 +1,259 @@
+package deployments
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+)
+
+const (
+	preInstallHook    = "pre-install"
+	postInstallHook   = "post-install"
+	preUninstallHook  = "pre-uninstall"
+	postUninstallHook = "post-uninstall"
+
+	// failure policies of the hooks
+	abortOnFailure  = "abort"
+	ignoreOnFailure = "ignore"
+)
+
+// hook is a step of any type, run the same way as the install steps in a phase of the extension lifecycle
+type hook struct {
+	installStep
+	// FailurePolicy is abort (the default) to stop the installation or uninstallation when the hook fails,
+	// or ignore to only report the failure
+	FailurePolicy string
+}
+
+type extensionHooks struct {
+	PreInstall    []hook `json:"pre-install"`
+	PostInstall   []hook `json:"post-install"`
+	PreUninstall  []hook `json:"pre-uninstall"`
+	PostUninstall []hook `json:"post-uninstall"`
+}
+
+func (h extensionHooks) phase(name string) []hook {
+	switch name {
+	case preInstallHook:
+		return h.PreInstall
+	case postInstallHook:
+		return h.PostInstall
+	case preUninstallHook:
+		return h.PreUninstall
+	case postUninstallHook:
+		return h.PostUninstall
+	}
+	return nil
+}
+
+// installContext describes the installation of the extension. The string values of the install steps and
+// hooks marked as templates, and the manifests, job, patch and values files of these steps, are rendered
+// as templates with the context (e.g. {{ .Namespace }}), and the scripts get it in the environment.
+type installContext struct {
+	Name      string
+	Version   string
+	Namespace string
+	Domain    string
+	// Hook is the phase of the running hook, empty for the install and uninstall steps
+	Hook string
+	// Requires has the name, version, namespace and outputs of each required extension,
+	// e.g. {{ .Requires.minio.outputs.endpoint }}
+	Requires map[string]map[string]interface{}
+	// Generated has the generated credential values by the service, credentials and config value,
+	// e.g. {{ .Generated.s3.default.AWS_SECRET_ACCESS_KEY }}
+	Generated map[string]map[string]map[string]string
+
+	// position of the running hook in its phase, starting with 1
+	hookIndex int
+	// the files fetched by the running step are templates
+	templateFiles bool
+}
+
+func (e *Extension) newInstallContext(ctx context.Context, c *kubernetes.Cluster, options *kubernetes.InstallationOptions) (installContext, error) {
+	// the domain is not known when it was neither given nor detected yet
+	domain, _ := options.GetString("system_domain", "")
+	ic := installContext{
+		Name:      e.Name,
+		Version:   e.Desc.Version,
+		Namespace: e.Desc.Namespace,
+		Domain:    domain,
+	}
+	required, err := e.requiredContext(ctx, c, ic)
+	if err != nil {
+		return ic, err
+	}
+	ic.Requires = required
+	generated, err := e.generatedCredentials(ctx, c, false)
+	if err != nil {
+		return ic, err
+	}
+	ic.Generated = generated
+	return ic, nil
+}
+
+// env returns the context as the environment variables of the scripts
+func (ic installContext) env() []string {
+	env := []string{
+		"FUSEML_EXTENSION=" + ic.Name,
+		"FUSEML_EXTENSION_VERSION=" + ic.Version,
+		"FUSEML_EXTENSION_NAMESPACE=" + ic.Namespace,
+		"FUSEML_SYSTEM_DOMAIN=" + ic.Domain,
+	}
+	if ic.Hook != "" {
+		env = append(env, "FUSEML_HOOK="+ic.Hook)
+	}
+	return env
+}
+
+// render executes the text as a template with the context, referencing unknown values is an error
+func (ic installContext) render(text string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	t, err := template.New("value").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse template")
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, ic); err != nil {
+		return "", errors.Wrap(err, "failed to render template")
+	}
+	return b.String(), nil
+}
+
+// renderStep returns a copy of the step with all its string values rendered with the context,
+// the step is returned as it is unless it is a template
+func (ic installContext) renderStep(step installStep) (installStep, error) {
+	if !step.Template {
+		return step, nil
+	}
+	rendered := step
+	if err := ic.renderValues(&rendered); err != nil {
+		return step, err
+	}
+	return rendered, nil
+}
+
+// renderValues renders all the string values of the struct the pointer points to
+func (ic installContext) renderValues(ptr interface{}) error {
+	return renderStrings(reflect.ValueOf(ptr).Elem(), ic.render)
+}
+
+// renderStrings replaces the strings of the value (recursively for structs and slices), the slices
+// are copied first, so the original value is not modified
+func renderStrings(v reflect.Value, render func(string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.String:
+		s, err := render(v.String())
+		if err != nil {
+			return errors.Wrapf(err, "invalid value %q", v.String())
+		}
+		v.SetString(s)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Field(i).CanSet() {
+				continue
+			}
+			if err := renderStrings(v.Field(i), render); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(copied, v)
+		v.Set(copied)
+		for i := 0; i < v.Len(); i++ {
+			if err := renderStrings(v.Index(i), render); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runHooks runs the hooks of the phase in the order of the description. The hooks run in the namespace
+// of the extension, unless they specify their own (which has to exist).
+func (e *Extension) runHooks(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, phase string, reinstall bool, ic installContext) error {
+	ic.Hook = phase
+	for i, h := range e.Desc.Hooks.phase(phase) {
+		ic.hookIndex = i + 1
+		policy := h.FailurePolicy
+		if policy == "" {
+			policy = abortOnFailure
+		}
+		if policy != abortOnFailure && policy != ignoreOnFailure {
+			return errors.New(fmt.Sprintf("Unsupported failure policy of %s hook %d: %s", phase, i+1, policy))
+		}
+
+		err := e.runHook(ctx, c, ui, h.installStep, reinstall, ic)
+		if err == nil {
+			continue
+		}
+		err = errors.Wrapf(err, "%s hook %d (%s) of extension %s failed", phase, i+1, h.Type, e.Name)
+		if policy == abortOnFailure {
+			return err
+		}
+		ui.Exclamation().Msg(fmt.Sprintf("%s, ignoring", err))
+	}
+	return nil
+}
+
+func (e *Extension) runHook(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, step installStep, reinstall bool, ic installContext) error {
+	step, err := ic.renderStep(step)
+	if err != nil {
+		return err
+	}
+	ns := step.Namespace
+	if ns == "" {
+		ns = e.Desc.Namespace
+	}
+	if err := e.runStep(ctx, c, ui, step, ns, reinstall, ic); err != nil {
+		return err
+	}
+	return e.waitFor(ctx, c, ui, step)
+}
+
+// helmRelease returns the name of the helm release installed by the step, the hooks get their own releases,
+// e.g. mlflow-post-install-1
+func (e *Extension) helmRelease(ic installContext) string {
+	if ic.Hook == "" {
+		return e.Name
+	}
+	return fmt.Sprintf("%s-%s-%d", e.Name, ic.Hook, ic.hookIndex)
+}
+
+// uninstallHookReleases removes the helm releases installed by the hooks of all phases
+func (e *Extension) uninstallHookReleases(ctx context.Context, ui *ui.UI, ic installContext) error {
+	for _, phase := range []string{preInstallHook, postInstallHook, preUninstallHook, postUninstallHook} {
+		ic.Hook = phase
+		for i, h := range e.Desc.Hooks.phase(phase) {
+			if h.Type != "helm" {
+				continue
+			}
+			ic.hookIndex = i + 1
+			step, err := ic.renderStep(h.installStep)
+			if err != nil {
+				return err
+			}
+			ns := step.Namespace
+			if ns == "" {
+				ns = e.Desc.Namespace
+			}
+			if err := e.uninstallHelmChart(ctx, ui, e.helmRelease(ic), ns); err != nil {
+				return errors.Wrap(err, "failed to uninstall helm release "+e.helmRelease(ic))
+			}
+		}
+	}
+	return nil
+}
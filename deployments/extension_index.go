@@ -0,0 +1,94 @@
+package deployments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/fuseml/fuseml/cli/helpers"
+)
+
+// ErrNoExtensionIndex is returned when the extensions of the remote repository can't be listed,
+// as it provides no index file and it is not on GitHub
+var ErrNoExtensionIndex = errors.New("the extensions repository provides no index")
+
+// Repositories served by GitHub are listed through its API when they have no index (replaceable in tests)
+var (
+	GitHubRawHost = "raw.githubusercontent.com"
+	GitHubAPI     = "https://api.github.com"
+)
+
+// remoteExtensionNames reads the names of the extensions from the index file of the repository,
+// or lists the directories of the repository on GitHub
+func remoteExtensionNames(ctx context.Context, repository *url.URL, tmpDir string) ([]string, bool, error) {
+	u, _ := repository.Parse(defaultIndexFileName)
+	if err := helpers.DownloadFile(ctx, u.String(), defaultIndexFileName, tmpDir); err == nil {
+		data, err := os.ReadFile(filepath.Join(tmpDir, defaultIndexFileName))
+		if err != nil {
+			return nil, false, errors.Wrap(err, "failed to read index file")
+		}
+		names := []string{}
+		if err := yaml.Unmarshal(data, &names); err != nil {
+			return nil, false, errors.Wrap(err, "failed to parse index file")
+		}
+		return names, false, nil
+	}
+
+	if repository.Host != GitHubRawHost {
+		return nil, false, ErrNoExtensionIndex
+	}
+	names, err := githubDirectories(ctx, repository)
+	return names, true, err
+}
+
+// githubDirectories lists the directories of the repository given by its raw URL,
+// https://raw.githubusercontent.com/<owner>/<repo>/<ref>/<path>
+func githubDirectories(ctx context.Context, repository *url.URL) ([]string, error) {
+	parts := strings.SplitN(strings.Trim(repository.Path, "/"), "/", 4)
+	if len(parts) < 3 {
+		return nil, ErrNoExtensionIndex
+	}
+	dir := ""
+	if len(parts) == 4 {
+		dir = parts[3]
+	}
+	contents := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s",
+		strings.TrimSuffix(GitHubAPI, "/"), parts[0], parts[1], dir, url.QueryEscape(parts[2]))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, contents, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the extensions repository")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed to list the extensions repository: %s returned %s", contents, resp.Status))
+	}
+
+	entries := []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the extensions repository listing")
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if entry.Type == "dir" {
+			names = append(names, entry.Name)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,142 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fuseml/fuseml/cli/kubernetes"
+)
+
+// extensionOutput is a value the extension provides to the extensions requiring it. The value is
+// either static, or read from the key of the Secret or ConfigMap, or the address of the Service.
+type extensionOutput struct {
+	Name string
+	// static value, rendered with the install context of the extension
+	Value     string
+	Secret    string
+	ConfigMap string
+	Key       string
+	// Service is addressed as <scheme>://<service>.<namespace>.svc.cluster.local:<port>, with the first
+	// port of the Service and the http scheme by default
+	Service string
+	Port    int
+	Scheme  string
+	// namespace of the Secret, ConfigMap or Service, the namespace of the extension by default
+	Namespace string
+}
+
+// resolveOutputs reads the values of all the outputs of the extension from the cluster
+func (e *Extension) resolveOutputs(ctx context.Context, c *kubernetes.Cluster, ic installContext) (map[string]string, error) {
+	outputs := map[string]string{}
+	for _, o := range e.Desc.Outputs {
+		value, err := o.resolve(ctx, c, ic)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve output %s of extension %s", o.Name, e.Name)
+		}
+		outputs[o.Name] = value
+	}
+	return outputs, nil
+}
+
+func (o extensionOutput) resolve(ctx context.Context, c *kubernetes.Cluster, ic installContext) (string, error) {
+	ns := o.Namespace
+	if ns == "" {
+		ns = ic.Namespace
+	}
+
+	switch {
+	case o.Secret != "":
+		secret, err := c.GetSecret(ctx, ns, o.Secret)
+		if err != nil {
+			return "", err
+		}
+		value, ok := secret.Data[o.Key]
+		if !ok {
+			return "", errors.New(fmt.Sprintf("key %s not found in secret %s/%s", o.Key, ns, o.Secret))
+		}
+		return string(value), nil
+	case o.ConfigMap != "":
+		configMap, err := c.Kubectl.CoreV1().ConfigMaps(ns).Get(ctx, o.ConfigMap, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get config map")
+		}
+		value, ok := configMap.Data[o.Key]
+		if !ok {
+			return "", errors.New(fmt.Sprintf("key %s not found in config map %s/%s", o.Key, ns, o.ConfigMap))
+		}
+		return value, nil
+	case o.Service != "":
+		port := o.Port
+		if port == 0 {
+			service, err := c.Kubectl.CoreV1().Services(ns).Get(ctx, o.Service, metav1.GetOptions{})
+			if err != nil {
+				return "", errors.Wrap(err, "failed to get service")
+			}
+			if len(service.Spec.Ports) == 0 {
+				return "", errors.New(fmt.Sprintf("service %s/%s has no ports", ns, o.Service))
+			}
+			port = int(service.Spec.Ports[0].Port)
+		}
+		scheme := o.Scheme
+		if scheme == "" {
+			scheme = "http"
+		}
+		return fmt.Sprintf("%s://%s.%s.svc.cluster.local:%d", scheme, o.Service, ns, port), nil
+	}
+	return ic.render(o.Value)
+}
+
+// requiredContext resolves the context of the extensions required by the extension, with their outputs.
+// The required extensions are expected to be installed already.
+func (e *Extension) requiredContext(ctx context.Context, c *kubernetes.Cluster, ic installContext) (map[string]map[string]interface{}, error) {
+	required := map[string]map[string]interface{}{}
+	for _, name := range e.Desc.Requires {
+		r := NewExtension(name, e.Repository, e.Timeout, e.Debug)
+		if err := r.LoadDescription(ctx); err != nil {
+			return nil, errors.Wrapf(err, "failed to load description of required extension %s", name)
+		}
+		ric := installContext{Name: r.Name, Version: r.Desc.Version, Namespace: r.Desc.Namespace, Domain: ic.Domain}
+		outputs, err := r.resolveOutputs(ctx, c, ric)
+		if err != nil {
+			return nil, err
+		}
+		required[name] = map[string]interface{}{
+			"name":      r.Name,
+			"version":   r.Desc.Version,
+			"namespace": r.Desc.Namespace,
+			"outputs":   outputs,
+		}
+	}
+	return required, nil
+}
+
+// renderFile renders the content of the local file with the context. The file is returned as it is
+// unless the step fetching it is a template or when it contains no templates, otherwise the rendered
+// copy is written to the directory.
+func renderFile(path, tmpDir string, ic installContext) (string, error) {
+	if !ic.templateFiles {
+		return path, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", path)
+	}
+	if !strings.Contains(string(data), "{{") {
+		return path, nil
+	}
+	rendered, err := ic.render(string(data))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to render %s", filepath.Base(path))
+	}
+	renderedPath := filepath.Join(tmpDir, "rendered-"+filepath.Base(path))
+	if err := ioutil.WriteFile(renderedPath, []byte(rendered), 0600); err != nil {
+		return "", errors.Wrapf(err, "failed to write %s", renderedPath)
+	}
+	return renderedPath, nil
+}
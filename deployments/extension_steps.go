@@ -0,0 +1,348 @@
+package deployments
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// catalog of the operators installed by the olm steps, unless the step gives another one
+	defaultOperatorSource          = "operatorhubio-catalog"
+	defaultOperatorSourceNamespace = "olm"
+	// namespace with the operators watching all namespaces, created by OLM
+	defaultOperatorNamespace = "operators"
+
+	stepPollInterval = 2 * time.Second
+)
+
+// stepTimeout returns the timeout of the step
+func (e *Extension) stepTimeout(step installStep) time.Duration {
+	if step.Timeout > 0 {
+		return time.Duration(step.Timeout) * time.Second
+	}
+	return time.Duration(e.Timeout) * time.Second
+}
+
+// runJob creates the Job from the manifest at the step location, streams the logs of its pod
+// and waits until the Job completes
+func (e *Extension) runJob(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, step installStep, ns string, ic installContext) error {
+	tmpDir, err := ioutil.TempDir("", tmpSubDir)
+	if err != nil {
+		return errors.Wrap(err, "can't create temp directory "+tmpDir)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manifestLocalPath, err := e.fetchFile(ctx, step.Location, tmpDir)
+	if err != nil {
+		return errors.Wrap(err, "failed fetching file from "+step.Location)
+	}
+	manifestLocalPath, err = renderFile(manifestLocalPath, tmpDir, ic)
+	if err != nil {
+		return err
+	}
+
+	namespace := ""
+	if ns != "" {
+		namespace = " --namespace " + ns
+	}
+	// the pod template of a Job can't be changed, the Job of the previous run is replaced
+	out, err := helpers.KubectlWithProgress(ctx, ui, fmt.Sprintf("delete --filename %s --ignore-not-found%s", manifestLocalPath, namespace))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("kubectl delete failed:\n%s", out))
+	}
+
+	kubectlCmd := fmt.Sprintf("apply --filename %s --output jsonpath={.kind}/{.metadata.namespace}/{.metadata.name}%s", manifestLocalPath, namespace)
+	out, err = helpers.KubectlWithProgress(ctx, ui, kubectlCmd)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("kubectl apply failed:\n%s", out))
+	}
+	object := strings.Split(strings.TrimSpace(out), "/")
+	if len(object) != 3 || object[0] != "Job" {
+		return errors.New(fmt.Sprintf("%s has to contain a single Job, found: %s", step.Location, out))
+	}
+	jobNamespace, jobName := object[1], object[2]
+	if err := labelManifest(ctx, kubernetes.ExtensionOwner(e.Name), manifestLocalPath, ns); err != nil {
+		return err
+	}
+
+	timeout := e.stepTimeout(step)
+	ui.Note().Msg(fmt.Sprintf("Running job %s/%s:", jobNamespace, jobName))
+	logsCmd := fmt.Sprintf("kubectl logs --follow --pod-running-timeout=%ds --namespace %s job/%s",
+		int(timeout.Seconds()), jobNamespace, jobName)
+	if out, err := helpers.RunProc(ctx, logsCmd, tmpDir, true); err != nil {
+		ui.Exclamation().Msg(fmt.Sprintf("Failed streaming the logs of job %s: %s", jobName, strings.TrimSpace(out)))
+	}
+
+	err = kubernetes.PollImmediate(ctx, stepPollInterval, timeout, func() (bool, error) {
+		job, err := c.Kubectl.BatchV1().Jobs(jobNamespace).Get(ctx, jobName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case batchv1.JobComplete:
+				return true, nil
+			case batchv1.JobFailed:
+				return false, errors.New(fmt.Sprintf("job %s failed: %s", jobName, condition.Message))
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed waiting for job %s to complete", jobName)
+	}
+	ui.Success().Msg(fmt.Sprintf("Job %s completed", jobName))
+	return nil
+}
+
+// deleteJob removes the Job created from the manifest at the step location, with its pods
+func (e *Extension) deleteJob(ctx context.Context, ui *ui.UI, step installStep, ns string, ic installContext) error {
+	return e.uninstallManifest(ctx, ui, step.Location, ns, ic)
+}
+
+// applyPatch patches the object given by the step kind and name, with the patch given inline or
+// by the file at the step location. The missing object is only reported during the uninstallation.
+func (e *Extension) applyPatch(ctx context.Context, ui *ui.UI, step installStep, ns string, uninstall bool, ic installContext) error {
+	if step.Kind == "" || step.Name == "" {
+		return errors.New("the kind and the name of the object to patch are required")
+	}
+	patchType := step.PatchType
+	if patchType == "" {
+		patchType = "strategic"
+	}
+	if !helpers.StringInSlice([]string{"strategic", "merge", "json"}, patchType) {
+		return errors.New(fmt.Sprintf("unsupported patch type %s (use strategic, merge or json)", patchType))
+	}
+
+	tmpDir, err := ioutil.TempDir("", tmpSubDir)
+	if err != nil {
+		return errors.Wrap(err, "can't create temp directory "+tmpDir)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	patchPath := ""
+	if step.Patch != "" {
+		patchPath, err = helpers.CreateTmpFile(step.Patch)
+		defer os.Remove(patchPath)
+	} else if step.Location != "" {
+		if patchPath, err = e.fetchFile(ctx, step.Location, tmpDir); err == nil {
+			patchPath, err = renderFile(patchPath, tmpDir, ic)
+		}
+	} else {
+		return errors.New("neither the patch nor its location was provided")
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read the patch")
+	}
+
+	object := fmt.Sprintf("%s/%s", step.Kind, step.Name)
+	kubectlCmd := fmt.Sprintf("patch %s --type %s --patch-file %s", object, patchType, patchPath)
+	if ns != "" {
+		kubectlCmd = kubectlCmd + " --namespace " + ns
+	}
+	out, err := helpers.KubectlWithProgress(ctx, ui, kubectlCmd)
+	if err != nil {
+		if uninstall && strings.Contains(out, "NotFound") {
+			ui.Exclamation().Msg(fmt.Sprintf("%s not found, skipping the patch", object))
+			return nil
+		}
+		return errors.Wrap(err, fmt.Sprintf("kubectl patch failed:\n%s", out))
+	}
+	return nil
+}
+
+// waitHTTP polls the endpoint until it responds with the expected status, or (when available is not set)
+// until it stops responding with it
+func (e *Extension) waitHTTP(ctx context.Context, ui *ui.UI, step installStep, available bool) error {
+	if step.URL == "" {
+		return errors.New("the URL to wait for is required")
+	}
+	status := step.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	client := &http.Client{Timeout: stepPollInterval * 5}
+	if step.Insecure {
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+
+	message := fmt.Sprintf("Waiting for %s to respond with %d", step.URL, status)
+	if !available {
+		message = fmt.Sprintf("Waiting for %s to stop responding with %d", step.URL, status)
+	}
+	s := ui.Progressf(" %s", message)
+	defer s.Stop()
+
+	last := ""
+	err := kubernetes.PollImmediate(ctx, stepPollInterval, e.stepTimeout(step), func() (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, step.URL, nil)
+		if err != nil {
+			return false, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			last = err.Error()
+			return !available, nil
+		}
+		resp.Body.Close()
+		last = resp.Status
+		return (resp.StatusCode == status) == available, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "%s failed (last response: %s)", message, last)
+	}
+	return nil
+}
+
+// subscribeOperator installs the operator from the OLM catalog by the Subscription, and waits until
+// its ClusterServiceVersion succeeds. The namespace without an OperatorGroup gets one for all namespaces.
+func (e *Extension) subscribeOperator(ctx context.Context, ui *ui.UI, step installStep, ns string) error {
+	if step.Package == "" {
+		return errors.New("the package of the operator is required")
+	}
+	if ns == "" {
+		ns = defaultOperatorNamespace
+	}
+	source, sourceNamespace := step.Source, step.SourceNamespace
+	if source == "" {
+		source = defaultOperatorSource
+	}
+	if sourceNamespace == "" {
+		sourceNamespace = defaultOperatorSourceNamespace
+	}
+	owner := kubernetes.ExtensionOwner(e.Name)
+
+	out, err := helpers.Kubectl(ctx, fmt.Sprintf("get operatorgroups --namespace %s --output name", ns))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to list operator groups (is OLM installed?):\n%s", out))
+	}
+	manifest := ""
+	if strings.TrimSpace(out) == "" {
+		manifest = fmt.Sprintf(`apiVersion: operators.coreos.com/v1
+kind: OperatorGroup
+metadata:
+  name: %s
+  namespace: %s
+  labels:
+    %s: %s
+---
+`, ns, ns, owner.LabelKey, owner.Name)
+	}
+	manifest = manifest + fmt.Sprintf(`apiVersion: operators.coreos.com/v1alpha1
+kind: Subscription
+metadata:
+  name: %s
+  namespace: %s
+  labels:
+    %s: %s
+spec:
+  name: %s
+  source: %s
+  sourceNamespace: %s
+  installPlanApproval: Automatic
+`, step.Package, ns, owner.LabelKey, owner.Name, step.Package, source, sourceNamespace)
+	if step.Channel != "" {
+		manifest = manifest + "  channel: " + step.Channel + "\n"
+	}
+
+	manifestPath, err := helpers.CreateTmpFile(manifest)
+	defer os.Remove(manifestPath)
+	if err != nil {
+		return err
+	}
+	out, err = helpers.KubectlWithProgress(ctx, ui, "apply --filename "+manifestPath)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("kubectl apply failed:\n%s", out))
+	}
+
+	timeout := e.stepTimeout(step)
+	csv := ""
+	message := fmt.Sprintf("Waiting for operator %s to be installed", step.Package)
+	out, err = helpers.WaitForCommandCompletion(ui, message,
+		func() (string, error) {
+			return helpers.ExecToSuccessWithTimeout(ctx, func() (string, error) {
+				if csv == "" {
+					found, err := installedCSV(ctx, step.Package, ns)
+					if err != nil || found == "" {
+						return "", errors.New("no installed ClusterServiceVersion")
+					}
+					csv = found
+				}
+				phase, err := helpers.Kubectl(ctx, fmt.Sprintf("get csv %s --namespace %s --output jsonpath={.status.phase}", csv, ns))
+				if err != nil || strings.TrimSpace(phase) != "Succeeded" {
+					return "", errors.New(fmt.Sprintf("ClusterServiceVersion %s is %s", csv, phase))
+				}
+				return phase, nil
+			}, timeout, stepPollInterval)
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("%s failed:\n%s", message, out))
+	}
+	ui.Success().Msg(fmt.Sprintf("Operator %s installed (%s)", step.Package, csv))
+	return nil
+}
+
+// unsubscribeOperator removes the Subscription of the operator and its ClusterServiceVersion,
+// which uninstalls the operator. The OperatorGroup created for the operator is removed
+// when no other Subscription is left in the namespace.
+func (e *Extension) unsubscribeOperator(ctx context.Context, ui *ui.UI, step installStep, ns string) error {
+	if step.Package == "" {
+		return errors.New("the package of the operator is required")
+	}
+	if ns == "" {
+		ns = defaultOperatorNamespace
+	}
+
+	csv, _ := installedCSV(ctx, step.Package, ns)
+	out, err := helpers.KubectlWithProgress(ctx, ui, fmt.Sprintf("delete subscription %s --namespace %s --ignore-not-found", step.Package, ns))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("kubectl delete failed:\n%s", out))
+	}
+	if csv != "" {
+		out, err := helpers.KubectlWithProgress(ctx, ui, fmt.Sprintf("delete csv %s --namespace %s --ignore-not-found", csv, ns))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("kubectl delete failed:\n%s", out))
+		}
+	}
+
+	out, err = helpers.Kubectl(ctx, fmt.Sprintf("get subscriptions --namespace %s --output name", ns))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to list subscriptions:\n%s", out))
+	}
+	if strings.TrimSpace(out) != "" {
+		return nil
+	}
+	owner := kubernetes.ExtensionOwner(e.Name)
+	out, err = helpers.KubectlWithProgress(ctx, ui, fmt.Sprintf("delete operatorgroups --namespace %s --selector %s --ignore-not-found", ns, owner.Label()))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("kubectl delete failed:\n%s", out))
+	}
+	return nil
+}
+
+// installedCSV returns the name of the ClusterServiceVersion installed by the subscription
+func installedCSV(ctx context.Context, subscription, ns string) (string, error) {
+	out, err := helpers.Kubectl(ctx, fmt.Sprintf("get subscription %s --namespace %s --output jsonpath={.status.installedCSV}", subscription, ns))
+	if err != nil {
+		return "", errors.Wrap(err, out)
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,320 @@
+package deployments_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Extension job, patch and olm steps", func() {
+	var (
+		repository string
+		binDir     string
+		path       string
+		options    *kubernetes.InstallationOptions
+		cluster    *kubernetes.Cluster
+	)
+
+	writeFile := func(name, content string) {
+		Expect(ioutil.WriteFile(filepath.Join(repository, "steps", name), []byte(content), 0600)).To(Succeed())
+	}
+	// respond sets the output of the kubectl stand-in for the request
+	respond := func(request, output string) {
+		Expect(ioutil.WriteFile(filepath.Join(binDir, request), []byte(output), 0600)).To(Succeed())
+	}
+	// recorded returns the content recorded by the kubectl stand-in, and clears it
+	recorded := func(name string) string {
+		content, _ := ioutil.ReadFile(filepath.Join(binDir, name))
+		os.Remove(filepath.Join(binDir, name))
+		return string(content)
+	}
+	extension := func(description string) *Extension {
+		writeFile("description.yaml", description)
+		e := NewExtension("steps", repository, 1, false)
+		Expect(e.LoadDescription(context.Background())).To(Succeed())
+		return e
+	}
+
+	BeforeEach(func() {
+		var err error
+		repository, err = ioutil.TempDir("", "fuseml-extensions")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(repository, "steps"), 0700)).To(Succeed())
+
+		// kubectl stand-in recording the commands with the applied manifests and patches,
+		// and printing the outputs set by respond
+		binDir, err = ioutil.TempDir("", "fuseml-bin")
+		Expect(err).ToNot(HaveOccurred())
+		kubectl := fmt.Sprintf(`#!/bin/sh
+dir=%s
+echo "$*" >> $dir/commands
+case "$1 $2" in
+"apply "*|"patch "*)
+  for arg in "$@"; do
+    if [ "$previous" = --filename ] || [ "$previous" = --patch-file ]; then cat "$arg" >> $dir/files; fi
+    previous=$arg
+  done ;;
+esac
+case "$1 $2" in
+"apply "*) case "$*" in *--output*) cat $dir/applied-object ;; esac ;;
+"patch "*) if [ -f $dir/patch-error ]; then cat $dir/patch-error; exit 1; fi ;;
+"get operatorgroups") cat $dir/operatorgroups 2>/dev/null ;;
+"get subscriptions") cat $dir/subscriptions 2>/dev/null ;;
+"get subscription") cat $dir/installed-csv 2>/dev/null ;;
+"get csv") cat $dir/csv-phase ;;
+esac
+exit 0
+`, binDir)
+		Expect(ioutil.WriteFile(filepath.Join(binDir, "kubectl"), []byte(kubectl), 0700)).To(Succeed())
+		path = os.Getenv("PATH")
+		os.Setenv("PATH", binDir+string(os.PathListSeparator)+path)
+
+		options = &kubernetes.InstallationOptions{
+			{Name: "force_reinstall", Type: kubernetes.BooleanType, Value: false},
+			{Name: "system_domain", Type: kubernetes.StringType, Value: "example.com"},
+		}
+		cluster = &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset()}
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(binDir)
+		os.RemoveAll(repository)
+	})
+
+	Context("with the job step", func() {
+		job := func(condition batchv1.JobConditionType, message string) *batchv1.Job {
+			return &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "fuseml-workloads"},
+				Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+					{Type: condition, Status: corev1.ConditionTrue, Message: message},
+				}},
+			}
+		}
+		description := `name: steps
+install:
+- type: job
+  location: job.yaml
+  timeout: 10
+uninstall:
+- type: job
+  location: job.yaml
+`
+
+		BeforeEach(func() {
+			writeFile("job.yaml", "apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: train\n  namespace: fuseml-workloads\n")
+			respond("applied-object", "Job/fuseml-workloads/train")
+		})
+
+		It("replaces the job, follows its logs and waits for its completion", func() {
+			cluster = &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset(job(batchv1.JobComplete, ""))}
+			e := extension(description)
+
+			Expect(e.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+			commands := recorded("commands")
+			Expect(commands).To(MatchRegexp(`delete --filename \S+ --ignore-not-found\n`))
+			Expect(commands).To(ContainSubstring("apply --filename"))
+			Expect(commands).To(ContainSubstring(kubernetes.ExtensionOwner("steps").Label()))
+			Expect(commands).To(ContainSubstring("logs --follow --pod-running-timeout=10s --namespace fuseml-workloads job/train"))
+			Expect(recorded("files")).To(ContainSubstring("name: train"))
+		})
+
+		It("deletes the job on uninstall", func() {
+			e := extension(description)
+
+			Expect(e.Uninstall(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+			commands := recorded("commands")
+			Expect(commands).To(MatchRegexp(`delete --filename \S+ --ignore-not-found\n`))
+			Expect(commands).ToNot(ContainSubstring("apply"))
+		})
+
+		It("fails when the job fails", func() {
+			cluster = &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset(job(batchv1.JobFailed, "BackoffLimitExceeded"))}
+			e := extension(description)
+
+			err := e.Install(context.Background(), cluster, ui.NewUI(), options)
+			Expect(err).To(MatchError(ContainSubstring("job train failed: BackoffLimitExceeded")))
+		})
+
+		It("fails when the manifest does not contain a single Job", func() {
+			respond("applied-object", "Deployment/fuseml-workloads/train")
+			e := extension(description)
+
+			err := e.Install(context.Background(), cluster, ui.NewUI(), options)
+			Expect(err).To(MatchError(ContainSubstring("job.yaml has to contain a single Job")))
+		})
+	})
+
+	Context("with the patch step", func() {
+		patch := `{"spec":{"replicas":2}}`
+
+		It("applies the inline patch as strategic merge patch by default", func() {
+			e := extension(fmt.Sprintf(`name: steps
+install:
+- type: patch
+  kind: deployment
+  name: mlflow
+  patch: '%s'
+`, patch))
+
+			Expect(e.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+			Expect(recorded("commands")).To(ContainSubstring("patch deployment/mlflow --type strategic --patch-file"))
+			Expect(recorded("files")).To(Equal(patch))
+		})
+
+		It("applies the patch from the location with the given type", func() {
+			writeFile("patch.yaml", "spec:\n  replicas: 2\n")
+			e := extension(`name: steps
+install:
+- type: patch
+  kind: deployment
+  name: mlflow
+  location: patch.yaml
+  patchtype: merge
+`)
+
+			Expect(e.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+			Expect(recorded("commands")).To(ContainSubstring("patch deployment/mlflow --type merge --patch-file"))
+			Expect(recorded("files")).To(Equal("spec:\n  replicas: 2\n"))
+		})
+
+		It("applies the json patch", func() {
+			e := extension(`name: steps
+install:
+- type: patch
+  kind: deployment
+  name: mlflow
+  patch: '[{"op":"replace","path":"/spec/replicas","value":2}]'
+  patchtype: json
+`)
+
+			Expect(e.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+			Expect(recorded("commands")).To(ContainSubstring("patch deployment/mlflow --type json --patch-file"))
+			Expect(recorded("files")).To(Equal(`[{"op":"replace","path":"/spec/replicas","value":2}]`))
+		})
+
+		It("fails on the unsupported patch type", func() {
+			e := extension(fmt.Sprintf("name: steps\ninstall:\n- type: patch\n  kind: deployment\n  name: mlflow\n  patch: '%s'\n  patchtype: apply\n", patch))
+
+			err := e.Install(context.Background(), cluster, ui.NewUI(), options)
+			Expect(err).To(MatchError(ContainSubstring("unsupported patch type apply")))
+		})
+
+		Context("when the object does not exist", func() {
+			description := fmt.Sprintf(`name: steps
+install:
+- type: patch
+  kind: deployment
+  name: mlflow
+  patch: '%[1]s'
+uninstall:
+- type: patch
+  kind: deployment
+  name: mlflow
+  patch: '%[1]s'
+`, patch)
+
+			BeforeEach(func() {
+				respond("patch-error", `Error from server (NotFound): deployments.apps "mlflow" not found`)
+			})
+
+			It("fails the installation", func() {
+				e := extension(description)
+
+				err := e.Install(context.Background(), cluster, ui.NewUI(), options)
+				Expect(err).To(MatchError(ContainSubstring("kubectl patch failed")))
+			})
+
+			It("skips the patch on uninstall", func() {
+				e := extension(description)
+
+				Expect(e.Uninstall(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+				Expect(recorded("commands")).To(ContainSubstring("patch deployment/mlflow"))
+			})
+		})
+	})
+
+	Context("with the olm step", func() {
+		description := `name: steps
+install:
+- type: olm
+  package: prometheus
+  channel: beta
+  timeout: 3
+uninstall:
+- type: olm
+  package: prometheus
+`
+		owner := kubernetes.ExtensionOwner("steps")
+
+		BeforeEach(func() {
+			respond("installed-csv", "prometheusoperator.0.47.0")
+			respond("csv-phase", "Succeeded")
+		})
+
+		It("subscribes to the operator with a new operator group and waits for its installation", func() {
+			e := extension(description)
+
+			Expect(e.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+			Expect(recorded("commands")).To(ContainSubstring("get csv prometheusoperator.0.47.0 --namespace operators"))
+			applied := recorded("files")
+			Expect(applied).To(ContainSubstring("kind: OperatorGroup\nmetadata:\n  name: operators\n  namespace: operators\n"))
+			Expect(applied).To(ContainSubstring("kind: Subscription\nmetadata:\n  name: prometheus\n  namespace: operators\n"))
+			Expect(applied).To(ContainSubstring(fmt.Sprintf("%s: %s", owner.LabelKey, owner.Name)))
+			Expect(applied).To(ContainSubstring("source: operatorhubio-catalog\n  sourceNamespace: olm\n"))
+			Expect(applied).To(ContainSubstring("channel: beta\n"))
+		})
+
+		It("keeps the operator group of the namespace", func() {
+			respond("operatorgroups", "operatorgroup.operators.coreos.com/global-operators")
+			e := extension(description)
+
+			Expect(e.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+			applied := recorded("files")
+			Expect(applied).ToNot(ContainSubstring("kind: OperatorGroup"))
+			Expect(applied).To(ContainSubstring("kind: Subscription"))
+		})
+
+		It("fails when the ClusterServiceVersion does not succeed in time", func() {
+			respond("csv-phase", "Failed")
+			e := extension(description)
+
+			err := e.Install(context.Background(), cluster, ui.NewUI(), options)
+			Expect(err).To(MatchError(ContainSubstring("Waiting for operator prometheus to be installed failed")))
+		})
+
+		It("removes the subscription, its ClusterServiceVersion and the unused operator group on uninstall", func() {
+			e := extension(description)
+
+			Expect(e.Uninstall(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+			commands := recorded("commands")
+			Expect(commands).To(ContainSubstring("delete subscription prometheus --namespace operators --ignore-not-found"))
+			Expect(commands).To(ContainSubstring("delete csv prometheusoperator.0.47.0 --namespace operators --ignore-not-found"))
+			Expect(commands).To(ContainSubstring("delete operatorgroups --namespace operators --selector " + owner.Label()))
+		})
+
+		It("keeps the operator group used by other subscriptions", func() {
+			respond("subscriptions", "subscription.operators.coreos.com/grafana")
+			e := extension(description)
+
+			Expect(e.Uninstall(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+			commands := recorded("commands")
+			Expect(commands).To(ContainSubstring("delete subscription prometheus"))
+			Expect(commands).ToNot(ContainSubstring("delete operatorgroups"))
+		})
+	})
+})
@@ -1,26 +1,23 @@
 package deployments
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
-	"log"
-	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
-	"github.com/hashicorp/go-retryablehttp"
 	"github.com/pkg/errors"
 
 	"github.com/fuseml/fuseml/cli/helpers"
 	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/compat"
+	"github.com/fuseml/fuseml/cli/paas/extensionregistry"
 	"github.com/fuseml/fuseml/cli/paas/ui"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -31,13 +28,22 @@ const (
 	defaultDescriptionFileName = "description.yaml"
 	defaultNamespace           = "fuseml-workloads"
 	tmpSubDir                  = "fuseml-extension"
+
+	// list of the extensions in the remote repository (which can't be listed otherwise, except GitHub)
+	defaultIndexFileName = "index.yaml"
 )
 
 type waitForStep struct {
 	Kind      string
 	Namespace string
-	Condition string
+	Name      string
 	Selector  string
+	// the state to wait for, the Ready condition by default
+	Condition string
+	JSONPath  string
+	Value     string
+	Rollout   bool
+	Exists    bool
 	Timeout   int
 }
 
@@ -50,6 +56,25 @@ type installStep struct {
 	Version   string
 	Namespace string
 	WaitFor   []waitForStep
+	// object to patch by the patch step, e.g. kind deployment and name mlflow
+	Kind string
+	Name string
+	// patch given inline (instead of the file at Location) and its type: strategic (default), merge or json
+	Patch     string
+	PatchType string
+	// endpoint polled by the wait-http step until it responds with the status (200 by default)
+	URL      string
+	Status   int
+	Insecure bool
+	// operator installed by the olm step from the catalog source
+	Package         string
+	Channel         string
+	Source          string
+	SourceNamespace string
+	// seconds to wait for the job, wait-http and olm steps (the timeout of the installer by default)
+	Timeout int
+	// Template enables rendering the string values of the step with the install context
+	Template bool
 }
 
 type istioGateway struct {
@@ -60,76 +85,6 @@ type istioGateway struct {
 	ServiceHost string
 }
 
-// extension structurs necessary to build a payload for extension registry
-// (copied from fuseml-core/gen/extension/service.go for now...)
-type registeredExtensionService struct {
-	ID           *string
-	ExtensionID  *string
-	Resource     *string
-	Category     *string
-	Description  *string
-	AuthRequired *bool
-	Status       *registeredExtensionServiceStatus
-	Endpoints    []*registeredExtensionEndpoint
-	Credentials  []*registeredExtensionCredentials
-}
-
-// a query that can be run against the extension registry to retrieve
-// (just a dummy structure for now)
-type registeredExtensionQuery struct {
-}
-
-// extension information as expected by extension registry
-type registeredExtension struct {
-	ID            *string
-	Product       *string
-	Version       *string
-	Description   *string
-	Zone          *string
-	Configuration map[string]string
-	Status        *registeredExtensionStatus
-	Services      []*registeredExtensionService
-}
-
-type registeredExtensionEndpoint struct {
-	URL           *string
-	ExtensionID   *string
-	ServiceID     *string
-	Type          *string
-	Configuration map[string]string
-	Status        *registeredExtensionEndpointStatus
-}
-
-type registeredExtensionCredentials struct {
-	ID            *string
-	ExtensionID   *string
-	ServiceID     *string
-	Default       *bool
-	Scope         *string
-	Projects      []string
-	Users         []string
-	Configuration map[string]string
-	Status        *registeredExtensionCredentialsStatus
-}
-
-type registeredExtensionCredentialsStatus struct {
-	Created string
-	Updated string
-}
-
-type registeredExtensionStatus struct {
-	Registered string
-	Updated    string
-}
-
-type registeredExtensionServiceStatus struct {
-	Registered string
-	Updated    string
-}
-
-type registeredExtensionEndpointStatus struct {
-}
-
 // serviceCredentialTemplate describes the way how to generate service credentials
 type serviceCredentialTemplate struct {
 	ServiceID   string
@@ -141,11 +96,37 @@ type credentialTemplate struct {
 	Transform []credentialTransformValue
 }
 
+// credentialTransformValue gives the source of the ConfigValue of the credentials: the key of the Secret
+// or ConfigMap, the JSONPath of the object of any Kind, the (templated) Value, or the Generated random value
 type credentialTransformValue struct {
 	ConfigValue string
 	SecretValue string
 	Secret      string
-	Namespace   string
+	// key of the ConfigMap
+	ConfigMapValue string
+	ConfigMap      string
+	// object in the form accepted by kubectl, e.g. kind service and name istio-ingressgateway
+	Kind     string
+	Name     string
+	JSONPath string
+	Value    string
+	// Generate the random value of the Length (32 by default), persisted for the following installations
+	Generate bool
+	Length   int
+	// namespace of the Secret, ConfigMap or object, the namespace of the extension by default
+	Namespace string
+}
+
+// extensionService is the service of the extension, registered in the extension registry. The description
+// keeps its own field names (e.g. authrequired), independent of the JSON names of the registry API.
+type extensionService struct {
+	ID           *string
+	Resource     *string
+	Category     *string
+	Description  *string
+	AuthRequired *bool
+	Endpoints    []extensionregistry.Endpoint
+	Credentials  []extensionregistry.Credentials
 }
 
 type extensionDesc struct {
@@ -159,9 +140,15 @@ type extensionDesc struct {
 	Install            []installStep
 	Uninstall          []installStep
 	Gateways           []istioGateway
-	Services           []registeredExtensionService
+	Services           []extensionService
 	ServiceCredentials []serviceCredentialTemplate
 	RoleRules          []roleRule
+	// values provided to the extensions requiring this one
+	Outputs []extensionOutput
+	// steps run in the phases of the installation and uninstallation
+	Hooks extensionHooks
+	// versions of the installer, fuseml-core, Kubernetes and other extensions the extension works with
+	Compatibility compat.Requirements
 }
 type roleRule struct {
 	ApiGroups []string
@@ -174,21 +161,26 @@ type Extension struct {
 	Repository             string
 	Debug                  bool
 	Timeout                int
-	httpClient             *http.Client
 	Desc                   *extensionDesc
 	TransformedCredentials map[string]map[string]map[string]string
+	// Checkpoints record the completed install steps, so the interrupted installation
+	// continues with the first step not completed (nil does not record anything)
+	Checkpoints *Checkpoints
 }
 
-func NewHttpClient(debug bool) *http.Client {
-	retryClient := retryablehttp.NewClient()
-
-	if !debug {
-		// suppress the regular logger output (it logs all debug messages),
-		// it should suffice to return the final failure of http requests
-		retryClient.Logger = log.New(ioutil.Discard, "", log.LstdFlags)
+// RegistryClient returns the client of the extension registry of fuseml-core installed under the system domain,
+// using the scheme of FuseML endpoints and trusting the CA generated by the installer
+func RegistryClient(ctx context.Context, c *kubernetes.Cluster, options *kubernetes.InstallationOptions, debug bool) (*extensionregistry.Client, error) {
+	domain, err := options.GetString("system_domain", "")
+	if err != nil {
+		return nil, errors.New("system_domain value not provided")
 	}
-
-	return retryClient.StandardClient()
+	ca, err := TrustedCA(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	coreURL := fmt.Sprintf("%s://%s.%s", URLScheme(ctx, c), CoreDeploymentID, domain)
+	return extensionregistry.NewClient(coreURL, extensionregistry.Options{CA: ca, Debug: debug}), nil
 }
 
 func NewExtension(name, repository string, timeout int, debug bool) *Extension {
@@ -199,12 +191,11 @@ func NewExtension(name, repository string, timeout int, debug bool) *Extension {
 		Desc:       &extensionDesc{},
 		Debug:      debug,
 		Timeout:    timeout,
-		httpClient: NewHttpClient(debug),
 	}
 }
 
 // LoadDescription finds the description file of the extension and loads it into the struct
-func (e *Extension) LoadDescription() error {
+func (e *Extension) LoadDescription(ctx context.Context) error {
 
 	u, err := url.Parse(e.Repository)
 	if err != nil {
@@ -223,7 +214,7 @@ func (e *Extension) LoadDescription() error {
 		// "/" at the end is necessary so that last part of the path is not replaced
 		u, _ = u.Parse(e.Name + "/")
 		u, _ = u.Parse(defaultDescriptionFileName)
-		if err := helpers.DownloadFile(u.String(), defaultDescriptionFileName, tmpDir); err != nil {
+		if err := helpers.DownloadFile(ctx, u.String(), defaultDescriptionFileName, tmpDir); err != nil {
 			return err
 		}
 		descFilePath = filepath.Join(tmpDir, defaultDescriptionFileName)
@@ -253,10 +244,60 @@ func (e *Extension) LoadDescription() error {
 	return nil
 }
 
+// ListExtensions returns the extensions available in the repository, with their descriptions loaded.
+// The local repository contains a directory with the description file for each extension, the remote
+// one has to provide the index file with the list of extension names, unless it is on GitHub
+// (where the directories with the description file are listed).
+func ListExtensions(ctx context.Context, repository string, timeout int, debug bool) ([]*Extension, error) {
+	names := []string{}
+	// the listed directories may not be extensions
+	listed := false
+
+	u, err := url.Parse(repository)
+	if err != nil {
+		return nil, err
+	}
+	if u.IsAbs() && u.Scheme != "" && u.Host != "" {
+		tmpDir, err := ioutil.TempDir("", tmpSubDir)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't create temp directory "+tmpDir)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		names, listed, err = remoteExtensionNames(ctx, u, tmpDir)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		entries, err := os.ReadDir(repository)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read extensions repository")
+		}
+		for _, entry := range entries {
+			if _, err := os.Stat(filepath.Join(repository, entry.Name(), defaultDescriptionFileName)); entry.IsDir() && err == nil {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+
+	extensions := []*Extension{}
+	for _, name := range names {
+		extension := NewExtension(name, repository, timeout, debug)
+		if err := extension.LoadDescription(ctx); err != nil {
+			if listed {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to load description of extension %s", name)
+		}
+		extensions = append(extensions, extension)
+	}
+	return extensions, nil
+}
+
 // Pass the path string and return the absolute location of the file
 // If the path is relative, join it with the base repository path; if
 // the path is URL download it and return path to downloaded copy
-func (e *Extension) fetchFile(filePath, tmpDir string) (string, error) {
+func (e *Extension) fetchFile(ctx context.Context, filePath, tmpDir string) (string, error) {
 
 	// 1, local path is absolute, return right away
 	if filepath.IsAbs(filePath) {
@@ -270,7 +311,7 @@ func (e *Extension) fetchFile(filePath, tmpDir string) (string, error) {
 	}
 	// 2. full URL, download and return path to copy
 	if u.IsAbs() && u.Host != "" {
-		if err := helpers.DownloadFile(u.String(), name, tmpDir); err != nil {
+		if err := helpers.DownloadFile(ctx, u.String(), name, tmpDir); err != nil {
 			return "", err
 		}
 		return filepath.Join(tmpDir, name), nil
@@ -280,7 +321,7 @@ func (e *Extension) fetchFile(filePath, tmpDir string) (string, error) {
 	if u.IsAbs() && u.Host != "" {
 		u, _ = u.Parse(e.Name + "/")
 		u, _ = u.Parse(filePath)
-		if err := helpers.DownloadFile(u.String(), name, tmpDir); err != nil {
+		if err := helpers.DownloadFile(ctx, u.String(), name, tmpDir); err != nil {
 			return "", err
 		}
 		return filepath.Join(tmpDir, name), nil
@@ -292,7 +333,7 @@ func (e *Extension) fetchFile(filePath, tmpDir string) (string, error) {
 // Pass the path string and return the absolute location of the directory
 // If the path is relative, join it with the base repository path; if
 // the path is URL, return the URL
-func (e *Extension) getDirectoryPath(dirPath string) (string, error) {
+func (e *Extension) getDirectoryPath(ctx context.Context, dirPath string) (string, error) {
 
 	// 1, local path is absolute, return right away
 	if filepath.IsAbs(dirPath) {
@@ -324,8 +365,8 @@ func (e *Extension) getDirectoryPath(dirPath string) (string, error) {
 // extension repository path. The input dirPath can be an absolute
 // (local) path, a URL, or a path relative to the extension repository
 // path or URL
-func (e *Extension) getKustomizePath(dirPath string) (string, error) {
-	dirPath, err := e.getDirectoryPath(dirPath)
+func (e *Extension) getKustomizePath(ctx context.Context, dirPath string) (string, error) {
+	dirPath, err := e.getDirectoryPath(ctx, dirPath)
 	if err != nil {
 		return "", err
 	}
@@ -354,14 +395,14 @@ func (e *Extension) getKustomizePath(dirPath string) (string, error) {
 	return u.String(), nil
 }
 
-func (e *Extension) executeScript(path string) error {
+func (e *Extension) executeScript(ctx context.Context, path string, env []string) error {
 	tmpDir, err := ioutil.TempDir("", tmpSubDir)
 	if err != nil {
 		return errors.Wrap(err, "can't create temp directory "+tmpDir)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	fullCmd, err := e.fetchFile(path, tmpDir)
+	fullCmd, err := e.fetchFile(ctx, path, tmpDir)
 	if err != nil {
 		return errors.Wrap(err, "failed fetching file from "+path)
 	}
@@ -370,55 +411,65 @@ func (e *Extension) executeScript(path string) error {
 		return errors.New(fmt.Sprintf("Failed changing the file mode of %s", fullCmd))
 	}
 
-	if out, err := helpers.RunProc(fullCmd, tmpDir, e.Debug); err != nil {
+	// the scripts should label the resources they create with the owner label
+	env = append(env, "FUSEML_OWNER_LABEL="+kubernetes.ExtensionOwner(e.Name).Label())
+	if out, err := helpers.RunProcEnv(ctx, fullCmd, tmpDir, e.Debug, env); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Failed running script: %s\n", out))
 	}
 
 	return nil
 }
 
-func (e *Extension) installManifest(ui *ui.UI, path, ns string) error {
+func (e *Extension) installManifest(ctx context.Context, ui *ui.UI, path, ns string, ic installContext) error {
 	tmpDir, err := ioutil.TempDir("", tmpSubDir)
 	if err != nil {
 		return errors.Wrap(err, "can't create temp directory "+tmpDir)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	manifestLocalPath, err := e.fetchFile(path, tmpDir)
+	manifestLocalPath, err := e.fetchFile(ctx, path, tmpDir)
 	if err != nil {
 		return errors.Wrap(err, "failed fetching file from "+path)
 	}
+	manifestLocalPath, err = renderFile(manifestLocalPath, tmpDir, ic)
+	if err != nil {
+		return err
+	}
 
 	kubectlCmd := fmt.Sprintf("apply --filename %s", manifestLocalPath)
 	if ns != "" {
 		kubectlCmd = kubectlCmd + " --namespace " + ns
 	}
-	out, err := helpers.KubectlWithProgress(ui, kubectlCmd)
+	out, err := helpers.KubectlWithProgress(ctx, ui, kubectlCmd)
 
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("kubectl apply failed:\n%s", out))
 	}
-	return nil
+	return labelManifest(ctx, kubernetes.ExtensionOwner(e.Name), manifestLocalPath, ns)
 }
 
-func (e *Extension) uninstallManifest(ui *ui.UI, path, ns string) error {
+func (e *Extension) uninstallManifest(ctx context.Context, ui *ui.UI, path, ns string, ic installContext) error {
 	tmpDir, err := ioutil.TempDir("", tmpSubDir)
 	if err != nil {
 		return errors.Wrap(err, "can't create temp directory "+tmpDir)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	manifestLocalPath, err := e.fetchFile(path, tmpDir)
+	manifestLocalPath, err := e.fetchFile(ctx, path, tmpDir)
 	if err != nil {
 		return errors.Wrap(err, "failed fetching file from "+path)
 	}
+	manifestLocalPath, err = renderFile(manifestLocalPath, tmpDir, ic)
+	if err != nil {
+		return err
+	}
 
 	kubectlCmd := fmt.Sprintf("delete --filename %s --ignore-not-found", manifestLocalPath)
 	if ns != "" {
 		kubectlCmd = kubectlCmd + " --namespace " + ns
 	}
 
-	out, err := helpers.KubectlWithProgress(ui, kubectlCmd)
+	out, err := helpers.KubectlWithProgress(ctx, ui, kubectlCmd)
 
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("kubectl delete failed:\n%s", out))
@@ -426,14 +477,14 @@ func (e *Extension) uninstallManifest(ui *ui.UI, path, ns string) error {
 	return nil
 }
 
-func (e *Extension) installKustomize(ui *ui.UI, path, ns string) error {
+func (e *Extension) installKustomize(ctx context.Context, ui *ui.UI, path, ns string) error {
 	tmpDir, err := ioutil.TempDir("", tmpSubDir)
 	if err != nil {
 		return errors.Wrap(err, "can't create temp directory "+tmpDir)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	kustomizeDir, err := e.getKustomizePath(path)
+	kustomizeDir, err := e.getKustomizePath(ctx, path)
 	if err != nil {
 		return errors.Wrap(err, "failed fetching directory from "+path)
 	}
@@ -442,22 +493,26 @@ func (e *Extension) installKustomize(ui *ui.UI, path, ns string) error {
 	if ns != "" {
 		kubectlCmd = kubectlCmd + " --namespace " + ns
 	}
-	out, err := helpers.KubectlWithProgress(ui, kubectlCmd)
+	out, err := helpers.KubectlWithProgress(ctx, ui, kubectlCmd)
 
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("kubectl apply failed:\n%s", out))
 	}
-	return nil
+	args := "--kustomize " + kustomizeDir
+	if ns != "" {
+		args = args + " --namespace " + ns
+	}
+	return labelOwned(ctx, kubernetes.ExtensionOwner(e.Name), args)
 }
 
-func (e *Extension) uninstallKustomize(ui *ui.UI, path, ns string) error {
+func (e *Extension) uninstallKustomize(ctx context.Context, ui *ui.UI, path, ns string) error {
 	tmpDir, err := ioutil.TempDir("", tmpSubDir)
 	if err != nil {
 		return errors.Wrap(err, "can't create temp directory "+tmpDir)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	kustomizeDir, err := e.getKustomizePath(path)
+	kustomizeDir, err := e.getKustomizePath(ctx, path)
 	if err != nil {
 		return errors.Wrap(err, "failed fetching directory from "+path)
 	}
@@ -466,7 +521,7 @@ func (e *Extension) uninstallKustomize(ui *ui.UI, path, ns string) error {
 	if ns != "" {
 		kubectlCmd = kubectlCmd + " --namespace " + ns
 	}
-	out, err := helpers.KubectlWithProgress(ui, kubectlCmd)
+	out, err := helpers.KubectlWithProgress(ctx, ui, kubectlCmd)
 
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("kubectl apply failed:\n%s", out))
@@ -474,8 +529,21 @@ func (e *Extension) uninstallKustomize(ui *ui.UI, path, ns string) error {
 	return nil
 }
 
+// policyRules returns the rules the extension grants to the workloads service account
+func (e *Extension) policyRules() []rbacv1.PolicyRule {
+	rules := []rbacv1.PolicyRule{}
+	for _, rule := range e.Desc.RoleRules {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: rule.ApiGroups,
+			Resources: rule.Resources,
+			Verbs:     rule.Verbs,
+		})
+	}
+	return rules
+}
+
 // Install helm chart. installStep provides the information about the chart location
-func (e *Extension) installHelmChart(ui *ui.UI, name string, ns string, desc installStep, reinstall bool) error {
+func (e *Extension) installHelmChart(ctx context.Context, ui *ui.UI, name string, ns string, desc installStep, reinstall bool, ic installContext) error {
 
 	tmpDir, err := ioutil.TempDir("", tmpSubDir)
 	if err != nil {
@@ -495,7 +563,7 @@ func (e *Extension) installHelmChart(ui *ui.UI, name string, ns string, desc ins
 
 	action := "install"
 
-	out, _ := helpers.RunProc(helmCmd, currentdir, e.Debug)
+	out, _ := helpers.RunProc(ctx, helmCmd, currentdir, e.Debug)
 	if strings.TrimSpace(out) == name {
 		if reinstall {
 			action = "upgrade"
@@ -508,7 +576,7 @@ func (e *Extension) installHelmChart(ui *ui.UI, name string, ns string, desc ins
 	chartLocalPath := ""
 	if desc.Location != "" {
 		tarName := filepath.Base(desc.Location)
-		if err = helpers.DownloadFile(desc.Location, tarName, tmpDir); err != nil {
+		if err = helpers.DownloadFile(ctx, desc.Location, tarName, tmpDir); err != nil {
 			return errors.Wrap(err, "can't download helm chart for "+name)
 		}
 
@@ -519,13 +587,17 @@ func (e *Extension) installHelmChart(ui *ui.UI, name string, ns string, desc ins
 
 	valuesLocalPath := ""
 	if desc.Values != "" {
-		valuesLocalPath, err = e.fetchFile(desc.Values, tmpDir)
+		valuesLocalPath, err = e.fetchFile(ctx, desc.Values, tmpDir)
 		if err != nil {
 			return errors.Wrap(err, "failed fetching values file from "+desc.Values)
 		}
 		if _, err := os.Stat(valuesLocalPath); os.IsNotExist(err) {
 			return errors.New(fmt.Sprintf("values file %s does not exist", valuesLocalPath))
 		}
+		valuesLocalPath, err = renderFile(valuesLocalPath, tmpDir, ic)
+		if err != nil {
+			return err
+		}
 	}
 	helmCmd = fmt.Sprintf("helm %s %s --create-namespace --values '%s' --wait %s", action, name, valuesLocalPath, chartLocalPath)
 	if chartLocalPath == "" && desc.Repo != "" {
@@ -540,14 +612,13 @@ func (e *Extension) installHelmChart(ui *ui.UI, name string, ns string, desc ins
 	if desc.Version != "" {
 		helmCmd = helmCmd + " --version " + desc.Version
 	}
-	if out, err := helpers.RunProc(helmCmd, currentdir, e.Debug); err != nil {
+	if out, err := helpers.RunProc(ctx, helmCmd, currentdir, e.Debug); err != nil {
 		return errors.New(fmt.Sprintf("Failed installing %s chart (%s): %s", name, err, out))
 	}
-
-	return nil
+	return labelHelmRelease(ctx, kubernetes.ExtensionOwner(e.Name), name, ns)
 }
 
-func (e *Extension) uninstallHelmChart(ui *ui.UI, name, ns string) error {
+func (e *Extension) uninstallHelmChart(ctx context.Context, ui *ui.UI, name, ns string) error {
 
 	currentdir, err := os.Getwd()
 	if err != nil {
@@ -559,7 +630,7 @@ func (e *Extension) uninstallHelmChart(ui *ui.UI, name, ns string) error {
 			if ns != "" {
 				helmCmd = helmCmd + " --namespace " + ns
 			}
-			return helpers.RunProc(helmCmd, currentdir, e.Debug)
+			return helpers.RunProc(ctx, helmCmd, currentdir, e.Debug)
 		},
 	)
 	if err != nil {
@@ -573,11 +644,11 @@ func (e *Extension) uninstallHelmChart(ui *ui.UI, name, ns string) error {
 	return nil
 }
 
-func deleteNamespace(c *kubernetes.Cluster, ui *ui.UI, ns string) error {
+func deleteNamespace(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, ns string) error {
 
 	_, err := helpers.WaitForCommandCompletion(ui, "Deleting namespace "+ns,
 		func() (string, error) {
-			return "", c.DeleteNamespace(ns)
+			return "", c.DeleteNamespace(ctx, ns)
 		},
 	)
 	if err != nil {
@@ -586,12 +657,12 @@ func deleteNamespace(c *kubernetes.Cluster, ui *ui.UI, ns string) error {
 	return nil
 }
 
-func createNamespace(c *kubernetes.Cluster, ns string) error {
-	if exists, _ := c.NamespaceExists(ns); exists == true {
+func createNamespace(ctx context.Context, c *kubernetes.Cluster, ns string) error {
+	if exists, _ := c.NamespaceExists(ctx, ns); exists == true {
 		return nil
 	}
 	if _, err := c.Kubectl.CoreV1().Namespaces().Create(
-		context.Background(),
+		ctx,
 		&corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: ns,
@@ -601,15 +672,15 @@ func createNamespace(c *kubernetes.Cluster, ns string) error {
 	); err != nil {
 		return err
 	}
-	return c.LabelNamespace(coreDeploymentNamespace, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
+	return c.LabelNamespace(ctx, ns, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
 }
 
-func (e *Extension) Uninstall(c *kubernetes.Cluster, ui *ui.UI, options *kubernetes.InstallationOptions) error {
+func (e *Extension) Uninstall(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options *kubernetes.InstallationOptions) error {
 
 	namespace := e.Desc.Namespace
 
 	if namespace != "" {
-		if notOurs, _ := c.NamespaceExistsAndNotOwned(namespace); notOurs == true {
+		if notOurs, _ := c.NamespaceExistsAndNotOwned(ctx, namespace); notOurs == true {
 			ui.Exclamation().Msg(fmt.Sprintf(
 				"Namespace %s was not created by FuseML; not deleting extension %s",
 				namespace, e.Name))
@@ -617,168 +688,152 @@ func (e *Extension) Uninstall(c *kubernetes.Cluster, ui *ui.UI, options *kuberne
 		}
 	}
 
+	ic, err := e.newInstallContext(ctx, c, options)
+	if err != nil {
+		// the required extensions may be removed already, only the steps referencing them fail
+		ui.Exclamation().Msg(fmt.Sprintf("Failed resolving the extensions required by %s: %s", e.Name, err))
+	}
+	if err := e.runHooks(ctx, c, ui, preUninstallHook, false, ic); err != nil {
+		return err
+	}
+
 	// based on installation type (script/helm/manifest), proceed with uninstallation of each install step
 	for _, step := range e.Desc.Uninstall {
+		step, err := ic.renderStep(step)
+		if err != nil {
+			return errors.Wrapf(err, "failed to render %s step", step.Type)
+		}
 
 		ns := step.Namespace
 		if ns == "" {
 			ns = namespace
 		} else {
-			if notOurs, _ := c.NamespaceExistsAndNotOwned(ns); notOurs == true {
+			if notOurs, _ := c.NamespaceExistsAndNotOwned(ctx, ns); notOurs == true {
 				ui.Exclamation().Msg(fmt.Sprintf(
 					"Namespace exists but %s was not created by FuseML; skipping %s step of extension %s",
 					ns, step.Type, e.Name))
 				continue
 			}
 		}
-		switch step.Type {
-		case "helm":
-			// TODO shoud step have a Name too? Could there be multiple helm charts?
-			err := e.uninstallHelmChart(ui, e.Name, ns)
-			if err != nil {
-				return errors.Wrap(err, "failed to uninstall helm release "+e.Name)
-			}
-		case "manifest":
-			err := e.uninstallManifest(ui, step.Location, ns)
-			if err != nil {
-				return errors.Wrap(err, "failed to uninstall kubernetes manifest from "+step.Location)
-			}
-		case "kustomize":
-			err := e.uninstallKustomize(ui, step.Location, ns)
-			if err != nil {
-				return errors.Wrap(err, "failed to uninstall using kustomize directory "+step.Location)
-			}
-		case "script":
-			err := e.executeScript(step.Location)
-			if err != nil {
-				return errors.Wrap(err, "failed to install using "+step.Location)
-			}
-		default:
-			return errors.New("Unsupported step type: " + step.Type)
+		if err := e.undoStep(ctx, c, ui, step, ns, ic); err != nil {
+			return err
 		}
 		// delete namespace if it was specific to step
 		if step.Namespace != "" && step.Namespace != namespace && step.Namespace != defaultNamespace {
-			if err := deleteNamespace(c, ui, step.Namespace); err != nil {
+			if err := deleteNamespace(ctx, c, ui, step.Namespace); err != nil {
 				return err
 			}
 		}
 	}
+	if err := e.unexposeGateways(ctx, c); err != nil {
+		return err
+	}
+	// run before the namespace of the extension is gone, so the hooks can still use it
+	if err := e.runHooks(ctx, c, ui, postUninstallHook, false, ic); err != nil {
+		return err
+	}
+	if err := e.uninstallHookReleases(ctx, ui, ic); err != nil {
+		return err
+	}
 	// delete namespace if it was specific to extension
 	if e.Desc.Namespace != "" && e.Desc.Namespace != defaultNamespace {
-		if err := deleteNamespace(c, ui, e.Desc.Namespace); err != nil {
+		if err := deleteNamespace(ctx, c, ui, e.Desc.Namespace); err != nil {
 			return err
 		}
 	}
+
+	w := Workloads{}
+	if err := w.RevokeExtensionRules(ctx, c, e.Name, e.policyRules()); err != nil {
+		return errors.Wrap(err, "Failed updating workloads role")
+	}
 	return nil
 }
 
-// Unregister extension from the extension registry
-func (e *Extension) UnRegister(c *kubernetes.Cluster, ui *ui.UI, options *kubernetes.InstallationOptions) error {
-	domain, err := options.GetString("system_domain", "")
-	if err != nil {
-		return errors.New("system_domain value not provided")
+// unexposeGateways deletes the gateways or ingresses exposing the services of the extension
+func (e *Extension) unexposeGateways(ctx context.Context, c *kubernetes.Cluster) error {
+	if len(e.Desc.Gateways) == 0 {
+		return nil
 	}
-
-	fusemlURL := fmt.Sprintf("http://%s.%s", CoreDeploymentID, domain)
-	fullURL := fmt.Sprintf("%s/extensions/%s", fusemlURL, e.Desc.Name)
-
-	req, err := http.NewRequest("DELETE", fullURL, nil)
+	provider, err := IngressProviderFor(ctx, c)
 	if err != nil {
 		return err
 	}
+	for _, g := range e.Desc.Gateways {
+		ns := g.Namespace
+		if ns == "" {
+			ns = e.Desc.Namespace
+		}
+		err := provider.Unexpose(ctx, c, ExposedService{Name: g.Name, Namespace: ns, ServiceName: g.ServiceHost})
+		if err != nil {
+			return errors.Wrap(err, "Failed removing exposed "+g.Name)
+		}
+	}
+	return nil
+}
 
-	resp, err := e.httpClient.Do(req)
+// Unregister extension from the extension registry
+func (e *Extension) UnRegister(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options *kubernetes.InstallationOptions) error {
+	client, err := RegistryClient(ctx, c, options, e.Debug)
 	if err != nil {
 		return err
 	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNoContent {
-		return nil
-	}
-
 	// no such extension found, that might be OK...
-	if resp.StatusCode == http.StatusNotFound {
-		return nil
+	if err := client.Delete(ctx, e.Desc.Name); err != nil && !extensionregistry.IsNotFound(err) {
+		return err
 	}
-
-	// something else is wrong, read the response from DELETE call
-	var buf bytes.Buffer
-	_, _ = io.Copy(&buf, resp.Body)
-
-	return errors.New(fmt.Sprintf("Failed unregistering the extension. Server returns %s: ", buf.String()))
+	return nil
 }
 
 // Read all extensions stored in extensions repository
-func GetRegisteredExtensions(options *kubernetes.InstallationOptions, client *http.Client) ([]registeredExtension, error) {
-
-	extensions := make([]registeredExtension, 0)
-	domain, err := options.GetString("system_domain", "")
+func GetRegisteredExtensions(ctx context.Context, c *kubernetes.Cluster, options *kubernetes.InstallationOptions, debug bool) ([]extensionregistry.Extension, error) {
+	client, err := RegistryClient(ctx, c, options, debug)
 	if err != nil {
-		return extensions, errors.New("system_domain value not provided")
+		return nil, err
 	}
+	return client.List(ctx, extensionregistry.Query{})
+}
 
-	fusemlURL := fmt.Sprintf("http://%s.%s", CoreDeploymentID, domain)
-	fullURL := fmt.Sprintf("%s/extensions", fusemlURL)
-
-	query := registeredExtensionQuery{}
-
-	reqBody, err := json.Marshal(query)
-	if err != nil {
-		return extensions, err
+// Check if an extension is already registered
+func (e *Extension) isExtensionRegistered(ctx context.Context, client *extensionregistry.Client) (bool, error) {
+	if _, err := client.Get(ctx, e.Desc.Name); err != nil {
+		if extensionregistry.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
 	}
+	return true, nil
+}
 
-	req, err := http.NewRequest("GET", fullURL, bytes.NewBuffer(reqBody))
+// Verify checks that the extension is registered in fuseml-core
+func (e *Extension) Verify(ctx context.Context, c *kubernetes.Cluster, options *kubernetes.InstallationOptions) error {
+	client, err := RegistryClient(ctx, c, options, e.Debug)
 	if err != nil {
-		return extensions, err
+		return err
 	}
 
-	resp, err := client.Do(req)
+	registered, err := e.isExtensionRegistered(ctx, client)
 	if err != nil {
-		return extensions, err
+		return errors.Wrap(err, "Failed checking if an extension is registered")
 	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		var buf bytes.Buffer
-		_, _ = io.Copy(&buf, resp.Body)
-
-		err = yaml.Unmarshal(buf.Bytes(), &extensions)
-		if err != nil {
-			return extensions, errors.Wrap(err, "failed to parse description file")
-		}
-	} else {
-		return extensions, errors.New(fmt.Sprintf("Unexpected response from registry: %d", resp.StatusCode))
+	if !registered {
+		return errors.New(fmt.Sprintf("extension %s is not registered", e.Name))
 	}
-
-	return extensions, nil
+	return nil
 }
 
-// Check if an extension is already registered
-// argument is the URL of the FuseML service
-func (e *Extension) isExtensionRegistered(fusemlURL string) (bool, error) {
-
-	fullURL := fmt.Sprintf("%s/extensions/%s", fusemlURL, e.Desc.Name)
-	resp, err := http.Get(fullURL)
+// TransformCredentials goes through the 'servicecredentials' section in the description file
+// and for each service/credential combination it fetches the right value from Kubernetes using
+// the transformation rules written in said section
+func (e *Extension) TransformCredentials(ctx context.Context, c *kubernetes.Cluster, options *kubernetes.InstallationOptions) error {
+	ic, err := e.newInstallContext(ctx, c, options)
 	if err != nil {
-		return false, err
+		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		return true, nil
-	} else if resp.StatusCode == http.StatusNotFound {
-		return false, nil
+	generated, err := loadGeneratedValues(ctx, c, e.Name)
+	if err != nil {
+		return err
 	}
-	return false, errors.New(fmt.Sprintf("Unexpected response from registry: %d", resp.StatusCode))
-}
 
-// createTransformMaps goes through the 'servicecredentials' section in the description file
-// and for each service/credential combination it fetches the right value from Kubernetes using
-// the transformation rules written in said section
-func (e *Extension) createTransformMaps(c *kubernetes.Cluster) error {
 	// maps service id to map of credentials which maps credential id to value map, e.g.:
 	// mlflow-store : { default-s3-account : { key1: value1, key2: value2 } }
 	e.TransformedCredentials = make(map[string]map[string]map[string]string)
@@ -787,29 +842,31 @@ func (e *Extension) createTransformMaps(c *kubernetes.Cluster) error {
 		for _, cred := range service.Credentials {
 			e.TransformedCredentials[service.ServiceID][cred.ID] = make(map[string]string)
 			for _, transform := range cred.Transform {
+				if err := ic.renderValues(&transform); err != nil {
+					return errors.Wrapf(err, "failed to render credential %s of service %s", cred.ID, service.ServiceID)
+				}
 				// now find the right value and save it to the map
-				secret, err := c.GetSecret(transform.Namespace, transform.Secret)
+				key := generatedValueKey(service.ServiceID, cred.ID, transform.ConfigValue)
+				value, err := transform.value(ctx, c, ic, generated, key)
 				if err != nil {
-					return err
+					return errors.Wrapf(err, "failed to get %s of credential %s of service %s", transform.ConfigValue, cred.ID, service.ServiceID)
 				}
-				e.TransformedCredentials[service.ServiceID][cred.ID][transform.ConfigValue] = string(secret.Data[transform.SecretValue])
+				e.TransformedCredentials[service.ServiceID][cred.ID][transform.ConfigValue] = value
 			}
 		}
 	}
-	return nil
+	return generated.save(ctx, c)
 }
 
 // Register extension in the registry that is run by fuseml-core server
-func (e *Extension) Register(c *kubernetes.Cluster, ui *ui.UI, options *kubernetes.InstallationOptions) error {
+func (e *Extension) Register(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options *kubernetes.InstallationOptions) error {
 
-	domain, err := options.GetString("system_domain", "")
+	client, err := RegistryClient(ctx, c, options, e.Debug)
 	if err != nil {
-		return errors.New("system_domain value not provided")
+		return err
 	}
 
-	fusemlURL := fmt.Sprintf("http://%s.%s", CoreDeploymentID, domain)
-
-	registered, err := e.isExtensionRegistered(fusemlURL)
+	registered, err := e.isExtensionRegistered(ctx, client)
 	if err != nil {
 		return errors.Wrap(err, "Failed checking if an extension is already registered")
 	}
@@ -817,16 +874,20 @@ func (e *Extension) Register(c *kubernetes.Cluster, ui *ui.UI, options *kubernet
 		ui.Exclamation().Msg(fmt.Sprintf("Extension %s is already registered; if you want to update it, delete it first", e.Name))
 		return nil
 	}
-	err = e.createTransformMaps(c)
+	err = e.TransformCredentials(ctx, c, options)
 	if err != nil {
 		return errors.Wrap(err, "Failed to transform values for credentials")
 	}
+	ic, err := e.newInstallContext(ctx, c, options)
+	if err != nil {
+		return err
+	}
 
-	extServices := []*registeredExtensionService{}
+	extServices := []*extensionregistry.Service{}
 	for _, service := range e.Desc.Services {
-		extServiceEndpoints := []*registeredExtensionEndpoint{}
+		extServiceEndpoints := []*extensionregistry.Endpoint{}
 		for _, endpoint := range service.Endpoints {
-			serviceEndpoint := registeredExtensionEndpoint{
+			serviceEndpoint := extensionregistry.Endpoint{
 				URL:           endpoint.URL,
 				Type:          endpoint.Type,
 				Configuration: endpoint.Configuration,
@@ -834,9 +895,9 @@ func (e *Extension) Register(c *kubernetes.Cluster, ui *ui.UI, options *kubernet
 			extServiceEndpoints = append(extServiceEndpoints, &serviceEndpoint)
 		}
 
-		extServiceCredentials := []*registeredExtensionCredentials{}
+		extServiceCredentials := []*extensionregistry.Credentials{}
 		for _, creds := range service.Credentials {
-			serviceCredentials := registeredExtensionCredentials{
+			serviceCredentials := extensionregistry.Credentials{
 				ID:            creds.ID,
 				Default:       creds.Default,
 				Scope:         creds.Scope,
@@ -845,7 +906,9 @@ func (e *Extension) Register(c *kubernetes.Cluster, ui *ui.UI, options *kubernet
 				Configuration: make(map[string]string),
 			}
 			for key, val := range creds.Configuration {
-				serviceCredentials.Configuration[key] = val
+				if serviceCredentials.Configuration[key], err = ic.render(val); err != nil {
+					return errors.Wrapf(err, "failed to render %s of credential %s", key, *creds.ID)
+				}
 			}
 			// update credential Configuration with the values from Transform section
 			if e.TransformedCredentials[*service.ID][*creds.ID] != nil {
@@ -856,7 +919,7 @@ func (e *Extension) Register(c *kubernetes.Cluster, ui *ui.UI, options *kubernet
 			extServiceCredentials = append(extServiceCredentials, &serviceCredentials)
 		}
 
-		extService := registeredExtensionService{
+		extService := extensionregistry.Service{
 			ID:           service.ID,
 			Resource:     service.Resource,
 			Category:     service.Category,
@@ -868,7 +931,7 @@ func (e *Extension) Register(c *kubernetes.Cluster, ui *ui.UI, options *kubernet
 		extServices = append(extServices, &extService)
 	}
 
-	ext := registeredExtension{
+	ext := extensionregistry.Extension{
 		ID:          &e.Name,
 		Product:     &e.Desc.Product,
 		Version:     &e.Desc.Version,
@@ -876,37 +939,20 @@ func (e *Extension) Register(c *kubernetes.Cluster, ui *ui.UI, options *kubernet
 		Services:    extServices,
 	}
 
-	jsonValue, err := json.Marshal(ext)
-	if err != nil {
+	if _, err := client.Create(ctx, ext); err != nil {
 		return err
 	}
-	fullURL := fmt.Sprintf("%s/extensions", fusemlURL)
-
-	resp, err := e.httpClient.Post(fullURL, "application/json", bytes.NewBuffer(jsonValue))
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusCreated {
-		return nil
-	}
-
-	// something wrong, read the response from POST call
-	var buf bytes.Buffer
-	_, _ = io.Copy(&buf, resp.Body)
-
-	return errors.New(fmt.Sprintf("Failed registering the extension. Server returns %s: ", buf.String()))
+	return nil
 }
 
 // Create a namespace for an extension; checks for existing first and reports the results.
 // Returns boolean value indicating if creating of namespace was skipped for some reason
 // (if namespace already exists but reinstall is requested and possible, method acts like it created the namespace)
-func (e *Extension) createNamespaceIfAppropriate(c *kubernetes.Cluster, ui *ui.UI, reinstall bool, namespace string) (bool, error) {
+func (e *Extension) createNamespaceIfAppropriate(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, reinstall bool, namespace string) (bool, error) {
 
-	exists, _ := c.NamespaceExists(namespace)
+	exists, _ := c.NamespaceExists(ctx, namespace)
 	if exists {
-		owned, err := c.NamespaceOwned(namespace)
+		owned, err := c.NamespaceOwned(ctx, namespace)
 		if err != nil {
 			return false, err
 		}
@@ -927,11 +973,11 @@ func (e *Extension) createNamespaceIfAppropriate(c *kubernetes.Cluster, ui *ui.U
 			return false, err
 		}
 	}
-	err := createNamespace(c, namespace)
+	err := createNamespace(ctx, c, namespace)
 	return false, err
 }
 
-func (e *Extension) Install(c *kubernetes.Cluster, ui *ui.UI, options *kubernetes.InstallationOptions) error {
+func (e *Extension) Install(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options *kubernetes.InstallationOptions) error {
 
 	reinstall, err := options.GetBool("force_reinstall", "")
 	if err != nil {
@@ -940,8 +986,20 @@ func (e *Extension) Install(c *kubernetes.Cluster, ui *ui.UI, options *kubernete
 
 	namespace := e.Desc.Namespace
 
+	// the namespaces created by the interrupted installation do not mean the extension is installed
+	resuming := false
+	for i := range e.Desc.Install {
+		resuming = resuming || e.Checkpoints.Completed(extensionStepCheckpoint(e.Name, i))
+	}
+	ensureNamespace := func(ns string) (bool, error) {
+		if exists, _ := c.NamespaceExists(ctx, ns); exists && resuming {
+			return false, nil
+		}
+		return e.createNamespaceIfAppropriate(ctx, c, ui, reinstall, ns)
+	}
+
 	if namespace != "" {
-		skipped, err := e.createNamespaceIfAppropriate(c, ui, reinstall, namespace)
+		skipped, err := ensureNamespace(namespace)
 		if err != nil {
 			return err
 		} else if skipped {
@@ -949,11 +1007,36 @@ func (e *Extension) Install(c *kubernetes.Cluster, ui *ui.UI, options *kubernete
 		}
 	}
 
+	ic, err := e.newInstallContext(ctx, c, options)
+	if err != nil {
+		return err
+	}
+	// the credentials are generated before the steps, which may configure the services with them
+	if ic.Generated, err = e.generatedCredentials(ctx, c, true); err != nil {
+		return err
+	}
+	if err := e.runHooks(ctx, c, ui, preInstallHook, reinstall, ic); err != nil {
+		return err
+	}
+
 	// based on installation type (script/helm/manifest), proceed with execution of each install step
-	for _, step := range e.Desc.Install {
+	for i, step := range e.Desc.Install {
+		step, err := ic.renderStep(step)
+		if err != nil {
+			return errors.Wrapf(err, "failed to render %s step", step.Type)
+		}
+		checkpoint := extensionStepCheckpoint(e.Name, i)
+		if e.Checkpoints.Completed(checkpoint) {
+			// the step is not run again, but its resources still have to be ready
+			ui.Success().Msg(fmt.Sprintf("Step %d (%s) of extension '%s' was completed by the previous installation", i+1, step.Type, e.Name))
+			if err := e.waitFor(ctx, c, ui, step); err != nil {
+				return err
+			}
+			continue
+		}
 		ns := step.Namespace
 		if ns != "" {
-			skipped, err := e.createNamespaceIfAppropriate(c, ui, reinstall, ns)
+			skipped, err := ensureNamespace(ns)
 			if err != nil {
 				return err
 			} else if skipped {
@@ -964,36 +1047,11 @@ func (e *Extension) Install(c *kubernetes.Cluster, ui *ui.UI, options *kubernete
 			ns = namespace
 		}
 
-		switch step.Type {
-		case "helm":
-			err := e.installHelmChart(ui, e.Name, ns, step, reinstall)
-			if err != nil {
-				message := "failed to install helm package from " + step.Location
-				if step.Location == "" {
-					message = fmt.Sprintf("failed to install helm package %s from %s ", step.Chart, step.Repo)
-				}
-				return errors.Wrap(err, message)
-			}
-		case "manifest":
-			err := e.installManifest(ui, step.Location, ns)
-			if err != nil {
-				return errors.Wrap(err, "failed to install kubernetes manifest from "+step.Location)
-			}
-		case "kustomize":
-			err := e.installKustomize(ui, step.Location, ns)
-			if err != nil {
-				return errors.Wrap(err, "failed to install from kustomize directory "+step.Location)
-			}
-		case "script":
-			err := e.executeScript(step.Location)
-			if err != nil {
-				return errors.Wrap(err, "failed to install using "+step.Location)
-			}
-		default:
-			return errors.New("Unsupported step type: " + step.Type)
+		if err := e.runStep(ctx, c, ui, step, ns, reinstall, ic); err != nil {
+			return err
 		}
 		if step.Namespace != "" && step.Namespace != namespace {
-			err := c.LabelNamespace(
+			err := c.LabelNamespace(ctx,
 				step.Namespace,
 				kubernetes.FusemlDeploymentLabelKey,
 				kubernetes.FusemlDeploymentLabelValue)
@@ -1001,55 +1059,16 @@ func (e *Extension) Install(c *kubernetes.Cluster, ui *ui.UI, options *kubernete
 				return err
 			}
 		}
-		if len(step.WaitFor) == 0 {
-			continue
+		if err := e.waitFor(ctx, c, ui, step); err != nil {
+			return err
 		}
-		// wait until all wait steps are completed
-		for _, waitStep := range step.WaitFor {
-
-			condition := waitStep.Condition
-			if condition == "" {
-				condition = "Ready"
-			}
-			selection := "--all"
-			if waitStep.Selector != "all" {
-				selection = fmt.Sprintf("--selector=%s", waitStep.Selector)
-			}
-			timeout := waitStep.Timeout
-			if timeout == 0 {
-				timeout = e.Timeout
-			}
-			kind := waitStep.Kind
-			if kind == "" {
-				kind = "pod"
-			}
-			// Wait for a resource to exist before checking its status
-			if kind == "pod" && waitStep.Selector != "all" {
-				if err := c.WaitUntilPodBySelectorExist(ui, waitStep.Namespace, waitStep.Selector, timeout); err != nil {
-					return errors.Wrap(err, "failed while waiting for install step to finish")
-				}
-			}
-
-			message := fmt.Sprintf("waiting for install step to finish waiting for resource %s status to become %s", kind, condition)
-			out, err := helpers.WaitForCommandCompletion(ui, message,
-				func() (string, error) {
-					return helpers.Kubectl(fmt.Sprintf("wait --for=condition=%s %s --timeout=%ds -n %s %s",
-						condition,
-						selection,
-						timeout,
-						waitStep.Namespace,
-						kind))
-				},
-			)
-			if err != nil {
-				return errors.Wrap(err, fmt.Sprintf("%s failed:\n%s", message, out))
-			}
+		if err := e.Checkpoints.Record(ctx, checkpoint); err != nil {
+			return err
 		}
-
 	}
 
 	if e.Desc.Namespace != "" {
-		err := c.LabelNamespace(
+		err := c.LabelNamespace(ctx,
 			e.Desc.Namespace,
 			kubernetes.FusemlDeploymentLabelKey,
 			kubernetes.FusemlDeploymentLabelValue)
@@ -1058,13 +1077,19 @@ func (e *Extension) Install(c *kubernetes.Cluster, ui *ui.UI, options *kubernete
 		}
 	}
 
-	// create istio gateways if required
-	if c.HasIstio() && len(e.Desc.Gateways) > 0 {
+	// expose the services described by gateways
+	if len(e.Desc.Gateways) > 0 {
 		domain, err := options.GetString("system_domain", "")
 		if err != nil {
 			return errors.New("system_domain value not provided")
 		}
 
+		provider, err := IngressProviderFor(ctx, c)
+		if err != nil {
+			return err
+		}
+		scheme := URLScheme(ctx, c)
+
 		for _, g := range e.Desc.Gateways {
 
 			ns := g.Namespace
@@ -1072,39 +1097,183 @@ func (e *Extension) Install(c *kubernetes.Cluster, ui *ui.UI, options *kubernete
 				ns = namespace
 			}
 
-			message := "Creating istio ingress gateway for " + g.Name
 			host := g.Name + "." + domain
 			// If host is provided, use it and not the name
 			// For example, we want 'seldon' as a name and '*.seldon' as a hostname prefix
 			if g.HostPrefix != "" {
 				host = g.HostPrefix + "." + domain
 			}
-			out, err := helpers.WaitForCommandCompletion(ui, message,
-				func() (string, error) {
-					return helpers.CreateIstioIngressGateway(g.Name, ns, host, g.ServiceHost, g.Port)
-				},
-			)
+			err := provider.Expose(ctx, c, ui, ExposedService{
+				Name:        g.Name,
+				Namespace:   ns,
+				Host:        host,
+				Domain:      domain,
+				ServiceName: g.ServiceHost,
+				ServicePort: g.Port,
+				Owner:       kubernetes.ExtensionOwner(e.Name),
+			}, e.Timeout)
 			if err != nil {
-				return errors.Wrap(err, fmt.Sprintf("%s failed:\n%s", message, out))
+				return errors.Wrap(err, "Failed exposing "+g.Name)
 			}
 			if g.ServiceHost != "" {
-				ui.Success().KeeplineUnder(1).Msg(fmt.Sprintf("%s accessible at http://%s", g.Name, host))
+				ui.Success().KeeplineUnder(1).Msg(fmt.Sprintf("%s accessible at %s://%s", g.Name, scheme, host))
 			}
 		}
 	}
-	for _, rule := range e.Desc.RoleRules {
+	if len(e.Desc.RoleRules) > 0 {
 		w := Workloads{}
-		err := w.updateWorkloadsRole(c, rbacv1.PolicyRule{
-			APIGroups: rule.ApiGroups,
-			Resources: rule.Resources,
-			Verbs:     rule.Verbs,
-		})
-		if err != nil {
+		if err := w.GrantExtensionRules(ctx, c, e.Name, e.policyRules()); err != nil {
 			return errors.Wrap(err, "Failed updating workloads role")
 		}
 	}
 
+	if err := e.runHooks(ctx, c, ui, postInstallHook, reinstall, ic); err != nil {
+		return err
+	}
+
 	ui.Success().Msg(fmt.Sprintf("%s deployed.", e.Name))
 
 	return nil
 }
+
+// runStep executes the install step (of the given type) in the namespace
+func (e *Extension) runStep(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, step installStep, ns string, reinstall bool, ic installContext) error {
+	ic.templateFiles = step.Template
+	switch step.Type {
+	case "helm":
+		err := e.installHelmChart(ctx, ui, e.helmRelease(ic), ns, step, reinstall, ic)
+		if err != nil {
+			message := "failed to install helm package from " + step.Location
+			if step.Location == "" {
+				message = fmt.Sprintf("failed to install helm package %s from %s ", step.Chart, step.Repo)
+			}
+			return errors.Wrap(err, message)
+		}
+	case "manifest":
+		err := e.installManifest(ctx, ui, step.Location, ns, ic)
+		if err != nil {
+			return errors.Wrap(err, "failed to install kubernetes manifest from "+step.Location)
+		}
+	case "kustomize":
+		err := e.installKustomize(ctx, ui, step.Location, ns)
+		if err != nil {
+			return errors.Wrap(err, "failed to install from kustomize directory "+step.Location)
+		}
+	case "script":
+		err := e.executeScript(ctx, step.Location, ic.env())
+		if err != nil {
+			return errors.Wrap(err, "failed to install using "+step.Location)
+		}
+	case "job":
+		err := e.runJob(ctx, c, ui, step, ns, ic)
+		if err != nil {
+			return errors.Wrap(err, "failed to run job from "+step.Location)
+		}
+	case "patch":
+		err := e.applyPatch(ctx, ui, step, ns, false, ic)
+		if err != nil {
+			return errors.Wrapf(err, "failed to patch %s %s", step.Kind, step.Name)
+		}
+	case "wait-http":
+		err := e.waitHTTP(ctx, ui, step, true)
+		if err != nil {
+			return errors.Wrap(err, "failed waiting for "+step.URL)
+		}
+	case "olm":
+		err := e.subscribeOperator(ctx, ui, step, ns)
+		if err != nil {
+			return errors.Wrap(err, "failed to install operator "+step.Package)
+		}
+	default:
+		return errors.New("Unsupported step type: " + step.Type)
+	}
+	return nil
+}
+
+// undoStep removes what was installed by the step, it is the counterpart of runStep
+func (e *Extension) undoStep(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, step installStep, ns string, ic installContext) error {
+	ic.templateFiles = step.Template
+	switch step.Type {
+	case "helm":
+		err := e.uninstallHelmChart(ctx, ui, e.helmRelease(ic), ns)
+		if err != nil {
+			return errors.Wrap(err, "failed to uninstall helm release "+e.helmRelease(ic))
+		}
+	case "manifest":
+		err := e.uninstallManifest(ctx, ui, step.Location, ns, ic)
+		if err != nil {
+			return errors.Wrap(err, "failed to uninstall kubernetes manifest from "+step.Location)
+		}
+	case "kustomize":
+		err := e.uninstallKustomize(ctx, ui, step.Location, ns)
+		if err != nil {
+			return errors.Wrap(err, "failed to uninstall using kustomize directory "+step.Location)
+		}
+	case "script":
+		err := e.executeScript(ctx, step.Location, ic.env())
+		if err != nil {
+			return errors.Wrap(err, "failed to uninstall using "+step.Location)
+		}
+	case "job":
+		err := e.deleteJob(ctx, ui, step, ns, ic)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete job from "+step.Location)
+		}
+	case "patch":
+		err := e.applyPatch(ctx, ui, step, ns, true, ic)
+		if err != nil {
+			return errors.Wrapf(err, "failed to patch %s %s", step.Kind, step.Name)
+		}
+	case "wait-http":
+		err := e.waitHTTP(ctx, ui, step, false)
+		if err != nil {
+			return errors.Wrap(err, "failed waiting for "+step.URL)
+		}
+	case "olm":
+		err := e.unsubscribeOperator(ctx, ui, step, ns)
+		if err != nil {
+			return errors.Wrap(err, "failed to uninstall operator "+step.Package)
+		}
+	default:
+		return errors.New("Unsupported step type: " + step.Type)
+	}
+	return nil
+}
+
+// waitFor waits until the resources of all the waitfor entries of the step reach their state
+func (e *Extension) waitFor(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, step installStep) error {
+	// wait until all wait steps are completed
+	for _, waitStep := range step.WaitFor {
+		timeout := waitStep.Timeout
+		if timeout == 0 {
+			timeout = e.Timeout
+		}
+		kind := waitStep.Kind
+		if kind == "" {
+			kind = "pod"
+		}
+		condition := kubernetes.WaitCondition{
+			Kind:      kind,
+			Namespace: waitStep.Namespace,
+			Name:      waitStep.Name,
+			Selector:  waitStep.Selector,
+			Condition: waitStep.Condition,
+			JSONPath:  waitStep.JSONPath,
+			Value:     waitStep.Value,
+			Rollout:   waitStep.Rollout,
+			Exists:    waitStep.Exists,
+			Timeout:   time.Duration(timeout) * time.Second,
+		}
+
+		message := fmt.Sprintf("waiting for %s", condition)
+		_, err := helpers.WaitForCommandCompletion(ui, message,
+			func() (string, error) {
+				return "", c.Wait(ctx, condition)
+			},
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed while waiting for install step to finish")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,699 @@
+package deployments_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("ListExtensions", func() {
+	var repository string
+
+	BeforeEach(func() {
+		var err error
+		repository, err = ioutil.TempDir("", "fuseml-extensions")
+		Expect(err).ToNot(HaveOccurred())
+
+		for name, description := range map[string]string{
+			"minio":  "name: minio\ndescription: S3 storage\n",
+			"mlflow": "name: mlflow\ndescription: Experiment tracking\nrequires:\n- minio\n",
+		} {
+			Expect(os.MkdirAll(filepath.Join(repository, name), 0700)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(repository, name, "description.yaml"), []byte(description), 0600)).To(Succeed())
+		}
+		// not an extension
+		Expect(os.MkdirAll(filepath.Join(repository, "docs"), 0700)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(repository)
+	})
+
+	It("lists the extensions of the local repository with their descriptions", func() {
+		extensions, err := ListExtensions(context.Background(), repository, 10, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(extensions).To(HaveLen(2))
+		Expect(extensions[0].Name).To(Equal("minio"))
+		Expect(extensions[0].Desc.Description).To(Equal("S3 storage"))
+		Expect(extensions[1].Name).To(Equal("mlflow"))
+		Expect(extensions[1].Desc.Requires).To(Equal([]string{"minio"}))
+	})
+
+	It("lists the extensions of the remote repository from its index", func() {
+		Expect(ioutil.WriteFile(filepath.Join(repository, "index.yaml"), []byte("- mlflow\n"), 0600)).To(Succeed())
+		server := httptest.NewServer(http.FileServer(http.Dir(repository)))
+		defer server.Close()
+
+		extensions, err := ListExtensions(context.Background(), server.URL+"/", 10, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(extensions).To(HaveLen(1))
+		Expect(extensions[0].Name).To(Equal("mlflow"))
+		Expect(extensions[0].Desc.Description).To(Equal("Experiment tracking"))
+	})
+
+	It("returns ErrNoExtensionIndex for the remote repository without index", func() {
+		server := httptest.NewServer(http.FileServer(http.Dir(repository)))
+		defer server.Close()
+
+		_, err := ListExtensions(context.Background(), server.URL+"/", 10, false)
+		Expect(err).To(Equal(ErrNoExtensionIndex))
+	})
+
+	It("lists the directories of the repository on GitHub without index", func() {
+		files := http.FileServer(http.Dir(repository))
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/repos/fuseml/extensions/contents/installer" {
+				Expect(r.URL.Query().Get("ref")).To(Equal("main"))
+				fmt.Fprint(w, `[{"name": "minio", "type": "dir"}, {"name": "docs", "type": "dir"}, {"name": "README.md", "type": "file"}]`)
+				return
+			}
+			http.StripPrefix("/fuseml/extensions/main/installer", files).ServeHTTP(w, r)
+		}))
+		defer server.Close()
+
+		rawHost, api := GitHubRawHost, GitHubAPI
+		defer func() { GitHubRawHost, GitHubAPI = rawHost, api }()
+		GitHubRawHost = strings.TrimPrefix(server.URL, "http://")
+		GitHubAPI = server.URL
+
+		extensions, err := ListExtensions(context.Background(), server.URL+"/fuseml/extensions/main/installer/", 10, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extensions).To(HaveLen(1))
+		Expect(extensions[0].Name).To(Equal("minio"))
+	})
+
+	It("returns an error when the repository does not exist", func() {
+		_, err := ListExtensions(context.Background(), filepath.Join(repository, "missing"), 10, false)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Extension description", func() {
+	It("reads the services with the field names of the description", func() {
+		repository, err := ioutil.TempDir("", "fuseml-extensions")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(repository)
+		Expect(os.MkdirAll(filepath.Join(repository, "mlflow"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(repository, "mlflow", "description.yaml"), []byte(`name: mlflow
+services:
+- id: mlflow-tracking
+  category: experiment-tracking
+  authrequired: true
+  endpoints:
+  - url: http://mlflow
+    type: internal
+  credentials:
+  - id: default
+    default: true
+`), 0600)).To(Succeed())
+
+		extension := NewExtension("mlflow", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+		service := extension.Desc.Services[0]
+		Expect(*service.AuthRequired).To(BeTrue())
+		Expect(*service.Endpoints[0].URL).To(Equal("http://mlflow"))
+		Expect(*service.Credentials[0].Default).To(BeTrue())
+	})
+})
+
+var _ = Describe("Extension steps", func() {
+	var (
+		repository string
+		server     *httptest.Server
+		status     int
+		options    *kubernetes.InstallationOptions
+		cluster    *kubernetes.Cluster
+	)
+
+	BeforeEach(func() {
+		var err error
+		repository, err = ioutil.TempDir("", "fuseml-extensions")
+		Expect(err).ToNot(HaveOccurred())
+
+		status = http.StatusServiceUnavailable
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			status = http.StatusOK
+		}))
+
+		description := fmt.Sprintf(`name: probe
+install:
+- type: wait-http
+  url: %[1]s/health
+  timeout: 10
+uninstall:
+- type: wait-http
+  url: %[1]s/health
+  timeout: 10
+`, server.URL)
+		Expect(os.MkdirAll(filepath.Join(repository, "probe"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(repository, "probe", "description.yaml"), []byte(description), 0600)).To(Succeed())
+
+		options = &kubernetes.InstallationOptions{{Name: "force_reinstall", Type: kubernetes.BooleanType, Value: false}}
+		cluster = &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset()}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(repository)
+	})
+
+	It("waits for the endpoint to respond on install, and to stop on uninstall", func() {
+		extension := NewExtension("probe", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		Expect(extension.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+		Expect(status).To(Equal(http.StatusOK))
+
+		server.Close()
+		Expect(extension.Uninstall(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+	})
+
+	It("fails when the endpoint does not respond with the status in time", func() {
+		Expect(ioutil.WriteFile(filepath.Join(repository, "probe", "description.yaml"),
+			[]byte(fmt.Sprintf("name: probe\ninstall:\n- type: wait-http\n  url: %s\n  status: 204\n  timeout: 3\n", server.URL)), 0600)).To(Succeed())
+		extension := NewExtension("probe", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		err := extension.Install(context.Background(), cluster, ui.NewUI(), options)
+		Expect(err).To(MatchError(ContainSubstring("last response: 200 OK")))
+	})
+})
+
+var _ = Describe("Extension hooks", func() {
+	var (
+		repository string
+		server     *httptest.Server
+		requests   []string
+		options    *kubernetes.InstallationOptions
+		cluster    *kubernetes.Cluster
+	)
+
+	writeDescription := func(hooks string) {
+		description := fmt.Sprintf(`name: probe
+version: 1.2.0
+install:
+- type: wait-http
+  url: %[1]s/install
+uninstall:
+- type: wait-http
+  url: %[1]s/uninstall
+  status: 204
+hooks:
+%[2]s`, server.URL, hooks)
+		Expect(os.MkdirAll(filepath.Join(repository, "probe"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(repository, "probe", "description.yaml"), []byte(description), 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		repository, err = ioutil.TempDir("", "fuseml-extensions")
+		Expect(err).ToNot(HaveOccurred())
+
+		requests = []string{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.URL.Path)
+			if strings.HasPrefix(r.URL.Path, "/broken") {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+
+		options = &kubernetes.InstallationOptions{
+			{Name: "force_reinstall", Type: kubernetes.BooleanType, Value: false},
+			{Name: "system_domain", Type: kubernetes.StringType, Value: "example.com"},
+		}
+		cluster = &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset()}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(repository)
+	})
+
+	It("runs the hooks of each phase with the rendered context", func() {
+		writeDescription(fmt.Sprintf(`  pre-install:
+  - type: wait-http
+    url: %[1]s/{{ .Hook }}/{{ .Name }}/{{ .Version }}
+    template: true
+  post-install:
+  - type: wait-http
+    url: %[1]s/{{ .Hook }}/{{ .Domain }}
+    template: true
+  pre-uninstall:
+  - type: wait-http
+    url: %[1]s/{{ .Hook }}
+    template: true
+  post-uninstall:
+  - type: wait-http
+    url: %[1]s/{{ .Hook }}
+    template: true
+`, server.URL))
+		extension := NewExtension("probe", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		Expect(extension.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+		Expect(extension.Uninstall(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+		Expect(requests).To(Equal([]string{
+			"/pre-install/probe/1.2.0", "/install", "/post-install/example.com",
+			"/pre-uninstall", "/uninstall", "/post-uninstall",
+		}))
+	})
+
+	It("ignores the failures of the hooks when requested", func() {
+		writeDescription(fmt.Sprintf(`  pre-install:
+  - type: wait-http
+    url: %[1]s/broken
+    timeout: 1
+    failurepolicy: ignore
+`, server.URL))
+		extension := NewExtension("probe", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		Expect(extension.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+		Expect(requests).To(ContainElement("/install"))
+	})
+
+	It("aborts the installation when the hook fails", func() {
+		writeDescription(fmt.Sprintf(`  pre-install:
+  - type: wait-http
+    url: %[1]s/broken
+    timeout: 1
+`, server.URL))
+		extension := NewExtension("probe", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		err := extension.Install(context.Background(), cluster, ui.NewUI(), options)
+		Expect(err).To(MatchError(ContainSubstring("pre-install hook 1 (wait-http) of extension probe failed")))
+		Expect(requests).ToNot(ContainElement("/install"))
+	})
+
+	It("keeps the values of the steps which are not templates", func() {
+		writeDescription(fmt.Sprintf(`  post-install:
+  - type: wait-http
+    url: %[1]s/{{ .Hook }}
+`, server.URL))
+		extension := NewExtension("probe", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		Expect(extension.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+		Expect(requests).To(Equal([]string{"/install", "/{{ .Hook }}"}))
+	})
+
+	It("fails when the hook references an unknown value", func() {
+		writeDescription(fmt.Sprintf(`  post-install:
+  - type: wait-http
+    url: %[1]s/{{ .Unknown }}
+    template: true
+`, server.URL))
+		extension := NewExtension("probe", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		err := extension.Install(context.Background(), cluster, ui.NewUI(), options)
+		Expect(err).To(MatchError(ContainSubstring("failed to render template")))
+	})
+})
+
+var _ = Describe("Extension manifest steps", func() {
+	var (
+		repository string
+		binDir     string
+		applied    string
+		path       string
+		options    *kubernetes.InstallationOptions
+		cluster    *kubernetes.Cluster
+	)
+
+	writeFile := func(name, content string) {
+		Expect(ioutil.WriteFile(filepath.Join(repository, "knative", name), []byte(content), 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		repository, err = ioutil.TempDir("", "fuseml-extensions")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(repository, "knative"), 0700)).To(Succeed())
+
+		// kubectl stand-in recording the applied manifests
+		binDir, err = ioutil.TempDir("", "fuseml-bin")
+		Expect(err).ToNot(HaveOccurred())
+		applied = filepath.Join(binDir, "applied.yaml")
+		kubectl := fmt.Sprintf(`#!/bin/sh
+if [ "$1" = apply ]; then
+  while [ $# -gt 0 ]; do
+    if [ "$1" = --filename ]; then cat "$2" >> %s; fi
+    shift
+  done
+fi
+`, applied)
+		Expect(ioutil.WriteFile(filepath.Join(binDir, "kubectl"), []byte(kubectl), 0700)).To(Succeed())
+		path = os.Getenv("PATH")
+		os.Setenv("PATH", binDir+string(os.PathListSeparator)+path)
+
+		options = &kubernetes.InstallationOptions{
+			{Name: "force_reinstall", Type: kubernetes.BooleanType, Value: false},
+			{Name: "system_domain", Type: kubernetes.StringType, Value: "example.com"},
+		}
+		cluster = &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset()}
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(binDir)
+		os.RemoveAll(repository)
+	})
+
+	It("renders only the files of the steps marked as templates", func() {
+		writeFile("description.yaml", `name: knative
+install:
+- type: manifest
+  location: config-network.yaml
+- type: manifest
+  location: config-domain.yaml
+  template: true
+`)
+		writeFile("config-network.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-network
+data:
+  domain-template: "{{.Name}}.{{.Namespace}}.{{.Domain}}"
+`)
+		writeFile("config-domain.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-domain
+data:
+  {{ .Domain }}: ""
+`)
+		extension := NewExtension("knative", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		Expect(extension.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+		manifests, err := ioutil.ReadFile(applied)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(manifests)).To(ContainSubstring(`domain-template: "{{.Name}}.{{.Namespace}}.{{.Domain}}"`))
+		Expect(string(manifests)).To(ContainSubstring(`example.com: ""`))
+	})
+
+	It("continues with the first step not completed by the previous installation", func() {
+		writeFile("description.yaml", `name: knative
+install:
+- type: manifest
+  location: config-network.yaml
+- type: manifest
+  location: config-domain.yaml
+`)
+		writeFile("config-network.yaml", "kind: ConfigMap\nmetadata:\n  name: config-network\n")
+		writeFile("config-domain.yaml", "kind: ConfigMap\nmetadata:\n  name: config-domain\n")
+		checkpoints, err := LoadCheckpoints(context.Background(), cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoints.Record(context.Background(), "extension-knative.step-0")).To(Succeed())
+
+		extension := NewExtension("knative", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+		extension.Checkpoints = checkpoints
+
+		Expect(extension.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+		manifests, err := ioutil.ReadFile(applied)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(manifests)).ToNot(ContainSubstring("config-network"))
+		Expect(string(manifests)).To(ContainSubstring("config-domain"))
+
+		checkpoints, err = LoadCheckpoints(context.Background(), cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoints.Completed("extension-knative.step-1")).To(BeTrue())
+	})
+})
+
+var _ = Describe("Extension outputs", func() {
+	var (
+		repository string
+		server     *httptest.Server
+		requests   []string
+		options    *kubernetes.InstallationOptions
+		cluster    *kubernetes.Cluster
+	)
+
+	writeDescription := func(name, description string) {
+		Expect(os.MkdirAll(filepath.Join(repository, name), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(repository, name, "description.yaml"), []byte(description), 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		repository, err = ioutil.TempDir("", "fuseml-extensions")
+		Expect(err).ToNot(HaveOccurred())
+
+		requests = []string{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.URL.RequestURI())
+		}))
+
+		writeDescription("minio", `name: minio
+namespace: fuseml-minio
+outputs:
+- name: endpoint
+  service: minio
+- name: accesskey
+  secret: minio-credentials
+  key: accesskey
+- name: region
+  configmap: minio-config
+  key: region
+- name: bucket
+  value: "{{ .Name }}-data"
+`)
+
+		options = &kubernetes.InstallationOptions{{Name: "force_reinstall", Type: kubernetes.BooleanType, Value: false}}
+		cluster = &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "minio-credentials", Namespace: "fuseml-minio"},
+				Data:       map[string][]byte{"accesskey": []byte("admin")},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "minio-config", Namespace: "fuseml-minio"},
+				Data:       map[string]string{"region": "eu"},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "minio", Namespace: "fuseml-minio"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 9000}}},
+			},
+		)}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(repository)
+	})
+
+	It("provides the outputs of the required extensions to the install steps", func() {
+		writeDescription("mlflow", fmt.Sprintf(`name: mlflow
+requires:
+- minio
+install:
+- type: wait-http
+  url: %s/{{ .Requires.minio.outputs.bucket }}?endpoint={{ .Requires.minio.outputs.endpoint }}&key={{ .Requires.minio.outputs.accesskey }}&region={{ .Requires.minio.outputs.region }}&ns={{ .Requires.minio.namespace }}
+  template: true
+`, server.URL))
+		extension := NewExtension("mlflow", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		Expect(extension.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+		Expect(requests).To(Equal([]string{
+			"/minio-data?endpoint=http://minio.fuseml-minio.svc.cluster.local:9000&key=admin&region=eu&ns=fuseml-minio",
+		}))
+	})
+
+	It("fails when the referenced output is missing", func() {
+		writeDescription("mlflow", fmt.Sprintf(`name: mlflow
+requires:
+- minio
+install:
+- type: wait-http
+  url: %s/{{ .Requires.minio.outputs.secretkey }}
+  template: true
+`, server.URL))
+		extension := NewExtension("mlflow", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		err := extension.Install(context.Background(), cluster, ui.NewUI(), options)
+		Expect(err).To(MatchError(ContainSubstring(`map has no entry for key "secretkey"`)))
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("fails when the output of the required extension can't be resolved", func() {
+		writeDescription("mlflow", "name: mlflow\nrequires:\n- minio\n")
+		cluster = &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset()}
+		extension := NewExtension("mlflow", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		err := extension.Install(context.Background(), cluster, ui.NewUI(), options)
+		Expect(err).To(MatchError(ContainSubstring("failed to resolve output endpoint of extension minio")))
+	})
+})
+
+var _ = Describe("Extension credentials", func() {
+	var (
+		repository string
+		kube       *fake.Clientset
+		cluster    *kubernetes.Cluster
+		options    *kubernetes.InstallationOptions
+	)
+
+	BeforeEach(func() {
+		var err error
+		repository, err = ioutil.TempDir("", "fuseml-extensions")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(repository, "minio"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(repository, "minio", "description.yaml"), []byte(`name: minio
+namespace: fuseml-minio
+servicecredentials:
+- serviceid: s3
+  credentials:
+  - id: default
+    transform:
+    - configvalue: user
+      secret: minio-credentials
+      secretvalue: user
+    - configvalue: region
+      configmap: minio-config
+      configmapvalue: region
+    - configvalue: address
+      kind: service
+      name: minio
+      jsonpath: "{.status.loadBalancer.ingress[0].ip}"
+    - configvalue: bucket
+      value: "{{ .Name }}-{{ .Domain }}"
+    - configvalue: password
+      generate: true
+      length: 12
+`), 0600)).To(Succeed())
+
+		service := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": "minio", "namespace": "fuseml-minio"},
+			"status": map[string]interface{}{"loadBalancer": map[string]interface{}{
+				"ingress": []interface{}{map[string]interface{}{"ip": "10.0.0.1"}},
+			}},
+		}}
+		mapper := meta.NewDefaultRESTMapper(nil)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+
+		kube = fake.NewSimpleClientset(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "minio-credentials", Namespace: "fuseml-minio"},
+				Data:       map[string][]byte{"user": []byte("admin")},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "minio-config", Namespace: "fuseml-minio"},
+				Data:       map[string]string{"region": "eu"},
+			},
+		)
+		cluster = &kubernetes.Cluster{
+			Kubectl: kube,
+			Mapper:  mapper,
+			Dynamic: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), service),
+		}
+		options = &kubernetes.InstallationOptions{{Name: "system_domain", Type: kubernetes.StringType, Value: "example.com"}}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(repository)
+	})
+
+	It("reads the values of the credentials from their sources", func() {
+		extension := NewExtension("minio", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		Expect(extension.TransformCredentials(context.Background(), cluster, options)).To(Succeed())
+		credentials := extension.TransformedCredentials["s3"]["default"]
+		Expect(credentials).To(HaveLen(5))
+		Expect(credentials).To(HaveKeyWithValue("user", "admin"))
+		Expect(credentials).To(HaveKeyWithValue("region", "eu"))
+		Expect(credentials).To(HaveKeyWithValue("address", "10.0.0.1"))
+		Expect(credentials).To(HaveKeyWithValue("bucket", "minio-example.com"))
+		Expect(credentials["password"]).To(MatchRegexp("^[a-zA-Z0-9]{12}$"))
+	})
+
+	It("keeps the generated values for the following installations", func() {
+		extension := NewExtension("minio", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+		Expect(extension.TransformCredentials(context.Background(), cluster, options)).To(Succeed())
+		password := extension.TransformedCredentials["s3"]["default"]["password"]
+
+		secret, err := kube.CoreV1().Secrets("fuseml-core").Get(context.Background(), "fuseml-extension-minio-generated", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secret.Labels).To(HaveKeyWithValue(kubernetes.FusemlExtensionLabelKey, "minio"))
+		Expect(string(secret.Data["s3.default.password"])).To(Equal(password))
+
+		reinstalled := NewExtension("minio", repository, 1, false)
+		Expect(reinstalled.LoadDescription(context.Background())).To(Succeed())
+		Expect(reinstalled.TransformCredentials(context.Background(), cluster, options)).To(Succeed())
+		Expect(reinstalled.TransformedCredentials["s3"]["default"]["password"]).To(Equal(password))
+	})
+
+	It("generates the values before the install steps", func() {
+		requests := []string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.URL.Path)
+		}))
+		defer server.Close()
+		Expect(os.MkdirAll(filepath.Join(repository, "mlflow"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(repository, "mlflow", "description.yaml"), []byte(fmt.Sprintf(`name: mlflow
+install:
+- type: wait-http
+  url: %s/{{ .Generated.s3.default.password }}
+  template: true
+servicecredentials:
+- serviceid: s3
+  credentials:
+  - id: default
+    transform:
+    - configvalue: password
+      generate: true
+`, server.URL)), 0600)).To(Succeed())
+		options = &kubernetes.InstallationOptions{{Name: "force_reinstall", Type: kubernetes.BooleanType, Value: false}}
+
+		extension := NewExtension("mlflow", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+		Expect(extension.Install(context.Background(), cluster, ui.NewUI(), options)).To(Succeed())
+
+		secret, err := kube.CoreV1().Secrets("fuseml-core").Get(context.Background(), "fuseml-extension-mlflow-generated", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(secret.Data["s3.default.password"])).To(MatchRegexp("^[a-zA-Z0-9]{32}$"))
+		Expect(requests).To(Equal([]string{"/" + string(secret.Data["s3.default.password"])}))
+	})
+
+	It("fails when the value is missing in its source", func() {
+		Expect(kube.CoreV1().ConfigMaps("fuseml-minio").Delete(context.Background(), "minio-config", metav1.DeleteOptions{})).To(Succeed())
+		extension := NewExtension("minio", repository, 1, false)
+		Expect(extension.LoadDescription(context.Background())).To(Succeed())
+
+		err := extension.TransformCredentials(context.Background(), cluster, options)
+		Expect(err).To(MatchError(ContainSubstring("failed to get region of credential default of service s3")))
+	})
+})
@@ -17,6 +17,8 @@ import (
 type Gitea struct {
 	Debug   bool
 	Timeout int
+	// KeepData keeps the persistent volumes on Delete, for the next installation
+	KeepData bool
 }
 
 const (
@@ -25,15 +27,17 @@ const (
 	giteaChartURL     = "https://dl.gitea.io/charts/gitea-4.1.1.tgz"
 )
 
+var giteaPods = []string{"memcached", "postgresql", "gitea"}
+
 func (k *Gitea) ID() string {
 	return GiteaDeploymentID
 }
 
-func (k *Gitea) Backup(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (k *Gitea) Backup(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
-func (k *Gitea) Restore(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (k *Gitea) Restore(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
@@ -42,10 +46,10 @@ func (k Gitea) Describe() string {
 }
 
 // Delete removes Gitea from kubernetes cluster
-func (k Gitea) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
+func (k Gitea) Delete(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
 	ui.Note().KeeplineUnder(1).Msg("Removing Gitea...")
 
-	existsAndOwned, err := c.NamespaceExistsAndOwned(GiteaDeploymentID)
+	existsAndOwned, err := c.NamespaceExistsAndOwned(ctx, GiteaDeploymentID)
 	if err != nil {
 		return errors.Wrapf(err, "failed to check if namespace '%s' is owned or not", GiteaDeploymentID)
 	}
@@ -54,6 +58,12 @@ func (k Gitea) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 		return nil
 	}
 
+	if k.KeepData {
+		if err := retainData(ctx, c, ui, "Gitea", GiteaDeploymentID); err != nil {
+			return err
+		}
+	}
+
 	currentdir, err := os.Getwd()
 	if err != nil {
 		return errors.New("Failed uninstalling Gitea: " + err.Error())
@@ -63,7 +73,7 @@ func (k Gitea) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	out, err := helpers.WaitForCommandCompletion(ui, message,
 		func() (string, error) {
 			helmCmd := fmt.Sprintf("helm uninstall gitea --namespace %s", GiteaDeploymentID)
-			return helpers.RunProc(helmCmd, currentdir, k.Debug)
+			return helpers.RunProc(ctx, helmCmd, currentdir, k.Debug)
 		},
 	)
 	if err != nil {
@@ -77,7 +87,7 @@ func (k Gitea) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	message = "Deleting Gitea namespace " + GiteaDeploymentID
 	_, err = helpers.WaitForCommandCompletion(ui, message,
 		func() (string, error) {
-			return "", c.DeleteNamespace(GiteaDeploymentID)
+			return "", c.DeleteNamespace(ctx, GiteaDeploymentID)
 		},
 	)
 	if err != nil {
@@ -89,8 +99,9 @@ func (k Gitea) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	return nil
 }
 
-func (k Gitea) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
-	action := "install"
+func (k Gitea) apply(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
+	// upgrade --install also finishes the release interrupted by the previous installation
+	action := "upgrade --install"
 	if upgrade {
 		action = "upgrade"
 	}
@@ -99,14 +110,6 @@ func (k Gitea) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Instal
 	if err != nil {
 		return err
 	}
-	if action == "install" {
-		helmCmd := fmt.Sprintf("helm list --namespace %s --deployed -q | grep gitea", GiteaDeploymentID)
-		out, _ := helpers.RunProc(helmCmd, currentdir, k.Debug)
-		if strings.TrimSpace(out) == "gitea" {
-			ui.Exclamation().Msg("gitea already present under " + GiteaDeploymentID + " namespace, skipping installation")
-			return nil
-		}
-	}
 
 	// Setup Gitea helm values
 	var helmArgs []string
@@ -117,18 +120,11 @@ func (k Gitea) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Instal
 	}
 	subdomain := GiteaDeploymentID + "." + domain
 
-	hasIstio := c.HasIstio()
+	scheme := URLScheme(ctx, c)
 
 	config := fmt.Sprintf(`
 ingress:
-  enabled: %t
-  hosts:
-    - host: %s
-      paths:
-       - path: /
-         pathType: Prefix
-  annotations:
-    kubernetes.io/ingress.class: traefik
+  enabled: false
 
 gitea:
   admin:
@@ -152,7 +148,7 @@ gitea:
     oauth2:
       ENABLE: true
       JWT_SECRET: HLNn92qqtznZSMkD_TzR_XFVdiZ5E87oaus6pyH7tiI
-`, !hasIstio, subdomain, subdomain, "http://"+subdomain)
+`, subdomain, scheme+"://"+subdomain)
 
 	configPath, err := helpers.CreateTmpFile(config)
 	if err != nil {
@@ -160,64 +156,82 @@ gitea:
 	}
 	defer os.Remove(configPath)
 
+	if err := reattachData(ctx, c, ui, "Gitea", GiteaDeploymentID); err != nil {
+		return err
+	}
+
 	helmCmd := fmt.Sprintf("helm %s gitea --create-namespace --values %s --namespace %s %s %s", action, configPath, GiteaDeploymentID, giteaChartURL, strings.Join(helmArgs, " "))
 
-	if out, err := helpers.RunProc(helmCmd, currentdir, k.Debug); err != nil {
+	if out, err := helpers.RunProc(ctx, helmCmd, currentdir, k.Debug); err != nil {
 		return errors.New("Failed installing Gitea: " + out)
 	}
-	err = c.LabelNamespace(GiteaDeploymentID, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
+	if err := labelHelmRelease(ctx, kubernetes.ComponentOwner(GiteaDeploymentID), "gitea", GiteaDeploymentID); err != nil {
+		return err
+	}
+	err = c.LabelNamespace(ctx, GiteaDeploymentID, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
 	if err != nil {
 		return err
 	}
 
-	if hasIstio {
-		message := "Creating istio ingress gateway"
-		out, err := helpers.WaitForCommandCompletion(ui, message,
-			func() (string, error) {
-				return helpers.CreateIstioIngressGateway("gitea", GiteaDeploymentID, subdomain, "gitea-http", 3000)
-			},
-		)
-		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("%s failed:\n%s", message, out))
-		}
+	provider, err := IngressProviderFor(ctx, c)
+	if err != nil {
+		return err
+	}
+	err = provider.Expose(ctx, c, ui, ExposedService{
+		Name:        "gitea",
+		Namespace:   GiteaDeploymentID,
+		Host:        subdomain,
+		Domain:      domain,
+		ServiceName: "gitea-http",
+		ServicePort: 3000,
+		Owner:       kubernetes.ComponentOwner(GiteaDeploymentID),
+	}, k.Timeout)
+	if err != nil {
+		return errors.Wrap(err, "Failed exposing Gitea")
 	}
 
-	for _, podname := range []string{
-		"memcached",
-		"postgresql",
-		"gitea",
-	} {
-		if err := c.WaitUntilPodBySelectorExist(ui, GiteaDeploymentID, "app.kubernetes.io/name="+podname, k.Timeout); err != nil {
+	for _, podname := range giteaPods {
+		if err := c.WaitUntilPodBySelectorExist(ctx, ui, GiteaDeploymentID, "app.kubernetes.io/name="+podname, k.Timeout); err != nil {
 			return errors.Wrap(err, "failed waiting Gitea "+podname+" deployment to exist")
 		}
-		if err := c.WaitForPodBySelectorRunning(ui, GiteaDeploymentID, "app.kubernetes.io/name="+podname, k.Timeout); err != nil {
+		if err := c.WaitForPodBySelectorRunning(ctx, ui, GiteaDeploymentID, "app.kubernetes.io/name="+podname, k.Timeout); err != nil {
 			return errors.Wrap(err, "failed waiting Gitea "+podname+" deployment to come up")
 		}
 	}
 
-	ui.Success().Msg(fmt.Sprintf("Gitea deployed (http://%s).", subdomain))
+	ui.Success().Msg(fmt.Sprintf("Gitea deployed (%s://%s).", scheme, subdomain))
 
 	return nil
 }
 
+// Host returns the host name the installed Gitea is exposed at
+func (k Gitea) Host(ctx context.Context, c *kubernetes.Cluster) (string, error) {
+	provider, err := IngressProviderFor(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	return provider.Host(ctx, c, GiteaDeploymentID, "gitea")
+}
+
 func (k Gitea) GetVersion() string {
 	return giteaVersion
 }
 
-func (k Gitea) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
-
-	_, err := c.Kubectl.CoreV1().Namespaces().Get(
-		context.Background(),
-		GiteaDeploymentID,
-		metav1.GetOptions{},
-	)
-	if err == nil {
-		ui.Note().Msg("Namespace " + GiteaDeploymentID + " already present")
+// Verify checks that all Gitea pods are running
+func (k Gitea) Verify(ctx context.Context, c *kubernetes.Cluster) error {
+	for _, podname := range giteaPods {
+		if err := c.PodsReady(ctx, GiteaDeploymentID, "app.kubernetes.io/name="+podname); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+func (k Gitea) Deploy(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 
 	ui.Note().KeeplineUnder(1).Msg("Deploying Gitea...")
 
-	err = k.apply(c, ui, options, false)
+	err := k.apply(ctx, c, ui, options, false)
 	if err != nil {
 		return err
 	}
@@ -225,9 +239,9 @@ func (k Gitea) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Insta
 	return nil
 }
 
-func (k Gitea) Upgrade(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+func (k Gitea) Upgrade(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 	_, err := c.Kubectl.CoreV1().Namespaces().Get(
-		context.Background(),
+		ctx,
 		GiteaDeploymentID,
 		metav1.GetOptions{},
 	)
@@ -237,5 +251,5 @@ func (k Gitea) Upgrade(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Inst
 
 	ui.Note().Msg("Upgrading Gitea...")
 
-	return k.apply(c, ui, options, true)
+	return k.apply(ctx, c, ui, options, true)
 }
@@ -0,0 +1,326 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Ingress providers which could be selected by the ingress install option
+const (
+	IngressIstio    = "istio"
+	IngressTraefik  = "traefik"
+	IngressNginx    = "nginx"
+	IngressExisting = "existing"
+
+	ingressSetting        = "ingress"
+	ingressClassSetting   = "ingress_class"
+	ingressServiceSetting = "ingress_service"
+)
+
+// ExposedService describes a service which has to be reachable from outside of the cluster
+type ExposedService struct {
+	// Name of the gateway or ingress object
+	Name      string
+	Namespace string
+	Host      string
+	// System domain, used for issuing TLS certificates
+	Domain string
+	// Name of the service (could be in the form of name.namespace[.svc.cluster.local])
+	ServiceName string
+	ServicePort int
+	// Owner labels the objects created for the service
+	Owner kubernetes.Owner
+}
+
+// IngressProvider makes FuseML services reachable from outside of the cluster
+type IngressProvider interface {
+	// Name returns the value of the ingress option selecting the provider
+	Name() string
+	// Controller returns the deployment of the ingress controller, nil if the controller is not managed by FuseML
+	Controller(timeout int) kubernetes.Deployment
+	// LoadBalancerService returns the name of the service which receives the external traffic
+	LoadBalancerService() string
+	// IngressClass returns the IngressClass handled by the controller
+	IngressClass() string
+	// Expose creates the gateway or ingress for the service
+	Expose(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, svc ExposedService, timeout int) error
+	// Unexpose deletes the gateway or ingress created by Expose for the service
+	Unexpose(ctx context.Context, c *kubernetes.Cluster, svc ExposedService) error
+	// Host returns the host name the service exposed under given name is reachable at
+	Host(ctx context.Context, c *kubernetes.Cluster, namespace, name string) (string, error)
+}
+
+// NewIngressProvider returns the ingress provider selected by the installation options.
+// When no provider is selected, it is chosen based on the mesh and what is already present in the cluster.
+func NewIngressProvider(ctx context.Context, c *kubernetes.Cluster, options kubernetes.InstallationOptions, mesh *Mesh) (IngressProvider, error) {
+	name, err := options.GetString("ingress", "")
+	if err != nil {
+		return nil, err
+	}
+	class, err := options.GetString("ingress_class", "")
+	if err != nil {
+		return nil, err
+	}
+	service, err := options.GetString("ingress_service", "")
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = detectIngressProvider(ctx, c, mesh)
+	}
+	if name == IngressIstio && !mesh.Enabled() {
+		return nil, errors.New("istio ingress can't be used without the service mesh")
+	}
+	if name == IngressExisting && class == "" {
+		_, class, err = c.IngressClasses(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ingressProvider(name, class, service, mesh)
+}
+
+// SaveIngressProvider remembers the ingress provider chosen at installation time
+func SaveIngressProvider(ctx context.Context, c *kubernetes.Cluster, provider IngressProvider) error {
+	err := saveSettings(ctx, c, map[string]string{
+		ingressSetting:        provider.Name(),
+		ingressClassSetting:   provider.IngressClass(),
+		ingressServiceSetting: provider.LoadBalancerService(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed saving ingress settings")
+	}
+	return nil
+}
+
+// IngressProviderFor returns the ingress provider FuseML was installed with
+func IngressProviderFor(ctx context.Context, c *kubernetes.Cluster) (IngressProvider, error) {
+	mesh, err := MeshFor(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	name, err := loadSetting(ctx, c, ingressSetting)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		// installed before the ingress provider could be chosen
+		if mesh.Enabled() {
+			return ingressProvider(IngressIstio, "", "", mesh)
+		}
+		return ingressProvider(IngressTraefik, "", "", mesh)
+	}
+	class, err := loadSetting(ctx, c, ingressClassSetting)
+	if err != nil {
+		return nil, err
+	}
+	service, err := loadSetting(ctx, c, ingressServiceSetting)
+	if err != nil {
+		return nil, err
+	}
+	return ingressProvider(name, class, service, mesh)
+}
+
+func ingressProvider(name, class, service string, mesh *Mesh) (IngressProvider, error) {
+	switch name {
+	case IngressIstio:
+		return istioProvider{mesh: mesh}, nil
+	case IngressTraefik:
+		return ingressControllerProvider{
+			name:    IngressTraefik,
+			class:   traefikIngressClass,
+			service: TraefikDeploymentID,
+			controller: func(timeout int) kubernetes.Deployment {
+				return &Traefik{Timeout: timeout}
+			},
+		}, nil
+	case IngressNginx:
+		return ingressControllerProvider{
+			name:    IngressNginx,
+			class:   nginxIngressClass,
+			service: nginxServiceName,
+			controller: func(timeout int) kubernetes.Deployment {
+				return &Nginx{Timeout: timeout}
+			},
+		}, nil
+	case IngressExisting:
+		return ingressControllerProvider{
+			name:    IngressExisting,
+			class:   class,
+			service: service,
+		}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unsupported ingress %s (use %s, %s, %s or %s)",
+		name, IngressIstio, IngressTraefik, IngressNginx, IngressExisting))
+}
+
+// Use Istio when there is a mesh, otherwise prefer Traefik if it is already running in the cluster
+func detectIngressProvider(ctx context.Context, c *kubernetes.Cluster, mesh *Mesh) string {
+	if mesh.Enabled() {
+		return IngressIstio
+	}
+	if hasBundledTraefik(ctx, c) {
+		return IngressTraefik
+	}
+	return IngressNginx
+}
+
+// istioProvider exposes services using Istio gateways and virtual services
+type istioProvider struct {
+	mesh *Mesh
+}
+
+func (p istioProvider) Name() string {
+	return IngressIstio
+}
+
+// Istio is deployed as the mesh
+func (p istioProvider) Controller(timeout int) kubernetes.Deployment {
+	return nil
+}
+
+func (p istioProvider) LoadBalancerService() string {
+	return p.mesh.GatewayName
+}
+
+func (p istioProvider) IngressClass() string {
+	return ""
+}
+
+func (p istioProvider) Expose(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, svc ExposedService, timeout int) error {
+	// the secret has to be in the namespace of the ingress gateway
+	tlsSecret, err := tlsSecretFor(ctx, c, ui, p.mesh.GatewayNamespace, svc.Domain, timeout)
+	if err != nil {
+		return errors.Wrap(err, "Failed preparing TLS certificate for "+svc.Name)
+	}
+	message := "Creating istio ingress gateway for " + svc.Name
+	out, err := helpers.WaitForCommandCompletion(ui, message,
+		func() (string, error) {
+			return helpers.ApplyIstioGateway(ctx, helpers.IstioGateway{
+				Name:           svc.Name,
+				Namespace:      svc.Namespace,
+				Host:           svc.Host,
+				ServiceHost:    svc.ServiceName,
+				ServicePort:    svc.ServicePort,
+				CredentialName: tlsSecret,
+				Selector:       p.mesh.GatewaySelector,
+				Labels:         svc.Owner.Labels(),
+			})
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("%s failed:\n%s", message, out))
+	}
+	return nil
+}
+
+func (p istioProvider) Unexpose(ctx context.Context, c *kubernetes.Cluster, svc ExposedService) error {
+	for _, resource := range []string{"VirtualService " + svc.Name, "Gateway " + svc.Name + "-gateway"} {
+		out, err := helpers.Kubectl(ctx, fmt.Sprintf("delete %s -n %s --ignore-not-found", resource, svc.Namespace))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Failed deleting %s:\n%s", resource, out))
+		}
+	}
+	return nil
+}
+
+func (p istioProvider) Host(ctx context.Context, c *kubernetes.Cluster, namespace, name string) (string, error) {
+	return helpers.Kubectl(ctx, fmt.Sprintf("get VirtualService -n %s %s -o jsonpath='{.spec.hosts[0]}'", namespace, name))
+}
+
+// ingressControllerProvider exposes services using Ingress resources handled by the controller
+type ingressControllerProvider struct {
+	name       string
+	class      string
+	service    string
+	controller func(timeout int) kubernetes.Deployment
+}
+
+func (p ingressControllerProvider) Name() string {
+	return p.name
+}
+
+func (p ingressControllerProvider) Controller(timeout int) kubernetes.Deployment {
+	if p.controller == nil {
+		return nil
+	}
+	return p.controller(timeout)
+}
+
+func (p ingressControllerProvider) LoadBalancerService() string {
+	return p.service
+}
+
+func (p ingressControllerProvider) IngressClass() string {
+	return p.class
+}
+
+func (p ingressControllerProvider) Expose(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, svc ExposedService, timeout int) error {
+	if svc.ServiceName == "" {
+		ui.Exclamation().Msg(fmt.Sprintf("Skipping %s: gateways without a service are supported only by Istio", svc.Name))
+		return nil
+	}
+
+	namespace, serviceName := svc.ingressNamespace()
+	tlsSecret, err := tlsSecretFor(ctx, c, ui, namespace, svc.Domain, timeout)
+	if err != nil {
+		return errors.Wrap(err, "Failed preparing TLS certificate for "+svc.Name)
+	}
+	message := "Creating ingress for " + svc.Name
+	_, err = helpers.WaitForCommandCompletion(ui, message,
+		func() (string, error) {
+			return "", c.CreateIngress(ctx, kubernetes.Ingress{
+				Name:        svc.Name,
+				Namespace:   namespace,
+				Host:        svc.Host,
+				ServiceName: serviceName,
+				ServicePort: svc.ServicePort,
+				TLSSecret:   tlsSecret,
+				ClassName:   p.class,
+				Labels:      svc.Owner.Labels(),
+			})
+		},
+	)
+	// existing ingress is kept, e.g. on upgrade
+	if err != nil && !apierrors.IsAlreadyExists(errors.Cause(err)) {
+		return errors.Wrap(err, fmt.Sprintf("%s failed", message))
+	}
+	return nil
+}
+
+func (p ingressControllerProvider) Unexpose(ctx context.Context, c *kubernetes.Cluster, svc ExposedService) error {
+	if svc.ServiceName == "" {
+		return nil
+	}
+	namespace, _ := svc.ingressNamespace()
+	return c.DeleteIngress(ctx, namespace, svc.Name)
+}
+
+// ingressNamespace returns the namespace and the short name of the service,
+// the ingress has to be in the namespace of the service
+func (svc ExposedService) ingressNamespace() (string, string) {
+	parts := strings.Split(svc.ServiceName, ".")
+	if len(parts) > 1 {
+		return parts[1], parts[0]
+	}
+	return svc.Namespace, parts[0]
+}
+
+func (p ingressControllerProvider) Host(ctx context.Context, c *kubernetes.Cluster, namespace, name string) (string, error) {
+	hosts, err := c.ListIngressRoutes(ctx, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	if len(hosts) == 0 {
+		return "", errors.New(fmt.Sprintf("ingress %s not found in %s", name, namespace))
+	}
+	return hosts[0], nil
+}
@@ -35,11 +35,11 @@ func (i *Istio) ID() string {
 	return istioDeploymentID
 }
 
-func (i *Istio) Backup(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (i *Istio) Backup(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
-func (i *Istio) Restore(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (i *Istio) Restore(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
@@ -48,10 +48,10 @@ func (i Istio) Describe() string {
 }
 
 // Delete removes istio from kubernetes cluster
-func (i Istio) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
+func (i Istio) Delete(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
 	ui.Note().KeeplineUnder(1).Msg("Removing Istio...")
 
-	existsAndOwned, err := c.NamespaceExistsAndOwned(istioDeploymentNamespace)
+	existsAndOwned, err := c.NamespaceExistsAndOwned(ctx, istioDeploymentNamespace)
 	if err != nil {
 		return errors.Wrapf(err, "failed to check if namespace '%s' is owned or not", istioDeploymentNamespace)
 	}
@@ -66,7 +66,7 @@ func (i Istio) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	}
 	defer os.Remove(tmpDir)
 
-	istioctl, err := i.fetchIstioctl(tmpDir)
+	istioctl, err := i.fetchIstioctl(ctx, tmpDir)
 	if err != nil {
 		return errors.Wrap(err, "can't download istioctl")
 	}
@@ -78,14 +78,14 @@ func (i Istio) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	defer os.Remove(yamlPathOnDisk)
 
 	fullCmd := istioctl + " manifest generate -f " + yamlPathOnDisk + "| kubectl delete --ignore-not-found -f -"
-	if out, err := helpers.RunProc(fullCmd, tmpDir, i.Debug); err != nil {
+	if out, err := helpers.RunProc(ctx, fullCmd, tmpDir, i.Debug); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Failed uninstalling istio: %s\n", out))
 	}
 
 	message := "Deleting Istio namespace " + istioDeploymentNamespace
 	_, err = helpers.WaitForCommandCompletion(ui, message,
 		func() (string, error) {
-			return "", c.DeleteNamespace(istioDeploymentNamespace)
+			return "", c.DeleteNamespace(ctx, istioDeploymentNamespace)
 		},
 	)
 	if err != nil {
@@ -96,9 +96,9 @@ func (i Istio) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 }
 
 // Create kubernetes namespace for istio
-func (i Istio) createNamespace(c *kubernetes.Cluster, ui *ui.UI) error {
+func (i Istio) createNamespace(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
 	_, err := c.Kubectl.CoreV1().Namespaces().Create(
-		context.Background(),
+		ctx,
 		&corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: istioDeploymentNamespace,
@@ -113,19 +113,19 @@ func (i Istio) createNamespace(c *kubernetes.Cluster, ui *ui.UI) error {
 		return nil
 	}
 
-	if err := c.LabelNamespace(istioDeploymentNamespace, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue); err != nil {
+	if err := c.LabelNamespace(ctx, istioDeploymentNamespace, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (i Istio) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
+func (i Istio) apply(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
 	if upgrade {
 		return nil
 	}
 
-	if err := i.createNamespace(c, ui); err != nil {
+	if err := i.createNamespace(ctx, c, ui); err != nil {
 		return errors.Wrap(err, "Failed creating namespace for istio component")
 	}
 
@@ -135,7 +135,7 @@ func (i Istio) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Instal
 	}
 	defer os.Remove(tmpDir)
 
-	istioctl, err := i.fetchIstioctl(tmpDir)
+	istioctl, err := i.fetchIstioctl(ctx, tmpDir)
 	if err != nil {
 		return errors.Wrap(err, "can't download istioctl")
 	}
@@ -147,14 +147,19 @@ func (i Istio) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Instal
 	defer os.Remove(yamlPathOnDisk)
 
 	fullCmd := istioctl + " manifest install -yf " + yamlPathOnDisk
-	if out, err := helpers.RunProc(fullCmd, tmpDir, i.Debug); err != nil {
+	if out, err := helpers.RunProc(ctx, fullCmd, tmpDir, i.Debug); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Failed installing istio: %s\n", out))
 	}
+	err = labelOwned(ctx, kubernetes.ComponentOwner(istioDeploymentID),
+		fmt.Sprintf("--filename <(%s manifest generate -f %s)", istioctl, yamlPathOnDisk))
+	if err != nil {
+		return err
+	}
 
-	if err := c.WaitUntilPodBySelectorExist(ui, istioDeploymentNamespace, "app=istiod", i.Timeout); err != nil {
+	if err := c.WaitUntilPodBySelectorExist(ctx, ui, istioDeploymentNamespace, "app=istiod", i.Timeout); err != nil {
 		return errors.Wrap(err, "failed waiting for Istio deployment to exist")
 	}
-	if err := c.WaitForPodBySelectorRunning(ui, istioDeploymentNamespace, "app=istiod", i.Timeout); err != nil {
+	if err := c.WaitForPodBySelectorRunning(ctx, ui, istioDeploymentNamespace, "app=istiod", i.Timeout); err != nil {
 		return errors.Wrap(err, "failed waiting for Istio deployment to come up")
 	}
 
@@ -167,35 +172,30 @@ func (i Istio) GetVersion() string {
 	return istioVersion
 }
 
-func (i Istio) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+// Verify checks that istiod is running
+func (i Istio) Verify(ctx context.Context, c *kubernetes.Cluster) error {
+	return c.PodsReady(ctx, istioDeploymentNamespace, "app=istiod")
+}
 
-	if c.HasIstio() {
-		ui.Exclamation().Msg("Istio already installed, skipping ...")
-		return nil
-	}
+func (i Istio) Deploy(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 
-	_, err := c.Kubectl.CoreV1().Services("kube-system").Get(
-		context.Background(),
-		"traefik",
-		metav1.GetOptions{},
-	)
-	if err == nil {
-		ui.Exclamation().Msg("Traefik Ingress already installed, not installing Istio")
+	if c.HasIstio(ctx) {
+		ui.Exclamation().Msg("Istio already installed, skipping ...")
 		return nil
 	}
 
 	ui.Note().KeeplineUnder(1).Msg("Deploying Istio...")
 
-	return i.apply(c, ui, options, false)
+	return i.apply(ctx, c, ui, options, false)
 }
 
-func (k Istio) Upgrade(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+func (k Istio) Upgrade(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 	ui.Note().Msg("Upgrade not supported")
 	return nil
 }
 
-func (i Istio) fetchIstioctl(dir string) (string, error) {
-	err := helpers.DownloadFile(istioFetchScriptURL, istioFetchScript, dir)
+func (i Istio) fetchIstioctl(ctx context.Context, dir string) (string, error) {
+	err := helpers.DownloadFile(ctx, istioFetchScriptURL, istioFetchScript, dir)
 	if err != nil {
 		return "", errors.New(fmt.Sprintf("Failed downloading install script from %s: %s", istioFetchScriptURL, err.Error()))
 	}
@@ -207,7 +207,7 @@ func (i Istio) fetchIstioctl(dir string) (string, error) {
 	}
 
 	env := []string{"ISTIO_VERSION=" + i.GetVersion()}
-	if out, err := helpers.RunProcEnv(pathToFetchScript, dir, i.Debug, env); err != nil {
+	if out, err := helpers.RunProcEnv(ctx, pathToFetchScript, dir, i.Debug, env); err != nil {
 		return "", errors.Wrap(err, fmt.Sprintf("Failed downloading istio: %s\n", out))
 	}
 	return filepath.Join(dir, "istio-"+i.GetVersion(), "bin", "istioctl"), nil
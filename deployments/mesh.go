@@ -0,0 +1,192 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Service mesh modes which could be selected by the mesh install option
+const (
+	// MeshNone does not use Istio at all
+	MeshNone = "none"
+	// MeshBundled installs (and uninstalls) Istio together with FuseML
+	MeshBundled = "bundled"
+	// MeshExisting uses Istio installed in the cluster beforehand, which is never modified by FuseML
+	MeshExisting = "existing"
+
+	meshSetting                 = "mesh"
+	meshRevisionSetting         = "mesh_revision"
+	meshGatewaySetting          = "mesh_gateway"
+	meshGatewayNamespaceSetting = "mesh_gateway_namespace"
+	meshGatewaySelectorSetting  = "mesh_gateway_selector"
+)
+
+// Mesh describes the Istio installation used by FuseML
+type Mesh struct {
+	Mode     string
+	Revision string
+	// Name and namespace of the ingress gateway service
+	GatewayName      string
+	GatewayNamespace string
+	// Labels of the ingress gateway pods
+	GatewaySelector map[string]string
+}
+
+func bundledMesh() *Mesh {
+	return &Mesh{
+		Mode:             MeshBundled,
+		Revision:         "default",
+		GatewayName:      "istio-ingressgateway",
+		GatewayNamespace: istioDeploymentNamespace,
+		GatewaySelector:  map[string]string{"istio": "ingressgateway"},
+	}
+}
+
+// NewMesh returns the service mesh selected by the installation options.
+// When no mode is selected, it is chosen based on what is already present in the cluster.
+func NewMesh(ctx context.Context, c *kubernetes.Cluster, options kubernetes.InstallationOptions) (*Mesh, error) {
+	mode, err := options.GetString("mesh", "")
+	if err != nil {
+		return nil, err
+	}
+	ingress, err := options.GetString("ingress", "")
+	if err != nil {
+		return nil, err
+	}
+
+	istio, err := c.DetectIstio(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == "" {
+		mode, err = detectMesh(ctx, c, istio, ingress)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch mode {
+	case MeshNone:
+		if ingress == IngressIstio {
+			return nil, errors.New("istio ingress can't be used without the service mesh")
+		}
+		return &Mesh{Mode: MeshNone}, nil
+	case MeshBundled:
+		return bundledMesh(), nil
+	case MeshExisting:
+		if istio == nil {
+			return nil, errors.New("existing mesh was requested, but no Istio installation was found in the cluster")
+		}
+		if istio.GatewayName == "" {
+			return nil, errors.New("no Istio ingress gateway found (a service labeled istio=ingressgateway)")
+		}
+		return &Mesh{
+			Mode:             MeshExisting,
+			Revision:         istio.Revision,
+			GatewayName:      istio.GatewayName,
+			GatewayNamespace: istio.GatewayNamespace,
+			GatewaySelector:  istio.GatewaySelector,
+		}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unsupported mesh %s (use %s, %s or %s)", mode, MeshNone, MeshBundled, MeshExisting))
+}
+
+// Istio installed by previous FuseML installation is still bundled one, any other Istio is existing.
+// Without Istio in the cluster, it is installed unless other ingress is going to be used.
+func detectMesh(ctx context.Context, c *kubernetes.Cluster, istio *kubernetes.IstioInstallation, ingress string) (string, error) {
+	if istio != nil {
+		owned, err := c.NamespaceExistsAndOwned(ctx, istio.Namespace)
+		if err != nil {
+			return "", err
+		}
+		if owned {
+			return MeshBundled, nil
+		}
+		return MeshExisting, nil
+	}
+	if ingress != "" && ingress != IngressIstio {
+		return MeshNone, nil
+	}
+	if ingress == "" && hasBundledTraefik(ctx, c) {
+		return MeshNone, nil
+	}
+	return MeshBundled, nil
+}
+
+// Check for Traefik bundled with the kubernetes distribution (like k3s)
+func hasBundledTraefik(ctx context.Context, c *kubernetes.Cluster) bool {
+	_, err := c.Kubectl.CoreV1().Services("kube-system").Get(ctx, TraefikDeploymentID, metav1.GetOptions{})
+	return err == nil
+}
+
+// SaveMesh remembers the service mesh chosen at installation time
+func SaveMesh(ctx context.Context, c *kubernetes.Cluster, mesh *Mesh) error {
+	err := saveSettings(ctx, c, map[string]string{
+		meshSetting:                 mesh.Mode,
+		meshRevisionSetting:         mesh.Revision,
+		meshGatewaySetting:          mesh.GatewayName,
+		meshGatewayNamespaceSetting: mesh.GatewayNamespace,
+		meshGatewaySelectorSetting:  labels.Set(mesh.GatewaySelector).String(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed saving mesh settings")
+	}
+	return nil
+}
+
+// MeshFor returns the service mesh FuseML was installed with
+func MeshFor(ctx context.Context, c *kubernetes.Cluster) (*Mesh, error) {
+	mode, err := loadSetting(ctx, c, meshSetting)
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case "":
+		// installed before the mesh could be chosen
+		if c.HasIstio(ctx) {
+			return bundledMesh(), nil
+		}
+		return &Mesh{Mode: MeshNone}, nil
+	case MeshNone:
+		return &Mesh{Mode: MeshNone}, nil
+	}
+
+	mesh := &Mesh{Mode: mode}
+	for setting, value := range map[string]*string{
+		meshRevisionSetting:         &mesh.Revision,
+		meshGatewaySetting:          &mesh.GatewayName,
+		meshGatewayNamespaceSetting: &mesh.GatewayNamespace,
+	} {
+		if *value, err = loadSetting(ctx, c, setting); err != nil {
+			return nil, err
+		}
+	}
+	selector, err := loadSetting(ctx, c, meshGatewaySelectorSetting)
+	if err != nil {
+		return nil, err
+	}
+	mesh.GatewaySelector, err = labels.ConvertSelectorToLabelsMap(selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid mesh gateway selector")
+	}
+	return mesh, nil
+}
+
+// Controller returns the Istio deployment when it is managed by FuseML
+func (m *Mesh) Controller(timeout int) kubernetes.Deployment {
+	if m.Mode != MeshBundled {
+		return nil
+	}
+	return &Istio{Timeout: timeout}
+}
+
+// Enabled returns true when Istio is used by FuseML
+func (m *Mesh) Enabled() bool {
+	return m.Mode != MeshNone
+}
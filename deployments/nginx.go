@@ -0,0 +1,166 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+	"github.com/kyokomi/emoji"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Nginx struct {
+	Debug   bool
+	Timeout int
+}
+
+const (
+	NginxDeploymentID = "ingress-nginx"
+	nginxVersion      = "4.0.1"
+	nginxChartURL     = "https://github.com/kubernetes/ingress-nginx/releases/download/helm-chart-4.0.1/ingress-nginx-4.0.1.tgz"
+	nginxIngressClass = "nginx"
+	nginxServiceName  = "ingress-nginx-controller"
+	nginxSelector     = "app.kubernetes.io/name=ingress-nginx,app.kubernetes.io/component=controller"
+)
+
+func (k *Nginx) ID() string {
+	return NginxDeploymentID
+}
+
+func (k *Nginx) Backup(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
+	return nil
+}
+
+func (k *Nginx) Restore(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
+	return nil
+}
+
+func (k Nginx) Describe() string {
+	return emoji.Sprintf(":cloud:NGINX Ingress chart version: %s\n:clipboard:NGINX Ingress chart: %s", nginxVersion, nginxChartURL)
+}
+
+// Delete removes NGINX ingress controller from kubernetes cluster
+func (k Nginx) Delete(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
+	ui.Note().KeeplineUnder(1).Msg("Removing NGINX Ingress...")
+
+	existsAndOwned, err := c.NamespaceExistsAndOwned(ctx, NginxDeploymentID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if namespace '%s' is owned or not", NginxDeploymentID)
+	}
+	if !existsAndOwned {
+		ui.Exclamation().Msg("Skipping NGINX Ingress because namespace either doesn't exist or not owned by Fuseml")
+		return nil
+	}
+
+	currentdir, err := os.Getwd()
+	if err != nil {
+		return errors.New("Failed uninstalling NGINX Ingress: " + err.Error())
+	}
+
+	message := "Removing helm release " + NginxDeploymentID
+	out, err := helpers.WaitForCommandCompletion(ui, message,
+		func() (string, error) {
+			helmCmd := fmt.Sprintf("helm uninstall %s --namespace '%s'", NginxDeploymentID, NginxDeploymentID)
+			return helpers.RunProc(ctx, helmCmd, currentdir, k.Debug)
+		},
+	)
+	if err != nil {
+		if strings.Contains(out, "release: not found") {
+			ui.Exclamation().Msgf("%s helm release not found, skipping.\n", NginxDeploymentID)
+		} else {
+			return errors.Wrapf(err, "Failed uninstalling helm release %s: %s", NginxDeploymentID, out)
+		}
+	}
+
+	message = "Deleting NGINX Ingress namespace " + NginxDeploymentID
+	_, err = helpers.WaitForCommandCompletion(ui, message,
+		func() (string, error) {
+			return "", c.DeleteNamespace(ctx, NginxDeploymentID)
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "Failed deleting namespace %s", NginxDeploymentID)
+	}
+
+	ui.Success().Msg("NGINX Ingress removed")
+
+	return nil
+}
+
+func (k Nginx) apply(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
+	// upgrade --install also finishes the release interrupted by the previous installation
+	action := "upgrade --install"
+	if upgrade {
+		action = "upgrade"
+	}
+
+	currentdir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	// Setup NGINX Ingress helm values
+	var helmArgs []string
+
+	// https://github.com/kubernetes/ingress-nginx/blob/helm-chart-4.0.1/charts/ingress-nginx/values.yaml#L93
+	helmArgs = append(helmArgs, "--set controller.ingressClassResource.name="+nginxIngressClass)
+
+	helmCmd := fmt.Sprintf("helm %s %s --create-namespace --namespace %s %s %s", action, NginxDeploymentID, NginxDeploymentID, nginxChartURL, strings.Join(helmArgs, " "))
+	if out, err := helpers.RunProc(ctx, helmCmd, currentdir, k.Debug); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Failed installing NGINX Ingress: %s\n", out))
+	}
+	if err := labelHelmRelease(ctx, kubernetes.ComponentOwner(NginxDeploymentID), NginxDeploymentID, NginxDeploymentID); err != nil {
+		return err
+	}
+
+	err = c.LabelNamespace(ctx, NginxDeploymentID, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
+	if err != nil {
+		return err
+	}
+
+	if err := c.WaitUntilPodBySelectorExist(ctx, ui, NginxDeploymentID, nginxSelector, k.Timeout); err != nil {
+		return errors.Wrap(err, "failed waiting NGINX Ingress deployment to exist")
+	}
+	if err := c.WaitForPodBySelectorRunning(ctx, ui, NginxDeploymentID, nginxSelector, k.Timeout); err != nil {
+		return errors.Wrap(err, "failed waiting NGINX Ingress deployment to come up")
+	}
+
+	ui.Success().Msg("NGINX Ingress deployed")
+
+	return nil
+}
+
+func (k Nginx) GetVersion() string {
+	return nginxVersion
+}
+
+// Verify checks that the NGINX Ingress controller is running
+func (k Nginx) Verify(ctx context.Context, c *kubernetes.Cluster) error {
+	return c.PodsReady(ctx, NginxDeploymentID, nginxSelector)
+}
+
+func (k Nginx) Deploy(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+	ui.Note().KeeplineUnder(1).Msg("Deploying NGINX Ingress...")
+
+	return k.apply(ctx, c, ui, options, false)
+}
+
+func (k Nginx) Upgrade(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+	_, err := c.Kubectl.CoreV1().Namespaces().Get(
+		ctx,
+		NginxDeploymentID,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		return errors.New("Namespace " + NginxDeploymentID + " not present")
+	}
+
+	ui.Note().Msg("Upgrading NGINX Ingress...")
+
+	return k.apply(ctx, c, ui, options, true)
+}
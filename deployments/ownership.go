@@ -0,0 +1,154 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/pkg/errors"
+)
+
+// namespaces of the components, the component is installed while its namespace exists.
+// TLS has no namespace of its own, see ComponentInstalled.
+var componentNamespaces = map[string]string{
+	CoreDeploymentID:      coreDeploymentNamespace,
+	GiteaDeploymentID:     GiteaDeploymentID,
+	RegistryDeploymentID:  RegistryDeploymentID,
+	TektonDeploymentID:    TektonDeploymentID,
+	WorkloadsDeploymentID: WorkloadsDeploymentID,
+	istioDeploymentID:     istioDeploymentNamespace,
+	NginxDeploymentID:     NginxDeploymentID,
+	TraefikDeploymentID:   TraefikDeploymentID,
+}
+
+// ComponentInstalled checks if the component with the given deployment ID is installed. TLS is installed
+// while its mode (other than none) is saved in the installer settings.
+// The unknown components are reported as installed, so their resources are never removed.
+func ComponentInstalled(ctx context.Context, c *kubernetes.Cluster, id string) (bool, error) {
+	if id == TLSDeploymentID {
+		mode, err := loadSetting(ctx, c, tlsModeSetting)
+		if err != nil {
+			return false, err
+		}
+		return mode != "" && mode != TLSModeNone, nil
+	}
+	namespace, ok := componentNamespaces[id]
+	if !ok {
+		return true, nil
+	}
+	return c.NamespaceExists(ctx, namespace)
+}
+
+// labelOwned labels the resources selected by the kubectl arguments with their owner.
+// Resources without the label would be missed by cleanup, so a failure is an error.
+func labelOwned(ctx context.Context, owner kubernetes.Owner, args string) error {
+	out, err := helpers.Kubectl(ctx, fmt.Sprintf("label --overwrite %s %s", args, owner.Label()))
+	if err != nil {
+		return errors.Wrapf(err, "failed labeling the resources of %s: %s", owner, strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// labelManifest labels the resources of the manifest file with their owner
+func labelManifest(ctx context.Context, owner kubernetes.Owner, path, ns string) error {
+	args := "--filename " + path
+	if ns != "" {
+		args = args + " --namespace " + ns
+	}
+	return labelOwned(ctx, owner, args)
+}
+
+// labelEmbeddedYaml labels the resources of the embedded yaml file with their owner
+func labelEmbeddedYaml(ctx context.Context, owner kubernetes.Owner, yamlPath string) error {
+	yamlPathOnDisk, err := helpers.ExtractFile(yamlPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed labeling the resources of %s", owner)
+	}
+	defer os.Remove(yamlPathOnDisk)
+
+	return labelManifest(ctx, owner, yamlPathOnDisk, "")
+}
+
+// labelHelmRelease labels the resources of the helm release (as rendered by helm, without hooks) with their owner
+func labelHelmRelease(ctx context.Context, owner kubernetes.Owner, release, ns string) error {
+	namespace := ""
+	if ns != "" {
+		namespace = " --namespace " + ns
+	}
+	return labelOwned(ctx, owner, fmt.Sprintf("--filename <(helm get manifest %s%s)%s", release, namespace, namespace))
+}
+
+// ListOwnedResources finds the resources of all types labeled with their owner
+func ListOwnedResources(ctx context.Context) ([]kubernetes.OwnedResource, error) {
+	out, err := helpers.Kubectl(ctx, "api-resources --verbs=list,delete --output name")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the resource types: %s", out)
+	}
+	types := []string{}
+	for _, t := range strings.Fields(out) {
+		// events are not created by the installer, and there are too many of them
+		if t == "events" || strings.HasPrefix(t, "events.") {
+			continue
+		}
+		types = append(types, t)
+	}
+
+	resources := []kubernetes.OwnedResource{}
+	for _, key := range []string{kubernetes.FusemlComponentLabelKey, kubernetes.FusemlExtensionLabelKey} {
+		out, err := helpers.Kubectl(ctx, fmt.Sprintf("get %s --all-namespaces --selector %s --output json",
+			strings.Join(types, ","), key))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the resources labeled with %s: %s", key, out)
+		}
+		// skip the warnings (e.g. about deprecated APIs) printed before the list
+		if i := strings.Index(out, "{"); i > 0 {
+			out = out[i:]
+		}
+		found, err := kubernetes.ParseOwnedResources([]byte(out))
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, found...)
+	}
+	return resources, nil
+}
+
+// OrphanedResources returns the resources whose owner is no longer installed. The extensions
+// are installed when they are in the given set (e.g. the ones registered in FuseML core).
+func OrphanedResources(ctx context.Context, c *kubernetes.Cluster, resources []kubernetes.OwnedResource, extensions map[string]bool) ([]kubernetes.OwnedResource, error) {
+	components := map[string]bool{}
+	orphans := []kubernetes.OwnedResource{}
+	for _, resource := range resources {
+		owner := resource.Owner
+		installed := extensions[owner.Name]
+		if !owner.IsExtension() {
+			var checked bool
+			if installed, checked = components[owner.Name]; !checked {
+				var err error
+				if installed, err = ComponentInstalled(ctx, c, owner.Name); err != nil {
+					return nil, errors.Wrapf(err, "failed to check if %s is installed", owner)
+				}
+				components[owner.Name] = installed
+			}
+		}
+		if !installed {
+			orphans = append(orphans, resource)
+		}
+	}
+	return orphans, nil
+}
+
+// DeleteOwnedResource removes the owned resource
+func DeleteOwnedResource(ctx context.Context, resource kubernetes.OwnedResource) error {
+	args := fmt.Sprintf("delete %s %s --ignore-not-found --wait=false", resource.Resource, resource.Name)
+	if resource.Namespace != "" {
+		args = args + " --namespace " + resource.Namespace
+	}
+	if out, err := helpers.Kubectl(ctx, args); err != nil {
+		return errors.Wrapf(err, "failed to delete %s: %s", resource, out)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package deployments_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("OrphanedResources", func() {
+	var (
+		ctx       context.Context
+		cluster   *kubernetes.Cluster
+		resources []kubernetes.OwnedResource
+	)
+
+	tlsSecret := kubernetes.OwnedResource{
+		Resource:  "secrets",
+		Namespace: "istio-system",
+		Name:      "fuseml-tls",
+		Owner:     kubernetes.ComponentOwner(TLSDeploymentID),
+	}
+	giteaService := kubernetes.OwnedResource{
+		Resource:  "services",
+		Namespace: "gitea",
+		Name:      "gitea-http",
+		Owner:     kubernetes.ComponentOwner(GiteaDeploymentID),
+	}
+	mlflowRole := kubernetes.OwnedResource{
+		Resource:  "roles.rbac.authorization.k8s.io",
+		Namespace: "fuseml-workloads",
+		Name:      "fuseml-workloads-mlflow",
+		Owner:     kubernetes.ExtensionOwner("mlflow"),
+	}
+
+	settings := func(tls string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "fuseml-settings", Namespace: "fuseml-system"},
+			Data:       map[string]string{"tls": tls},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		resources = []kubernetes.OwnedResource{tlsSecret, giteaService, mlflowRole}
+	})
+
+	It("reports the TLS secret left after the uninstallation", func() {
+		cluster = &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "gitea"}},
+		)}
+		orphans, err := OrphanedResources(ctx, cluster, resources, map[string]bool{"mlflow": true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(orphans).To(ConsistOf(tlsSecret))
+	})
+
+	It("keeps the TLS secret while TLS is configured", func() {
+		cluster = &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "gitea"}},
+			settings(TLSModeSelfSigned),
+		)}
+		orphans, err := OrphanedResources(ctx, cluster, resources, map[string]bool{"mlflow": true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(orphans).To(BeEmpty())
+	})
+
+	It("reports the resources of the components and extensions no longer installed", func() {
+		cluster = &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset(settings(TLSModeNone))}
+		orphans, err := OrphanedResources(ctx, cluster, resources, map[string]bool{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(orphans).To(ConsistOf(tlsSecret, giteaService, mlflowRole))
+	})
+})
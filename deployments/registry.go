@@ -17,6 +17,8 @@ import (
 type Registry struct {
 	Debug   bool
 	Timeout int
+	// KeepData keeps the persistent volumes on Delete, for the next installation
+	KeepData bool
 }
 
 const (
@@ -29,11 +31,11 @@ func (k *Registry) ID() string {
 	return RegistryDeploymentID
 }
 
-func (k *Registry) Backup(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (k *Registry) Backup(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
-func (k *Registry) Restore(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (k *Registry) Restore(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
@@ -42,10 +44,10 @@ func (k Registry) Describe() string {
 }
 
 // Delete removes Registry from kubernetes cluster
-func (k Registry) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
+func (k Registry) Delete(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
 	ui.Note().KeeplineUnder(1).Msg("Removing Registry...")
 
-	existsAndOwned, err := c.NamespaceExistsAndOwned(RegistryDeploymentID)
+	existsAndOwned, err := c.NamespaceExistsAndOwned(ctx, RegistryDeploymentID)
 	if err != nil {
 		return errors.Wrapf(err, "failed to check if namespace '%s' is owned or not", RegistryDeploymentID)
 	}
@@ -54,6 +56,12 @@ func (k Registry) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 		return nil
 	}
 
+	if k.KeepData {
+		if err := retainData(ctx, c, ui, "Registry", RegistryDeploymentID); err != nil {
+			return err
+		}
+	}
+
 	currentdir, err := os.Getwd()
 	if err != nil {
 		return errors.New("Failed uninstalling Registry: " + err.Error())
@@ -63,7 +71,7 @@ func (k Registry) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	out, err := helpers.WaitForCommandCompletion(ui, message,
 		func() (string, error) {
 			helmCmd := fmt.Sprintf("helm uninstall '%s' --namespace '%s'", RegistryDeploymentID, RegistryDeploymentID)
-			return helpers.RunProc(helmCmd, currentdir, k.Debug)
+			return helpers.RunProc(ctx, helmCmd, currentdir, k.Debug)
 		},
 	)
 	if err != nil {
@@ -77,7 +85,7 @@ func (k Registry) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	message = "Deleting Registry namespace " + RegistryDeploymentID
 	_, err = helpers.WaitForCommandCompletion(ui, message,
 		func() (string, error) {
-			return "", c.DeleteNamespace(RegistryDeploymentID)
+			return "", c.DeleteNamespace(ctx, RegistryDeploymentID)
 		},
 	)
 	if err != nil {
@@ -89,8 +97,9 @@ func (k Registry) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	return nil
 }
 
-func (k Registry) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
-	action := "install"
+func (k Registry) apply(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
+	// upgrade --install also finishes the release interrupted by the previous installation
+	action := "upgrade --install"
 	if upgrade {
 		action = "upgrade"
 	}
@@ -100,15 +109,6 @@ func (k Registry) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Ins
 		return err
 	}
 
-	if action == "install" {
-		helmCmd := fmt.Sprintf("helm list --namespace %s --deployed -q | grep %s", RegistryDeploymentID, RegistryDeploymentID)
-		out, _ := helpers.RunProc(helmCmd, currentdir, k.Debug)
-		if strings.TrimSpace(out) == RegistryDeploymentID {
-			ui.Exclamation().Msg(RegistryDeploymentID + " already present under " + RegistryDeploymentID + " namespace, skipping installation")
-			return nil
-		}
-	}
-
 	tarPath, err := helpers.ExtractFile(registryChartFile)
 	if err != nil {
 		return errors.New("Failed to extract embedded file: " + tarPath + " - " + err.Error())
@@ -128,19 +128,26 @@ fullnameOverride: registry
 	}
 	defer os.Remove(configPath)
 
+	if err := reattachData(ctx, c, ui, "Registry", RegistryDeploymentID); err != nil {
+		return err
+	}
+
 	helmCmd := fmt.Sprintf("helm %s %s --values %s --create-namespace --namespace %s %s", action, RegistryDeploymentID, configPath, RegistryDeploymentID, tarPath)
-	if out, err := helpers.RunProc(helmCmd, currentdir, k.Debug); err != nil {
+	if out, err := helpers.RunProc(ctx, helmCmd, currentdir, k.Debug); err != nil {
 		return errors.New("Failed installing Registry: " + out)
 	}
+	if err := labelHelmRelease(ctx, kubernetes.ComponentOwner(RegistryDeploymentID), RegistryDeploymentID, RegistryDeploymentID); err != nil {
+		return err
+	}
 
-	err = c.LabelNamespace(RegistryDeploymentID, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
+	err = c.LabelNamespace(ctx, RegistryDeploymentID, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
 	if err != nil {
 		return err
 	}
-	if err := c.WaitUntilPodBySelectorExist(ui, RegistryDeploymentID, "app.kubernetes.io/name=trow", 180); err != nil {
+	if err := c.WaitUntilPodBySelectorExist(ctx, ui, RegistryDeploymentID, "app.kubernetes.io/name=trow", 180); err != nil {
 		return errors.Wrap(err, "failed waiting Registry deployment to come up")
 	}
-	if err := c.WaitForPodBySelectorRunning(ui, RegistryDeploymentID, "app.kubernetes.io/name=trow", 180); err != nil {
+	if err := c.WaitForPodBySelectorRunning(ctx, ui, RegistryDeploymentID, "app.kubernetes.io/name=trow", 180); err != nil {
 		return errors.Wrap(err, "failed waiting Registry deployment to come up")
 	}
 
@@ -153,20 +160,16 @@ func (k Registry) GetVersion() string {
 	return registryVersion
 }
 
-func (k Registry) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+// Verify checks that the registry is running
+func (k Registry) Verify(ctx context.Context, c *kubernetes.Cluster) error {
+	return c.PodsReady(ctx, RegistryDeploymentID, "app.kubernetes.io/name=trow")
+}
 
-	_, err := c.Kubectl.CoreV1().Namespaces().Get(
-		context.Background(),
-		RegistryDeploymentID,
-		metav1.GetOptions{},
-	)
-	if err == nil {
-		ui.Note().Msg("Namespace " + RegistryDeploymentID + " already present")
-	}
+func (k Registry) Deploy(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 
 	ui.Note().KeeplineUnder(1).Msg("Deploying Registry...")
 
-	err = k.apply(c, ui, options, false)
+	err := k.apply(ctx, c, ui, options, false)
 	if err != nil {
 		return err
 	}
@@ -174,9 +177,9 @@ func (k Registry) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.In
 	return nil
 }
 
-func (k Registry) Upgrade(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+func (k Registry) Upgrade(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 	_, err := c.Kubectl.CoreV1().Namespaces().Get(
-		context.Background(),
+		ctx,
 		RegistryDeploymentID,
 		metav1.GetOptions{},
 	)
@@ -186,5 +189,5 @@ func (k Registry) Upgrade(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.I
 
 	ui.Note().Msg("Upgrading Registry...")
 
-	return k.apply(c, ui, options, true)
+	return k.apply(ctx, c, ui, options, true)
 }
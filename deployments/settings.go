@@ -0,0 +1,108 @@
+package deployments
+
+import (
+	"context"
+
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The installer keeps the settings chosen at installation time (which have to be
+// known later by upgrade, extensions or uninstall commands) in a ConfigMap
+// in its own namespace.
+const (
+	systemNamespace       = "fuseml-system"
+	settingsConfigMapName = "fuseml-settings"
+
+	systemDomainSetting = "system_domain"
+)
+
+// Create the namespace used for keeping installer data, if it does not exist yet
+func createSystemNamespace(ctx context.Context, c *kubernetes.Cluster) error {
+	return c.CreateOwnedNamespace(ctx, systemNamespace)
+}
+
+// saveSettings merges given values into the installer settings
+func saveSettings(ctx context.Context, c *kubernetes.Cluster, values map[string]string) error {
+	if err := createSystemNamespace(ctx, c); err != nil {
+		return errors.Wrapf(err, "failed creating namespace %s", systemNamespace)
+	}
+
+	configMaps := c.Kubectl.CoreV1().ConfigMaps(systemNamespace)
+	cm, err := configMaps.Get(ctx, settingsConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx,
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: settingsConfigMapName,
+				},
+				Data: values,
+			}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	for key, value := range values {
+		cm.Data[key] = value
+	}
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+// loadSetting returns the value of the installer setting; empty string is returned
+// when the setting is not present
+func loadSetting(ctx context.Context, c *kubernetes.Cluster, key string) (string, error) {
+	cm, err := c.Kubectl.CoreV1().ConfigMaps(systemNamespace).Get(ctx, settingsConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed reading installer settings")
+	}
+	return cm.Data[key], nil
+}
+
+// SaveSystemDomain remembers the system domain FuseML is installed under
+func SaveSystemDomain(ctx context.Context, c *kubernetes.Cluster, domain string) error {
+	if err := saveSettings(ctx, c, map[string]string{systemDomainSetting: domain}); err != nil {
+		return errors.Wrap(err, "Failed saving system domain")
+	}
+	return nil
+}
+
+// SystemDomain returns the system domain saved at installation time, empty when it is not known
+func SystemDomain(ctx context.Context, c *kubernetes.Cluster) (string, error) {
+	return loadSetting(ctx, c, systemDomainSetting)
+}
+
+// DeleteSettings removes the namespace holding installer settings
+func DeleteSettings(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
+	existsAndOwned, err := c.NamespaceExistsAndOwned(ctx, systemNamespace)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if namespace '%s' is owned or not", systemNamespace)
+	}
+	if !existsAndOwned {
+		return nil
+	}
+
+	message := "Deleting installer namespace " + systemNamespace
+	_, err = helpers.WaitForCommandCompletion(ui, message,
+		func() (string, error) {
+			return "", c.DeleteNamespace(ctx, systemNamespace)
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "Failed deleting namespace %s", systemNamespace)
+	}
+	return nil
+}
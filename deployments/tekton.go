@@ -12,9 +12,7 @@ import (
 	"github.com/fuseml/fuseml/cli/paas/ui"
 	"github.com/kyokomi/emoji"
 	"github.com/pkg/errors"
-	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type Tekton struct {
@@ -31,6 +29,8 @@ const (
 	tektonOperatorProfileYamlPath = "tekton/install/profile-all.yaml"
 	tektonTriggersSAYamlPath      = "tekton/install/sa.yaml"
 	tektonFuseMLTasksYamlPath     = "tekton/tasks"
+	// selects the resources created by the operator, quoted for the shell
+	tektonOperandsSelector = "'app.kubernetes.io/part-of in (tekton-pipelines,tekton-triggers,tekton-dashboard)'"
 )
 
 var fuseMLTasks = []string{"clone", "kaniko", "builder-prep"}
@@ -39,11 +39,11 @@ func (k *Tekton) ID() string {
 	return TektonDeploymentID
 }
 
-func (k *Tekton) Backup(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (k *Tekton) Backup(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
-func (k *Tekton) Restore(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (k *Tekton) Restore(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
@@ -53,14 +53,14 @@ func (k Tekton) Describe() string {
 }
 
 // Delete removes Tekton from kubernetes cluster
-func (k Tekton) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
+func (k Tekton) Delete(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
 	ui.Note().KeeplineUnder(1).Msg("Removing Tekton...")
 
 	wasDeleted := false
 	namespaces := []string{TektonDeploymentID, tektonOperatorNamespace}
 
 	for _, ns := range namespaces {
-		existsAndOwned, err := c.NamespaceExistsAndOwned(ns)
+		existsAndOwned, err := c.NamespaceExistsAndOwned(ctx, ns)
 		if err != nil {
 			return errors.Wrapf(err, "failed to check if namespace '%s' is owned or not", ns)
 		}
@@ -73,7 +73,7 @@ func (k Tekton) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 			} else {
 				yamlFile = tektonOperatorYamlPath
 			}
-			if out, err := helpers.KubectlDeleteEmbeddedYaml(yamlFile, true); err != nil {
+			if out, err := helpers.KubectlDeleteEmbeddedYaml(ctx, yamlFile, true); err != nil {
 				return errors.Wrap(err, fmt.Sprintf("Deleting %s failed:\n%s", yamlFile, out))
 			}
 			// wait for sa, roles and tekton-[pipelines|triggers|dashboard] to be deleted before deleting operator
@@ -81,7 +81,7 @@ func (k Tekton) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 				message := "Deleting Tekton triggers Service Account and Roles"
 				out, err := helpers.WaitForCommandCompletion(ui, message,
 					func() (string, error) {
-						return helpers.KubectlDeleteEmbeddedYaml(tektonTriggersSAYamlPath, true)
+						return helpers.KubectlDeleteEmbeddedYaml(ctx, tektonTriggersSAYamlPath, true)
 					},
 				)
 				if err != nil {
@@ -91,7 +91,7 @@ func (k Tekton) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 				message = "Waiting for tekton to be deleted"
 				out, err = helpers.WaitForCommandCompletion(ui, message,
 					func() (string, error) {
-						return helpers.Kubectl(fmt.Sprintf("wait --for=delete --timeout=%ds -n %s tektonconfig/config",
+						return helpers.Kubectl(ctx, fmt.Sprintf("wait --for=delete --timeout=%ds -n %s tektonconfig/config",
 							k.Timeout, TektonDeploymentID))
 					},
 				)
@@ -103,7 +103,7 @@ func (k Tekton) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 			message := fmt.Sprintf("Deleting %s namespace", ns)
 			_, err = helpers.WaitForCommandCompletion(ui, message,
 				func() (string, error) {
-					return "", c.DeleteNamespace(ns)
+					return "", c.DeleteNamespace(ctx, ns)
 				},
 			)
 			if err != nil {
@@ -120,13 +120,14 @@ func (k Tekton) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	return nil
 }
 
-func (k Tekton) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
+func (k Tekton) apply(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
+	owner := kubernetes.ComponentOwner(TektonDeploymentID)
 
 	installOperator := true
 	if !upgrade {
 		// Install tekton operator only if not already installed
 		_, err := c.Kubectl.CoreV1().Namespaces().Get(
-			context.Background(),
+			ctx,
 			tektonOperatorNamespace,
 			metav1.GetOptions{},
 		)
@@ -137,11 +138,14 @@ func (k Tekton) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Insta
 	}
 
 	if installOperator || upgrade {
-		if out, err := helpers.KubectlApplyEmbeddedYaml(tektonOperatorYamlPath); err != nil {
+		if out, err := helpers.KubectlApplyEmbeddedYaml(ctx, tektonOperatorYamlPath); err != nil {
 			return errors.Wrap(err, fmt.Sprintf("installing %s failed:\n%s", tektonOperatorYamlPath, out))
 		}
+		if err := labelEmbeddedYaml(ctx, owner, tektonOperatorYamlPath); err != nil {
+			return err
+		}
 
-		err := c.LabelNamespace(tektonOperatorNamespace, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
+		err := c.LabelNamespace(ctx, tektonOperatorNamespace, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
 		if err != nil {
 			return err
 		}
@@ -157,7 +161,7 @@ func (k Tekton) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Insta
 			message := fmt.Sprintf("Establish CRD %s", crd)
 			out, err := helpers.WaitForCommandCompletion(ui, message,
 				func() (string, error) {
-					return helpers.Kubectl("wait --for=condition=established --timeout=" + strconv.Itoa(k.Timeout) + "s crd/" + crd)
+					return helpers.Kubectl(ctx, "wait --for=condition=established --timeout="+strconv.Itoa(k.Timeout)+"s crd/"+crd)
 				},
 			)
 			if err != nil {
@@ -168,7 +172,7 @@ func (k Tekton) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Insta
 		message := "Waiting for tekton-operator pod to be ready"
 		out, err := helpers.WaitForCommandCompletion(ui, message,
 			func() (string, error) {
-				return helpers.Kubectl(fmt.Sprintf("wait --for=condition=Ready --timeout=%ds -n %s --selector=app=tekton-operator pod",
+				return helpers.Kubectl(ctx, fmt.Sprintf("wait --for=condition=Ready --timeout=%ds -n %s --selector=app=tekton-operator pod",
 					k.Timeout, tektonOperatorNamespace))
 			},
 		)
@@ -190,76 +194,87 @@ func (k Tekton) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Insta
 				time.Sleep(sleep)
 				sleep *= 2
 			}
-			if out, err := helpers.KubectlApplyEmbeddedYaml(tektonOperatorProfileYamlPath); i == (attempts-1) && err != nil {
+			if out, err := helpers.KubectlApplyEmbeddedYaml(ctx, tektonOperatorProfileYamlPath); i == (attempts-1) && err != nil {
 				return errors.Wrap(err, fmt.Sprintf("installing %s failed:\n%s", tektonOperatorProfileYamlPath, out))
 			}
 		}
+		if err := labelEmbeddedYaml(ctx, owner, tektonOperatorProfileYamlPath); err != nil {
+			return err
+		}
 
-		out, err := helpers.WaitForKubernetesResourceToExist(ui, TektonDeploymentID, "namespace", TektonDeploymentID, k.Timeout)
+		out, err := helpers.WaitForKubernetesResourceToExist(ctx, ui, TektonDeploymentID, "namespace", TektonDeploymentID, k.Timeout)
 		if err != nil {
 			return fmt.Errorf("error waiting for namespace %s: %s", TektonDeploymentID, out)
 		}
 
-		err = c.LabelNamespace(TektonDeploymentID, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
+		err = c.LabelNamespace(ctx, TektonDeploymentID, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
 		if err != nil {
 			return err
 		}
 
-		var message string
-		if c.HasIstio() {
-			message = "Creating Tekton dashboard istio ingress gateway"
-			_, err = helpers.WaitForCommandCompletion(ui, message,
-				func() (string, error) {
-					return helpers.CreateIstioIngressGateway("tekton", TektonDeploymentID, "tekton."+domain, "tekton-dashboard", 9097)
-				},
-			)
-		} else {
-			message = "Creating Tekton dashboard ingress"
-			_, err = helpers.WaitForCommandCompletion(ui, message,
-				func() (string, error) {
-					return "", createTektonIngress(c, "tekton."+domain)
-				},
-			)
+		provider, err := IngressProviderFor(ctx, c)
+		if err != nil {
+			return err
 		}
+		err = provider.Expose(ctx, c, ui, ExposedService{
+			Name:        "tekton",
+			Namespace:   TektonDeploymentID,
+			Host:        "tekton." + domain,
+			Domain:      domain,
+			ServiceName: "tekton-dashboard",
+			ServicePort: 9097,
+			Owner:       owner,
+		}, k.Timeout)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("%s failed", message))
+			return errors.Wrap(err, "Failed exposing Tekton dashboard")
 		}
 	}
 
 	message := "Waiting for tekton to be ready"
 	out, err := helpers.WaitForCommandCompletion(ui, message,
 		func() (string, error) {
-			return helpers.Kubectl(fmt.Sprintf("wait --for=condition=Ready --timeout=%ds -n %s tektonconfig/config",
+			return helpers.Kubectl(ctx, fmt.Sprintf("wait --for=condition=Ready --timeout=%ds -n %s tektonconfig/config",
 				k.Timeout, TektonDeploymentID))
 		},
 	)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("%s failed:\n%s", message, out))
 	}
+	// the CRDs and cluster roles of the pipelines, triggers and dashboard are created by the operator
+	err = labelOwned(ctx, owner, "crd,clusterrole,clusterrolebinding --selector "+tektonOperandsSelector)
+	if err != nil {
+		return err
+	}
 
 	message = "Installing Tekton triggers Service Account"
 	out, err = helpers.WaitForCommandCompletion(ui, message,
 		func() (string, error) {
-			return helpers.KubectlApplyEmbeddedYaml(tektonTriggersSAYamlPath)
+			return helpers.KubectlApplyEmbeddedYaml(ctx, tektonTriggersSAYamlPath)
 		},
 	)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("%s failed:\n%s", message, out))
 	}
+	if err := labelEmbeddedYaml(ctx, owner, tektonTriggersSAYamlPath); err != nil {
+		return err
+	}
 
 	for _, task := range fuseMLTasks {
 		message := fmt.Sprintf("Installing FuseML task: %s", task)
 		out, err := helpers.WaitForCommandCompletion(ui, message,
 			func() (string, error) {
-				return helpers.KubectlApplyEmbeddedYaml(fmt.Sprintf("%s/%s.yaml", tektonFuseMLTasksYamlPath, task))
+				return helpers.KubectlApplyEmbeddedYaml(ctx, fmt.Sprintf("%s/%s.yaml", tektonFuseMLTasksYamlPath, task))
 			},
 		)
 		if err != nil {
 			return errors.Wrap(err, fmt.Sprintf("%s failed:\n%s", message, out))
 		}
+		if err := labelEmbeddedYaml(ctx, owner, fmt.Sprintf("%s/%s.yaml", tektonFuseMLTasksYamlPath, task)); err != nil {
+			return err
+		}
 	}
 
-	ui.Success().Msg(fmt.Sprintf("Tekton deployed (http://tekton.%s).", domain))
+	ui.Success().Msg(fmt.Sprintf("Tekton deployed (%s://tekton.%s).", URLScheme(ctx, c), domain))
 
 	return nil
 }
@@ -267,7 +282,7 @@ func (k Tekton) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Insta
 func (k Tekton) GetVersion() string {
 	versions := map[string]string{}
 	for _, c := range []string{"pipeline", "trigger", "dashboard"} {
-		version, err := helpers.Kubectl(fmt.Sprintf("get tekton%ss %s -o jsonpath='{.status.version}')", c, c))
+		version, err := helpers.Kubectl(context.Background(), fmt.Sprintf("get tekton%ss %s -o jsonpath='{.status.version}')", c, c))
 		if err != nil {
 			versions[c] = "Unknown"
 		} else {
@@ -279,21 +294,25 @@ func (k Tekton) GetVersion() string {
 		versions["pipeline"], versions["trigger"], versions["dashboard"])
 }
 
-func (k Tekton) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
-
-	_, err := c.Kubectl.CoreV1().Namespaces().Get(
-		context.Background(),
-		TektonDeploymentID,
-		metav1.GetOptions{},
-	)
-	if err == nil {
-		ui.Exclamation().Msg("Namespace " + TektonDeploymentID + " already present, skipping installation")
-		return nil
+// Verify checks that Tekton is ready and the FuseML tasks are present
+func (k Tekton) Verify(ctx context.Context, c *kubernetes.Cluster) error {
+	// zero timeout checks the condition only once
+	out, err := helpers.Kubectl(ctx, fmt.Sprintf("wait --for=condition=Ready --timeout=0s -n %s tektonconfig/config", TektonDeploymentID))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("tekton is not ready:\n%s", out))
+	}
+	out, err = helpers.Kubectl(ctx, fmt.Sprintf("get tasks.tekton.dev -n %s %s", WorkloadsDeploymentID, strings.Join(fuseMLTasks, " ")))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("FuseML tasks are missing:\n%s", out))
 	}
+	return nil
+}
+
+func (k Tekton) Deploy(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 
 	ui.Note().KeeplineUnder(1).Msg("Deploying Tekton...")
 
-	err = k.apply(c, ui, options, false)
+	err := k.apply(ctx, c, ui, options, false)
 	if err != nil {
 		return err
 	}
@@ -301,9 +320,9 @@ func (k Tekton) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Inst
 	return nil
 }
 
-func (k Tekton) Upgrade(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+func (k Tekton) Upgrade(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 	_, err := c.Kubectl.CoreV1().Namespaces().Get(
-		context.Background(),
+		ctx,
 		TektonDeploymentID,
 		metav1.GetOptions{},
 	)
@@ -313,41 +332,5 @@ func (k Tekton) Upgrade(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Ins
 
 	ui.Note().Msg("Upgrading Tekton...")
 
-	return k.apply(c, ui, options, true)
-}
-
-func createTektonIngress(c *kubernetes.Cluster, subdomain string) error {
-	_, err := c.Kubectl.ExtensionsV1beta1().Ingresses(TektonDeploymentID).Create(
-		context.Background(),
-		// TODO: Switch to networking v1 when we don't care about <1.18 clusters
-		// Like this (which has been reverted):
-		// https://github.com/SUSE/carrier/commit/7721d610fdf27a79be980af522783671d3ffc198
-		&v1beta1.Ingress{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "tekton-dashboard",
-				Namespace: TektonDeploymentID,
-				Annotations: map[string]string{
-					"kubernetes.io/ingress.class": "traefik",
-				},
-			},
-			Spec: v1beta1.IngressSpec{
-				Rules: []v1beta1.IngressRule{
-					{
-						Host: subdomain,
-						IngressRuleValue: v1beta1.IngressRuleValue{
-							HTTP: &v1beta1.HTTPIngressRuleValue{
-								Paths: []v1beta1.HTTPIngressPath{
-									{
-										Path: "/",
-										Backend: v1beta1.IngressBackend{
-											ServiceName: "tekton-dashboard",
-											ServicePort: intstr.IntOrString{
-												Type:   intstr.Int,
-												IntVal: 9097,
-											},
-										}}}}}}}}},
-		metav1.CreateOptions{},
-	)
-
-	return err
+	return k.apply(ctx, c, ui, options, true)
 }
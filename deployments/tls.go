@@ -0,0 +1,427 @@
+package deployments
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/template"
+
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+	"github.com/kyokomi/emoji"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TLS prepares the certificate used by all FuseML endpoints
+type TLS struct {
+	Debug   bool
+	Timeout int
+}
+
+const (
+	TLSDeploymentID = "fuseml-tls"
+	tlsSecretName   = "fuseml-tls"
+	tlsVersion      = "0.1"
+
+	// TLSModeNone serves all endpoints over plain http
+	TLSModeNone = "none"
+	// TLSModeSelfSigned generates a self-signed CA and a wildcard certificate for the system domain
+	TLSModeSelfSigned = "self-signed"
+	// TLSModeCustom uses the certificate provided by the user
+	TLSModeCustom = "custom"
+	// TLSModeCertManager lets cert-manager issue the certificates
+	TLSModeCertManager = "cert-manager"
+
+	tlsModeSetting   = "tls"
+	tlsIssuerSetting = "tls_issuer"
+	caCertKey        = "ca.crt"
+)
+
+func (k *TLS) ID() string {
+	return TLSDeploymentID
+}
+
+func (k *TLS) Backup(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
+	return nil
+}
+
+func (k *TLS) Restore(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
+	return nil
+}
+
+func (k TLS) Describe() string {
+	return emoji.Sprintf(":lock:TLS certificates version: %s", tlsVersion)
+}
+
+func (k TLS) GetVersion() string {
+	return tlsVersion
+}
+
+// Verify checks that the certificate of the chosen TLS mode (or cert-manager) is present
+func (k TLS) Verify(ctx context.Context, c *kubernetes.Cluster) error {
+	mode, err := loadSetting(ctx, c, tlsModeSetting)
+	if err != nil {
+		return err
+	}
+
+	switch mode {
+	case "", TLSModeNone:
+		return nil
+	case TLSModeCertManager:
+		if out, err := helpers.Kubectl(ctx, "get crd certificates.cert-manager.io"); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("cert-manager does not seem to be installed:\n%s", out))
+		}
+		return nil
+	}
+	if _, err := c.Kubectl.CoreV1().Secrets(systemNamespace).Get(ctx, tlsSecretName, metav1.GetOptions{}); err != nil {
+		return errors.Wrap(err, "failed reading TLS certificate")
+	}
+	return nil
+}
+
+// Deploy prepares the certificate (or the cert-manager configuration) according to the chosen TLS mode
+func (k TLS) Deploy(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+	mode, err := options.GetString("tls", TLSDeploymentID)
+	if err != nil {
+		return err
+	}
+	if mode == TLSModeNone || mode == "" {
+		return nil
+	}
+
+	domain, err := options.GetString("system_domain", TLSDeploymentID)
+	if err != nil {
+		return err
+	}
+
+	ui.Note().KeeplineUnder(1).Msg("Configuring TLS...")
+
+	settings := map[string]string{tlsModeSetting: mode}
+
+	switch mode {
+	case TLSModeSelfSigned:
+		if err := k.createSelfSignedSecret(ctx, c, ui, domain); err != nil {
+			return err
+		}
+	case TLSModeCustom:
+		certFile, err := options.GetString("tls_cert_file", TLSDeploymentID)
+		if err != nil {
+			return err
+		}
+		keyFile, err := options.GetString("tls_key_file", TLSDeploymentID)
+		if err != nil {
+			return err
+		}
+		if err := k.createCustomSecret(ctx, c, certFile, keyFile); err != nil {
+			return err
+		}
+	case TLSModeCertManager:
+		issuer, err := options.GetString("tls_issuer", TLSDeploymentID)
+		if err != nil {
+			return err
+		}
+		if issuer == "" {
+			return errors.New("tls_issuer has to be provided when cert-manager is used for TLS")
+		}
+		if out, err := helpers.Kubectl(ctx, "get crd certificates.cert-manager.io"); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("cert-manager does not seem to be installed:\n%s", out))
+		}
+		settings[tlsIssuerSetting] = issuer
+	default:
+		return errors.New(fmt.Sprintf("Unsupported TLS mode %s (use %s, %s, %s or %s)",
+			mode, TLSModeNone, TLSModeSelfSigned, TLSModeCustom, TLSModeCertManager))
+	}
+
+	if err := saveSettings(ctx, c, settings); err != nil {
+		return errors.Wrap(err, "Failed saving TLS settings")
+	}
+
+	ui.Success().Msg(fmt.Sprintf("TLS configured (%s)", mode))
+	if mode == TLSModeSelfSigned {
+		ui.Normal().Msg(fmt.Sprintf("    To trust FuseML endpoints, add the generated CA to your trust store. It can be fetched with:\n\n"+
+			"    kubectl get secret -n %s %s -o jsonpath='{.data.ca\\.crt}' | base64 -d > fuseml-ca.crt",
+			systemNamespace, tlsSecretName))
+	}
+
+	return nil
+}
+
+func (k TLS) Upgrade(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+	// NOTE: Not implemented yet
+	return nil
+}
+
+// Delete removes TLS secrets created outside of FuseML owned namespaces
+func (k TLS) Delete(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
+	mode, err := loadSetting(ctx, c, tlsModeSetting)
+	if err != nil {
+		return err
+	}
+	if mode == "" || mode == TLSModeNone {
+		return nil
+	}
+
+	// other certificates are removed together with FuseML namespaces
+	mesh, err := MeshFor(ctx, c)
+	if err != nil {
+		return err
+	}
+	if !mesh.Enabled() {
+		return nil
+	}
+
+	ui.Note().KeeplineUnder(1).Msg("Removing TLS certificates...")
+
+	if mode == TLSModeCertManager {
+		out, err := helpers.Kubectl(ctx, fmt.Sprintf("delete certificate %s --namespace %s --ignore-not-found", tlsSecretName, mesh.GatewayNamespace))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Failed deleting certificate %s:\n%s", tlsSecretName, out))
+		}
+	}
+	err = c.Kubectl.CoreV1().Secrets(mesh.GatewayNamespace).Delete(ctx, tlsSecretName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "Failed deleting secret %s", tlsSecretName)
+	}
+
+	ui.Success().Msg("TLS certificates removed")
+
+	return nil
+}
+
+// Generate the self-signed certificate, unless there is one valid for the domain from previous installation.
+// When the domain changed, the certificate is regenerated and its copies in other namespaces are refreshed.
+func (k TLS) createSelfSignedSecret(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, domain string) error {
+	existing, err := c.Kubectl.CoreV1().Secrets(systemNamespace).Get(ctx, tlsSecretName, metav1.GetOptions{})
+	replaced := err == nil
+	if replaced {
+		if helpers.CertificateCoversDomain(existing.Data[corev1.TLSCertKey], domain) {
+			ui.Exclamation().Msg("Self-signed certificate already present, reusing it")
+			return nil
+		}
+		ui.Exclamation().Msg(fmt.Sprintf("Self-signed certificate is not valid for %s, regenerating it", domain))
+	} else if !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed reading TLS certificate")
+	}
+
+	cert, err := helpers.GenerateSelfSignedCertificate(domain)
+	if err != nil {
+		return errors.Wrap(err, "Failed generating self-signed certificate")
+	}
+	if err := createSystemNamespace(ctx, c); err != nil {
+		return errors.Wrapf(err, "failed creating namespace %s", systemNamespace)
+	}
+	if err := storeTLSSecret(ctx, c, systemNamespace, cert.Cert, cert.Key, cert.CA); err != nil {
+		return err
+	}
+	if replaced {
+		return refreshTLSSecretCopies(ctx, c)
+	}
+	return nil
+}
+
+// refreshTLSSecretCopies updates the secrets copied by tlsSecretFor to other namespaces
+// with the current content of the TLS secret in the system namespace
+func refreshTLSSecretCopies(ctx context.Context, c *kubernetes.Cluster) error {
+	source, err := c.Kubectl.CoreV1().Secrets(systemNamespace).Get(ctx, tlsSecretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed reading TLS certificate")
+	}
+	copies, err := c.Kubectl.CoreV1().Secrets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: kubernetes.ComponentOwner(TLSDeploymentID).Label(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed listing TLS secrets")
+	}
+	for _, secret := range copies.Items {
+		if secret.Name != tlsSecretName || secret.Namespace == systemNamespace {
+			continue
+		}
+		err = storeTLSSecret(ctx, c, secret.Namespace,
+			source.Data[corev1.TLSCertKey], source.Data[corev1.TLSPrivateKeyKey], source.Data[caCertKey])
+		if err != nil {
+			return errors.Wrapf(err, "failed updating TLS secret in namespace %s", secret.Namespace)
+		}
+	}
+	return nil
+}
+
+// Store the certificate provided by the user
+func (k TLS) createCustomSecret(ctx context.Context, c *kubernetes.Cluster, certFile, keyFile string) error {
+	if certFile == "" || keyFile == "" {
+		return errors.New("both tls_cert_file and tls_key_file have to be provided for custom TLS certificate")
+	}
+	cert, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return errors.Wrap(err, "failed reading TLS certificate")
+	}
+	key, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed reading TLS key")
+	}
+	if _, err := tls.X509KeyPair(cert, key); err != nil {
+		return errors.Wrap(err, "invalid TLS certificate or key")
+	}
+	if err := createSystemNamespace(ctx, c); err != nil {
+		return errors.Wrapf(err, "failed creating namespace %s", systemNamespace)
+	}
+	return storeTLSSecret(ctx, c, systemNamespace, cert, key, nil)
+}
+
+// Create or update TLS secret in the given namespace
+func storeTLSSecret(ctx context.Context, c *kubernetes.Cluster, namespace string, cert, key, ca []byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   tlsSecretName,
+			Labels: kubernetes.ComponentOwner(TLSDeploymentID).Labels(),
+		},
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       cert,
+			corev1.TLSPrivateKeyKey: key,
+		},
+		Type: corev1.SecretTypeTLS,
+	}
+	if ca != nil {
+		secret.Data[caCertKey] = ca
+	}
+
+	secrets := c.Kubectl.CoreV1().Secrets(namespace)
+	_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// tlsSecretFor makes sure there is a TLS secret valid for the system domain in the given
+// namespace and returns its name. Empty name is returned when TLS is not enabled.
+// The secret is shared by the services exposed in the namespace, so it is owned by the TLS component.
+func tlsSecretFor(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, namespace, domain string, timeout int) (string, error) {
+	mode, err := loadSetting(ctx, c, tlsModeSetting)
+	if err != nil {
+		return "", err
+	}
+
+	switch mode {
+	case "", TLSModeNone:
+		return "", nil
+	case TLSModeSelfSigned, TLSModeCustom:
+		if namespace == systemNamespace {
+			return tlsSecretName, nil
+		}
+		source, err := c.Kubectl.CoreV1().Secrets(systemNamespace).Get(ctx, tlsSecretName, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrap(err, "failed reading TLS certificate")
+		}
+		err = storeTLSSecret(ctx, c, namespace,
+			source.Data[corev1.TLSCertKey], source.Data[corev1.TLSPrivateKeyKey], source.Data[caCertKey])
+		if err != nil {
+			return "", errors.Wrapf(err, "failed creating TLS secret in namespace %s", namespace)
+		}
+	case TLSModeCertManager:
+		issuer, err := loadSetting(ctx, c, tlsIssuerSetting)
+		if err != nil {
+			return "", err
+		}
+		if err := createCertificate(ctx, c, ui, namespace, domain, issuer, timeout); err != nil {
+			return "", err
+		}
+	default:
+		return "", errors.New("Unsupported TLS mode " + mode)
+	}
+	return tlsSecretName, nil
+}
+
+// Create cert-manager Certificate for the system domain and wait until it is issued
+func createCertificate(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, namespace, domain, issuer string, timeout int) error {
+	certTmpl, err := template.New("certificate").Parse(`
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+  labels:
+    "{{ .Owner.LabelKey }}": "{{ .Owner.Name }}"
+spec:
+  secretName: {{ .Name }}
+  dnsNames:
+  - "*.{{ .Domain }}"
+  - "{{ .Domain }}"
+  issuerRef:
+    name: {{ .Issuer }}
+    kind: ClusterIssuer
+`)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile("", "fuseml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	err = certTmpl.Execute(tmpFile, struct {
+		Name      string
+		Namespace string
+		Domain    string
+		Issuer    string
+		Owner     kubernetes.Owner
+	}{
+		Name:      tlsSecretName,
+		Namespace: namespace,
+		Domain:    domain,
+		Issuer:    issuer,
+		Owner:     kubernetes.ComponentOwner(TLSDeploymentID),
+	})
+	if err != nil {
+		return err
+	}
+
+	if out, err := helpers.Kubectl(ctx, fmt.Sprintf("apply --filename %s", tmpFile.Name())); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Creating certificate in %s failed:\n%s", namespace, out))
+	}
+
+	message := fmt.Sprintf("Waiting for certificate in %s to be issued", namespace)
+	out, err := helpers.WaitForCommandCompletion(ui, message,
+		func() (string, error) {
+			return helpers.Kubectl(ctx, fmt.Sprintf("wait --for=condition=Ready --timeout=%ds -n %s certificate/%s",
+				timeout, namespace, tlsSecretName))
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("%s failed:\n%s", message, out))
+	}
+	return nil
+}
+
+// URLScheme returns the scheme used by FuseML endpoints
+func URLScheme(ctx context.Context, c *kubernetes.Cluster) string {
+	mode, err := loadSetting(ctx, c, tlsModeSetting)
+	if err != nil || mode == "" || mode == TLSModeNone {
+		return "http"
+	}
+	return "https"
+}
+
+// TrustedCA returns the CA of the self-signed certificate generated by the installer, which the clients
+// of FuseML endpoints have to trust. Nil is returned for the other TLS modes.
+func TrustedCA(ctx context.Context, c *kubernetes.Cluster) ([]byte, error) {
+	mode, err := loadSetting(ctx, c, tlsModeSetting)
+	if err != nil {
+		return nil, err
+	}
+	if mode != TLSModeSelfSigned {
+		return nil, nil
+	}
+	secret, err := c.Kubectl.CoreV1().Secrets(systemNamespace).Get(ctx, tlsSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading TLS certificate")
+	}
+	return secret.Data[caCertKey], nil
+}
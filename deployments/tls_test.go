@@ -0,0 +1,90 @@
+package deployments_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/ui"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("TLS", func() {
+	var (
+		ctx     context.Context
+		kube    *fake.Clientset
+		cluster *kubernetes.Cluster
+		options kubernetes.InstallationOptions
+	)
+
+	tlsSecret := func(namespace string, cert *helpers.Certificate) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fuseml-tls",
+				Namespace: namespace,
+				Labels:    kubernetes.ComponentOwner(TLSDeploymentID).Labels(),
+			},
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       cert.Cert,
+				corev1.TLSPrivateKeyKey: cert.Key,
+				"ca.crt":                cert.CA,
+			},
+			Type: corev1.SecretTypeTLS,
+		}
+	}
+
+	storedCert := func(namespace string) []byte {
+		secret, err := kube.CoreV1().Secrets(namespace).Get(ctx, "fuseml-tls", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return secret.Data[corev1.TLSCertKey]
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		options = kubernetes.InstallationOptions{
+			{Name: "tls", Value: TLSModeSelfSigned},
+			{Name: "system_domain", Value: "10.0.0.2.nip.io"},
+		}
+	})
+
+	Context("with a self-signed certificate from previous installation", func() {
+		var previous *helpers.Certificate
+
+		BeforeEach(func() {
+			var err error
+			previous, err = helpers.GenerateSelfSignedCertificate("10.0.0.1.nip.io")
+			Expect(err).ToNot(HaveOccurred())
+			kube = fake.NewSimpleClientset(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "fuseml-system"}},
+				tlsSecret("fuseml-system", previous),
+				tlsSecret("fuseml-workloads", previous),
+			)
+			cluster = &kubernetes.Cluster{Kubectl: kube}
+		})
+
+		It("regenerates the certificate and its copies when the domain changed", func() {
+			Expect(TLS{}.Deploy(ctx, cluster, ui.NewUI(), options)).To(Succeed())
+
+			for _, namespace := range []string{"fuseml-system", "fuseml-workloads"} {
+				cert := storedCert(namespace)
+				Expect(cert).ToNot(Equal(previous.Cert))
+				Expect(helpers.CertificateCoversDomain(cert, "10.0.0.2.nip.io")).To(BeTrue())
+			}
+		})
+
+		It("reuses the certificate valid for the domain", func() {
+			options[1].Value = "10.0.0.1.nip.io"
+			Expect(TLS{}.Deploy(ctx, cluster, ui.NewUI(), options)).To(Succeed())
+
+			Expect(storedCert("fuseml-system")).To(Equal(previous.Cert))
+			Expect(storedCert("fuseml-workloads")).To(Equal(previous.Cert))
+		})
+	})
+})
@@ -23,17 +23,18 @@ const (
 	TraefikDeploymentID = "traefik"
 	traefikVersion      = "9.11.0"
 	traefikChartURL     = "https://helm.traefik.io/traefik/traefik-9.11.0.tgz"
+	traefikIngressClass = "traefik"
 )
 
 func (k *Traefik) ID() string {
 	return TraefikDeploymentID
 }
 
-func (k *Traefik) Backup(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (k *Traefik) Backup(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
-func (k *Traefik) Restore(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (k *Traefik) Restore(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
@@ -42,10 +43,10 @@ func (k Traefik) Describe() string {
 }
 
 // Delete removes traefik from kubernetes cluster
-func (k Traefik) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
+func (k Traefik) Delete(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
 	ui.Note().KeeplineUnder(1).Msg("Removing Traefik...")
 
-	existsAndOwned, err := c.NamespaceExistsAndOwned(TraefikDeploymentID)
+	existsAndOwned, err := c.NamespaceExistsAndOwned(ctx, TraefikDeploymentID)
 	if err != nil {
 		return errors.Wrapf(err, "failed to check if namespace '%s' is owned or not", TraefikDeploymentID)
 	}
@@ -63,7 +64,7 @@ func (k Traefik) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	out, err := helpers.WaitForCommandCompletion(ui, message,
 		func() (string, error) {
 			helmCmd := fmt.Sprintf("helm uninstall traefik --namespace '%s'", TraefikDeploymentID)
-			return helpers.RunProc(helmCmd, currentdir, k.Debug)
+			return helpers.RunProc(ctx, helmCmd, currentdir, k.Debug)
 		},
 	)
 	if err != nil {
@@ -77,7 +78,7 @@ func (k Traefik) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	message = "Deleting Traefik namespace " + TraefikDeploymentID
 	_, err = helpers.WaitForCommandCompletion(ui, message,
 		func() (string, error) {
-			return "", c.DeleteNamespace(TraefikDeploymentID)
+			return "", c.DeleteNamespace(ctx, TraefikDeploymentID)
 		},
 	)
 	if err != nil {
@@ -89,8 +90,9 @@ func (k Traefik) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	return nil
 }
 
-func (k Traefik) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
-	action := "install"
+func (k Traefik) apply(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions, upgrade bool) error {
+	// upgrade --install also finishes the release interrupted by the previous installation
+	action := "upgrade --install"
 	if upgrade {
 		action = "upgrade"
 	}
@@ -109,19 +111,22 @@ func (k Traefik) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Inst
 	helmArgs = append(helmArgs, `--set "globalArguments="`)
 
 	helmCmd := fmt.Sprintf("helm %s traefik --create-namespace --namespace %s %s %s", action, TraefikDeploymentID, traefikChartURL, strings.Join(helmArgs, " "))
-	if out, err := helpers.RunProc(helmCmd, currentdir, k.Debug); err != nil {
+	if out, err := helpers.RunProc(ctx, helmCmd, currentdir, k.Debug); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Failed installing Traefik: %s\n", out))
 	}
+	if err := labelHelmRelease(ctx, kubernetes.ComponentOwner(TraefikDeploymentID), "traefik", TraefikDeploymentID); err != nil {
+		return err
+	}
 
-	err = c.LabelNamespace(TraefikDeploymentID, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
+	err = c.LabelNamespace(ctx, TraefikDeploymentID, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue)
 	if err != nil {
 		return err
 	}
 
-	if err := c.WaitUntilPodBySelectorExist(ui, TraefikDeploymentID, "app.kubernetes.io/name=traefik", k.Timeout); err != nil {
+	if err := c.WaitUntilPodBySelectorExist(ctx, ui, TraefikDeploymentID, "app.kubernetes.io/name=traefik", k.Timeout); err != nil {
 		return errors.Wrap(err, "failed waiting Traefik Ingress deployment to exist")
 	}
-	if err := c.WaitForPodBySelectorRunning(ui, TraefikDeploymentID, "app.kubernetes.io/name=traefik", k.Timeout); err != nil {
+	if err := c.WaitForPodBySelectorRunning(ctx, ui, TraefikDeploymentID, "app.kubernetes.io/name=traefik", k.Timeout); err != nil {
 		return errors.Wrap(err, "failed waiting Traefik Ingress deployment to come up")
 	}
 
@@ -134,19 +139,23 @@ func (k Traefik) GetVersion() string {
 	return traefikVersion
 }
 
-func (k Traefik) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+// Verify checks that the Traefik pods are running
+func (k Traefik) Verify(ctx context.Context, c *kubernetes.Cluster) error {
+	return c.PodsReady(ctx, TraefikDeploymentID, "app.kubernetes.io/name=traefik")
+}
 
-	_, err := c.Kubectl.CoreV1().Namespaces().Get(
-		context.Background(),
-		TraefikDeploymentID,
-		metav1.GetOptions{},
-	)
-	if err == nil {
+func (k Traefik) Deploy(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+
+	existsAndNotOwned, err := c.NamespaceExistsAndNotOwned(ctx, TraefikDeploymentID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if namespace '%s' is owned or not", TraefikDeploymentID)
+	}
+	if existsAndNotOwned {
 		return errors.New("Namespace " + TraefikDeploymentID + " present already")
 	}
 
 	_, err = c.Kubectl.CoreV1().Services("kube-system").Get(
-		context.Background(),
+		ctx,
 		"traefik",
 		metav1.GetOptions{},
 	)
@@ -156,19 +165,14 @@ func (k Traefik) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.Ins
 		return nil
 	}
 
-	if c.HasIstio() {
-		ui.Exclamation().Msg("Istio already installed, skipping traefik installation...")
-		return nil
-	}
-
 	ui.Note().KeeplineUnder(1).Msg("Deploying Traefik Ingress...")
 
-	return k.apply(c, ui, options, false)
+	return k.apply(ctx, c, ui, options, false)
 }
 
-func (k Traefik) Upgrade(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+func (k Traefik) Upgrade(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 	_, err := c.Kubectl.CoreV1().Namespaces().Get(
-		context.Background(),
+		ctx,
 		TraefikDeploymentID,
 		metav1.GetOptions{},
 	)
@@ -178,5 +182,5 @@ func (k Traefik) Upgrade(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.In
 
 	ui.Note().Msg("Upgrading Traefik Ingress...")
 
-	return k.apply(c, ui, options, true)
+	return k.apply(ctx, c, ui, options, true)
 }
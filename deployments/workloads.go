@@ -40,11 +40,11 @@ func (k *Workloads) ID() string {
 	return WorkloadsDeploymentID
 }
 
-func (k *Workloads) Backup(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (k *Workloads) Backup(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
-func (k *Workloads) Restore(c *kubernetes.Cluster, ui *ui.UI, d string) error {
+func (k *Workloads) Restore(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, d string) error {
 	return nil
 }
 
@@ -53,10 +53,10 @@ func (k Workloads) Describe() string {
 }
 
 // Delete removes Workloads from kubernetes cluster
-func (w Workloads) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
+func (w Workloads) Delete(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
 	ui.Note().KeeplineUnder(1).Msg("Removing Workloads...")
 
-	existsAndOwned, err := c.NamespaceExistsAndOwned(WorkloadsDeploymentID)
+	existsAndOwned, err := c.NamespaceExistsAndOwned(ctx, WorkloadsDeploymentID)
 	if err != nil {
 		return errors.Wrapf(err, "failed to check if namespace '%s' is owned or not", WorkloadsDeploymentID)
 	}
@@ -65,11 +65,11 @@ func (w Workloads) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 		return nil
 	}
 
-	if err := w.deleteWorkloadsNamespace(c, ui); err != nil {
+	if err := w.deleteWorkloadsNamespace(ctx, c, ui); err != nil {
 		return errors.Wrapf(err, "Failed deleting namespace %s", WorkloadsDeploymentID)
 	}
 
-	existsAndOwned, err = c.NamespaceExistsAndOwned("app-ingress")
+	existsAndOwned, err = c.NamespaceExistsAndOwned(ctx, "app-ingress")
 	if err != nil {
 		return errors.Wrapf(err, "failed to check if namespace 'app-ingress' is owned or not")
 	}
@@ -78,7 +78,7 @@ func (w Workloads) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 		return nil
 	}
 
-	if out, err := helpers.KubectlDeleteEmbeddedYaml(appIngressYamlPath, true); err != nil {
+	if out, err := helpers.KubectlDeleteEmbeddedYaml(ctx, appIngressYamlPath, true); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Deleting %s failed:\n%s", appIngressYamlPath, out))
 	}
 
@@ -87,21 +87,28 @@ func (w Workloads) Delete(c *kubernetes.Cluster, ui *ui.UI) error {
 	return nil
 }
 
-func (w Workloads) apply(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
-	if err := w.createWorkloadsNamespace(c, ui, options); err != nil {
+func (w Workloads) apply(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+	if err := w.createWorkloadsNamespace(ctx, c, ui, options); err != nil {
 		return err
 	}
 
-	if !c.HasIstio() {
-		if out, err := helpers.KubectlApplyEmbeddedYaml(appIngressYamlPath); err != nil {
+	provider, err := IngressProviderFor(ctx, c)
+	if err != nil {
+		return err
+	}
+	// with Istio, the applications are exposed by Knative
+	if provider.Name() != IngressIstio {
+		// the annotations of the application ingresses are specific to the controller
+		values := struct{ Ingress, IngressClass string }{provider.Name(), provider.IngressClass()}
+		if out, err := helpers.KubectlApplyEmbeddedTemplate(ctx, appIngressYamlPath, values); err != nil {
 			return errors.Wrap(err, fmt.Sprintf("Installing %s failed:\n%s", appIngressYamlPath, out))
 		}
 
-		if err := c.LabelNamespace("app-ingress", kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue); err != nil {
+		if err := c.LabelNamespace(ctx, "app-ingress", kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue); err != nil {
 			return err
 		}
 
-		if err := c.WaitUntilPodBySelectorExist(ui, "app-ingress", "name=app-ingress", w.Timeout); err != nil {
+		if err := c.WaitUntilPodBySelectorExist(ctx, ui, "app-ingress", "name=app-ingress", w.Timeout); err != nil {
 			return errors.Wrap(err, "failed waiting app-ingress deployment to exist")
 		}
 	}
@@ -116,20 +123,30 @@ func (k Workloads) GetVersion() string {
 	return WorkloadsIngressVersion
 }
 
-func (k Workloads) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
-	_, err := c.Kubectl.CoreV1().Namespaces().Get(
-		context.Background(),
-		WorkloadsDeploymentID,
-		metav1.GetOptions{},
-	)
-	if err == nil {
-		ui.Exclamation().Msg("Namespace " + WorkloadsDeploymentID + " already present, skipping installation")
-		return nil
+// Verify checks that the workloads namespace has its service account with the role binding,
+// and that app-ingress is running when it is used
+func (k Workloads) Verify(ctx context.Context, c *kubernetes.Cluster) error {
+	if _, err := c.Kubectl.CoreV1().ServiceAccounts(WorkloadsDeploymentID).Get(ctx, WorkloadsDeploymentID, metav1.GetOptions{}); err != nil {
+		return errors.Wrap(err, "failed reading workloads service account")
+	}
+	if _, err := c.Kubectl.RbacV1().RoleBindings(WorkloadsDeploymentID).Get(ctx, WorkloadsDeploymentID, metav1.GetOptions{}); err != nil {
+		return errors.Wrap(err, "failed reading workloads role binding")
+	}
+
+	provider, err := IngressProviderFor(ctx, c)
+	if err != nil {
+		return err
+	}
+	if provider.Name() != IngressIstio {
+		return c.PodsReady(ctx, "app-ingress", "name=app-ingress")
 	}
+	return nil
+}
 
+func (k Workloads) Deploy(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 	ui.Note().KeeplineUnder(1).Msg("Deploying Workloads...")
 
-	err = k.apply(c, ui, options)
+	err := k.apply(ctx, c, ui, options)
 	if err != nil {
 		return err
 	}
@@ -137,14 +154,14 @@ func (k Workloads) Deploy(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.I
 	return nil
 }
 
-func (k Workloads) Upgrade(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+func (k Workloads) Upgrade(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 	// NOTE: Not implemented yet
 	return nil
 }
 
-func (w Workloads) createWorkloadsNamespace(c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
+func (w Workloads) createWorkloadsNamespace(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI, options kubernetes.InstallationOptions) error {
 	if _, err := c.Kubectl.CoreV1().Namespaces().Create(
-		context.Background(),
+		ctx,
 		&corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:   WorkloadsDeploymentID,
@@ -152,34 +169,35 @@ func (w Workloads) createWorkloadsNamespace(c *kubernetes.Cluster, ui *ui.UI, op
 			},
 		},
 		metav1.CreateOptions{},
-	); err != nil {
-		return nil
+	); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
 	}
 
-	if err := c.LabelNamespace(WorkloadsDeploymentID, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue); err != nil {
+	// objects created by the interrupted installation are kept
+	if err := c.LabelNamespace(ctx, WorkloadsDeploymentID, kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue); err != nil {
 		return err
 	}
-	if err := w.createGiteaCredsSecret(c, options); err != nil {
+	if err := w.createGiteaCredsSecret(ctx, c, options); err != nil && !apierrors.IsAlreadyExists(err) {
 		return err
 	}
-	if err := w.createWorkloadsServiceAccountWithSecretAccess(c); err != nil {
+	if err := w.createWorkloadsServiceAccountWithSecretAccess(ctx, c); err != nil && !apierrors.IsAlreadyExists(err) {
 		return err
 	}
-	if err := w.createWorkloadsRole(c); err != nil {
+	if err := w.createWorkloadsRole(ctx, c); err != nil && !apierrors.IsAlreadyExists(err) {
 		return err
 	}
-	if err := w.createWorkloadsRoleBinding(c); err != nil {
+	if err := w.createWorkloadsRoleBinding(ctx, c); err != nil && !apierrors.IsAlreadyExists(err) {
 		return err
 	}
 
 	return nil
 }
 
-func (w Workloads) deleteWorkloadsNamespace(c *kubernetes.Cluster, ui *ui.UI) error {
+func (w Workloads) deleteWorkloadsNamespace(ctx context.Context, c *kubernetes.Cluster, ui *ui.UI) error {
 	message := "Deleting Workloads namespace " + WorkloadsDeploymentID
 	_, err := helpers.WaitForCommandCompletion(ui, message,
 		func() (string, error) {
-			return "", c.DeleteNamespace(WorkloadsDeploymentID)
+			return "", c.DeleteNamespace(ctx, WorkloadsDeploymentID)
 		},
 	)
 	if err != nil {
@@ -192,7 +210,7 @@ func (w Workloads) deleteWorkloadsNamespace(c *kubernetes.Cluster, ui *ui.UI) er
 			var err error
 			for err == nil {
 				_, err = c.Kubectl.CoreV1().Namespaces().Get(
-					context.Background(),
+					ctx,
 					WorkloadsDeploymentID,
 					metav1.GetOptions{},
 				)
@@ -216,18 +234,19 @@ func (w Workloads) deleteWorkloadsNamespace(c *kubernetes.Cluster, ui *ui.UI) er
 	return nil
 }
 
-func (w Workloads) createGiteaCredsSecret(c *kubernetes.Cluster, options kubernetes.InstallationOptions) error {
+func (w Workloads) createGiteaCredsSecret(ctx context.Context, c *kubernetes.Cluster, options kubernetes.InstallationOptions) error {
 	domain, err := options.GetString("system_domain", GiteaDeploymentID)
 	if err != nil {
 		return err
 	}
 	giteaSubdomain := GiteaDeploymentID + "." + domain
-	_, err = c.Kubectl.CoreV1().Secrets(WorkloadsDeploymentID).Create(context.Background(),
+	_, err = c.Kubectl.CoreV1().Secrets(WorkloadsDeploymentID).Create(ctx,
 		&corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: "gitea-creds",
+				Name:   "gitea-creds",
+				Labels: kubernetes.ComponentOwner(WorkloadsDeploymentID).Labels(),
 				Annotations: map[string]string{
-					"tekton.dev/git-0": fmt.Sprintf("http://%s", giteaSubdomain),
+					"tekton.dev/git-0": fmt.Sprintf("%s://%s", URLScheme(ctx, c), giteaSubdomain),
 				},
 			},
 			StringData: map[string]string{
@@ -243,13 +262,14 @@ func (w Workloads) createGiteaCredsSecret(c *kubernetes.Cluster, options kuberne
 	return nil
 }
 
-func (w Workloads) createWorkloadsServiceAccountWithSecretAccess(c *kubernetes.Cluster) error {
+func (w Workloads) createWorkloadsServiceAccountWithSecretAccess(ctx context.Context, c *kubernetes.Cluster) error {
 	automountServiceAccountToken := true
 	_, err := c.Kubectl.CoreV1().ServiceAccounts(WorkloadsDeploymentID).Create(
-		context.Background(),
+		ctx,
 		&corev1.ServiceAccount{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: WorkloadsDeploymentID,
+				Name:   WorkloadsDeploymentID,
+				Labels: kubernetes.ComponentOwner(WorkloadsDeploymentID).Labels(),
 			},
 			Secrets:                      []corev1.ObjectReference{{Name: "gitea-creds"}},
 			AutomountServiceAccountToken: &automountServiceAccountToken,
@@ -258,55 +278,166 @@ func (w Workloads) createWorkloadsServiceAccountWithSecretAccess(c *kubernetes.C
 	return err
 }
 
-func (w Workloads) createWorkloadsRole(c *kubernetes.Cluster) error {
+func (w Workloads) createWorkloadsRole(ctx context.Context, c *kubernetes.Cluster) error {
 	_, err := c.Kubectl.RbacV1().Roles(WorkloadsDeploymentID).Create(
-		context.Background(),
+		ctx,
 		&rbacv1.Role{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: WorkloadsDeploymentID,
+				Name:   WorkloadsDeploymentID,
+				Labels: kubernetes.ComponentOwner(WorkloadsDeploymentID).Labels(),
 			},
 			Rules: roleRules,
 		}, metav1.CreateOptions{})
 	return err
 }
 
-// Update the role with new rule
-func (w Workloads) updateWorkloadsRole(c *kubernetes.Cluster, newRule rbacv1.PolicyRule) error {
+// extensionRoleName returns the name of the Role (and its RoleBinding) with the rules granted
+// to the workloads service account by the extension
+func extensionRoleName(extension string) string {
+	return fmt.Sprintf("%s-%s", WorkloadsDeploymentID, extension)
+}
 
-	role, err := c.Kubectl.RbacV1().Roles(WorkloadsDeploymentID).Get(
-		context.Background(),
-		WorkloadsDeploymentID,
-		metav1.GetOptions{},
-	)
+// Grant is the rule of the workloads service account and who granted it
+type Grant struct {
+	GrantedBy string
+	Rule      rbacv1.PolicyRule
+}
 
-	for _, rule := range role.Rules {
-		// this is only a simple check for exact duplicates; we ignore the situation
-		// when e.g. the new rule is subset of existing one
-		if helpers.StringSlicesEqual(rule.APIGroups, newRule.APIGroups) &&
-			helpers.StringSlicesEqual(rule.Resources, newRule.Resources) &&
-			helpers.StringSlicesEqual(rule.Verbs, newRule.Verbs) {
-			return nil
-		}
+// GrantExtensionRules grants the rules to the workloads service account on behalf of the extension,
+// by the Role and RoleBinding of the extension. The rules granted before by the extension are replaced.
+func (w Workloads) GrantExtensionRules(ctx context.Context, c *kubernetes.Cluster, extension string, rules []rbacv1.PolicyRule) error {
+	owner := kubernetes.ExtensionOwner(extension)
+	meta := metav1.ObjectMeta{
+		Name:   extensionRoleName(extension),
+		Labels: owner.Labels(),
 	}
 
-	role, err = c.Kubectl.RbacV1().Roles(WorkloadsDeploymentID).Update(
-		context.Background(),
-		&rbacv1.Role{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: WorkloadsDeploymentID,
+	roles := c.Kubectl.RbacV1().Roles(WorkloadsDeploymentID)
+	role, err := roles.Get(ctx, meta.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = roles.Create(ctx, &rbacv1.Role{ObjectMeta: meta, Rules: rules}, metav1.CreateOptions{})
+	} else if err == nil {
+		role.Rules = rules
+		_, err = roles.Update(ctx, role, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to grant the rules of extension %s", extension)
+	}
+
+	_, err = c.Kubectl.RbacV1().RoleBindings(WorkloadsDeploymentID).Create(
+		ctx,
+		&rbacv1.RoleBinding{
+			ObjectMeta: meta,
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: "rbac.authorization.k8s.io",
+				Kind:     "Role",
+				Name:     meta.Name,
 			},
-			Rules: append(role.Rules, newRule),
-		}, metav1.UpdateOptions{})
+			Subjects: []rbacv1.Subject{{Kind: "ServiceAccount", Name: WorkloadsDeploymentID, Namespace: WorkloadsDeploymentID}},
+		}, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to bind the rules of extension %s", extension)
+	}
 
+	return w.removeFromWorkloadsRole(ctx, c, rules)
+}
+
+// RevokeExtensionRules removes the rules granted to the workloads service account by the extension.
+// The rules are also removed from the workloads Role, where the older installers added them.
+func (w Workloads) RevokeExtensionRules(ctx context.Context, c *kubernetes.Cluster, extension string, rules []rbacv1.PolicyRule) error {
+	name := extensionRoleName(extension)
+	err := c.Kubectl.RbacV1().RoleBindings(WorkloadsDeploymentID).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to revoke the rules of extension %s", extension)
+	}
+	err = c.Kubectl.RbacV1().Roles(WorkloadsDeploymentID).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to revoke the rules of extension %s", extension)
+	}
+
+	return w.removeFromWorkloadsRole(ctx, c, rules)
+}
+
+// removeFromWorkloadsRole removes the rules other than the default ones from the workloads Role
+func (w Workloads) removeFromWorkloadsRole(ctx context.Context, c *kubernetes.Cluster, rules []rbacv1.PolicyRule) error {
+	roles := c.Kubectl.RbacV1().Roles(WorkloadsDeploymentID)
+	role, err := roles.Get(ctx, WorkloadsDeploymentID, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	kept := []rbacv1.PolicyRule{}
+	for _, rule := range role.Rules {
+		if !containsRule(roleRules, rule) && containsRule(rules, rule) {
+			continue
+		}
+		kept = append(kept, rule)
+	}
+	if len(kept) == len(role.Rules) {
+		return nil
+	}
+	role.Rules = kept
+	_, err = roles.Update(ctx, role, metav1.UpdateOptions{})
 	return err
 }
 
-func (w Workloads) createWorkloadsRoleBinding(c *kubernetes.Cluster) error {
+// WorkloadsGrants lists the rules of the workloads service account, with the extensions granting them
+func (w Workloads) WorkloadsGrants(ctx context.Context, c *kubernetes.Cluster) ([]Grant, error) {
+	grants := []Grant{}
+
+	role, err := c.Kubectl.RbacV1().Roles(WorkloadsDeploymentID).Get(ctx, WorkloadsDeploymentID, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil {
+		for _, rule := range role.Rules {
+			grantedBy := "FuseML"
+			if !containsRule(roleRules, rule) {
+				// added by an older installer, which did not track the extensions
+				grantedBy = "unknown extension"
+			}
+			grants = append(grants, Grant{GrantedBy: grantedBy, Rule: rule})
+		}
+	}
+
+	roles, err := c.Kubectl.RbacV1().Roles(WorkloadsDeploymentID).List(ctx, metav1.ListOptions{
+		LabelSelector: kubernetes.FusemlExtensionLabelKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles.Items {
+		owner := kubernetes.ExtensionOwner(role.Labels[kubernetes.FusemlExtensionLabelKey])
+		for _, rule := range role.Rules {
+			grants = append(grants, Grant{GrantedBy: owner.String(), Rule: rule})
+		}
+	}
+	return grants, nil
+}
+
+// containsRule is only a simple check for exact duplicates; we ignore the situation
+// when e.g. the rule is subset of existing one
+func containsRule(rules []rbacv1.PolicyRule, rule rbacv1.PolicyRule) bool {
+	for _, r := range rules {
+		if helpers.StringSlicesEqual(r.APIGroups, rule.APIGroups) &&
+			helpers.StringSlicesEqual(r.Resources, rule.Resources) &&
+			helpers.StringSlicesEqual(r.Verbs, rule.Verbs) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w Workloads) createWorkloadsRoleBinding(ctx context.Context, c *kubernetes.Cluster) error {
 	_, err := c.Kubectl.RbacV1().RoleBindings(WorkloadsDeploymentID).Create(
-		context.Background(),
+		ctx,
 		&rbacv1.RoleBinding{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: WorkloadsDeploymentID,
+				Name:   WorkloadsDeploymentID,
+				Labels: kubernetes.ComponentOwner(WorkloadsDeploymentID).Labels(),
 			},
 			RoleRef: rbacv1.RoleRef{
 				APIGroup: "rbac.authorization.k8s.io",
@@ -0,0 +1,80 @@
+package deployments_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Workloads permissions", func() {
+	var (
+		ctx     context.Context
+		kube    *fake.Clientset
+		cluster *kubernetes.Cluster
+	)
+	defaultRule := rbacv1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "list", "watch"}}
+	mlflowRule := rbacv1.PolicyRule{APIGroups: []string{"serving.kubeflow.org"}, Resources: []string{"inferenceservices"}, Verbs: []string{"get", "create"}}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		kube = fake.NewSimpleClientset(&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: WorkloadsDeploymentID, Namespace: WorkloadsDeploymentID},
+			// the extension rule added by an older installer
+			Rules: []rbacv1.PolicyRule{defaultRule, mlflowRule},
+		})
+		cluster = &kubernetes.Cluster{Kubectl: kube}
+	})
+
+	It("grants the rules by the role of the extension", func() {
+		Expect(Workloads{}.GrantExtensionRules(ctx, cluster, "mlflow", []rbacv1.PolicyRule{mlflowRule})).To(Succeed())
+
+		role, err := kube.RbacV1().Roles(WorkloadsDeploymentID).Get(ctx, "fuseml-workloads-mlflow", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(role.Rules).To(Equal([]rbacv1.PolicyRule{mlflowRule}))
+		binding, err := kube.RbacV1().RoleBindings(WorkloadsDeploymentID).Get(ctx, "fuseml-workloads-mlflow", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(binding.Subjects[0].Name).To(Equal(WorkloadsDeploymentID))
+
+		grants, err := Workloads{}.WorkloadsGrants(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(grants).To(Equal([]Grant{
+			{GrantedBy: "FuseML", Rule: defaultRule},
+			{GrantedBy: "extension mlflow", Rule: mlflowRule},
+		}))
+	})
+
+	It("revokes exactly the rules of the extension", func() {
+		Expect(Workloads{}.GrantExtensionRules(ctx, cluster, "mlflow", []rbacv1.PolicyRule{mlflowRule})).To(Succeed())
+		Expect(Workloads{}.GrantExtensionRules(ctx, cluster, "kfserving", []rbacv1.PolicyRule{mlflowRule})).To(Succeed())
+
+		Expect(Workloads{}.RevokeExtensionRules(ctx, cluster, "mlflow", []rbacv1.PolicyRule{mlflowRule})).To(Succeed())
+
+		_, err := kube.RbacV1().Roles(WorkloadsDeploymentID).Get(ctx, "fuseml-workloads-mlflow", metav1.GetOptions{})
+		Expect(err).To(HaveOccurred())
+		grants, err := Workloads{}.WorkloadsGrants(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(grants).To(Equal([]Grant{
+			{GrantedBy: "FuseML", Rule: defaultRule},
+			{GrantedBy: "extension kfserving", Rule: mlflowRule},
+		}))
+	})
+
+	It("reports the rules added by older installers", func() {
+		grants, err := Workloads{}.WorkloadsGrants(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(grants[1]).To(Equal(Grant{GrantedBy: "unknown extension", Rule: mlflowRule}))
+
+		Expect(Workloads{}.RevokeExtensionRules(ctx, cluster, "mlflow", []rbacv1.PolicyRule{mlflowRule})).To(Succeed())
+		grants, err = Workloads{}.WorkloadsGrants(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(grants).To(Equal([]Grant{{GrantedBy: "FuseML", Rule: defaultRule}}))
+	})
+})
@@ -0,0 +1,45 @@
+package helpers_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+
+	. "github.com/fuseml/fuseml/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GenerateSelfSignedCertificate", func() {
+	It("creates a wildcard certificate signed by a new CA", func() {
+		cert, err := GenerateSelfSignedCertificate("10.0.0.1.nip.io")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = tls.X509KeyPair(cert.Cert, cert.Key)
+		Expect(err).ToNot(HaveOccurred())
+
+		pool := x509.NewCertPool()
+		Expect(pool.AppendCertsFromPEM(cert.CA)).To(BeTrue())
+
+		block, _ := pem.Decode(cert.Cert)
+		Expect(block).ToNot(BeNil())
+		leaf, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, host := range []string{"fuseml-core.10.0.0.1.nip.io", "10.0.0.1.nip.io"} {
+			_, err = leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: pool})
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+})
+
+var _ = Describe("CertificateCoversDomain", func() {
+	It("matches the wildcard name of the domain only", func() {
+		cert, err := GenerateSelfSignedCertificate("10.0.0.1.nip.io")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(CertificateCoversDomain(cert.Cert, "10.0.0.1.nip.io")).To(BeTrue())
+		Expect(CertificateCoversDomain(cert.Cert, "10.0.0.2.nip.io")).To(BeFalse())
+		Expect(CertificateCoversDomain([]byte("garbage"), "10.0.0.1.nip.io")).To(BeFalse())
+	})
+})
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"context"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -10,10 +11,19 @@ import (
 	"github.com/pkg/errors"
 )
 
+// get sends GET request, which is aborted when the context is cancelled
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
+
 // DownloadFile downloads the given url as a file with "name" under "directory"
-func DownloadFile(url, name, directory string) error {
+func DownloadFile(ctx context.Context, url, name, directory string) error {
 
-	resp, err := http.Get(url)
+	resp, err := get(ctx, url)
 	if err != nil {
 		return err
 	}
@@ -33,9 +43,9 @@ func DownloadFile(url, name, directory string) error {
 }
 
 // DownloadAndUntar downloads the given url and decopresses it under the target directory
-func DownloadAndUntar(url, targetDir string) error {
+func DownloadAndUntar(ctx context.Context, url, targetDir string) error {
 
-	resp, err := http.Get(url)
+	resp, err := get(ctx, url)
 	if err != nil {
 		return err
 	}
@@ -45,8 +55,8 @@ func DownloadAndUntar(url, targetDir string) error {
 }
 
 // DownloadAndUnzip downloads the archive from given url and decopresses it under the target directory
-func DownloadAndUnzip(url, targetDir string) error {
-	resp, err := http.Get(url)
+func DownloadAndUnzip(ctx context.Context, url, targetDir string) error {
+	resp, err := get(ctx, url)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package helpers_test
 
 import (
+	"context"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -35,7 +36,7 @@ var _ = Describe("DownloadFile", func() {
 	})
 
 	It("downloads a url with filename under directory", func() {
-		err = DownloadFile(url, "downloadedFile", directory)
+		err = DownloadFile(context.Background(), url, "downloadedFile", directory)
 		Expect(err).ToNot(HaveOccurred())
 		targetPath := path.Join(directory, "downloadedFile")
 
@@ -1,12 +1,14 @@
 package helpers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
+	"text/template"
 
 	_ "github.com/fuseml/fuseml/cli/statik"
 	"github.com/rakyll/statik/fs"
@@ -39,21 +41,56 @@ func ExtractFile(filePath string) (string, error) {
 	return tmpFilePath, nil
 }
 
+// ReadEmbeddedFile returns the contents of a file embedded with statik
+func ReadEmbeddedFile(filePath string) ([]byte, error) {
+	statikFS, err := fs.New()
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(statikFS, path.Join("/", filePath))
+}
+
 // KubectlApplyEmbeddedYaml un-embeds the given yaml file and calls `kubectl apply`
 // on it. It returns the command output and an error (if there is one)
-func KubectlApplyEmbeddedYaml(yamlPath string) (string, error) {
+func KubectlApplyEmbeddedYaml(ctx context.Context, yamlPath string) (string, error) {
+	yamlPathOnDisk, err := ExtractFile(yamlPath)
+	if err != nil {
+		return "", errors.New("Failed to extract embedded file: " + yamlPath + " - " + err.Error())
+	}
+	defer os.Remove(yamlPathOnDisk)
+
+	return Kubectl(ctx, fmt.Sprintf("apply --filename %s", yamlPathOnDisk))
+}
+
+// KubectlApplyEmbeddedTemplate un-embeds the given yaml template, renders it with
+// the given values and calls `kubectl apply` on the result. It returns the command
+// output and an error (if there is one)
+func KubectlApplyEmbeddedTemplate(ctx context.Context, yamlPath string, values interface{}) (string, error) {
 	yamlPathOnDisk, err := ExtractFile(yamlPath)
 	if err != nil {
 		return "", errors.New("Failed to extract embedded file: " + yamlPath + " - " + err.Error())
 	}
 	defer os.Remove(yamlPathOnDisk)
 
-	return Kubectl(fmt.Sprintf("apply --filename %s", yamlPathOnDisk))
+	tmpl, err := template.ParseFiles(yamlPathOnDisk)
+	if err != nil {
+		return "", err
+	}
+	renderedFile, err := ioutil.TempFile("", "fuseml")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(renderedFile.Name())
+	if err := tmpl.Execute(renderedFile, values); err != nil {
+		return "", err
+	}
+
+	return Kubectl(ctx, fmt.Sprintf("apply --filename %s", renderedFile.Name()))
 }
 
 // KubectlDeleteEmbeddedYaml un-embeds the given yaml file and calls `kubectl delete`
 // on it. It returns the command output and an error (if there is one)
-func KubectlDeleteEmbeddedYaml(yamlPath string, ignoreMissing bool) (string, error) {
+func KubectlDeleteEmbeddedYaml(ctx context.Context, yamlPath string, ignoreMissing bool) (string, error) {
 	yamlPathOnDisk, err := ExtractFile(yamlPath)
 	if err != nil {
 		return "", errors.New("Failed to extract embedded file: " + yamlPath + " - " + err.Error())
@@ -61,8 +98,8 @@ func KubectlDeleteEmbeddedYaml(yamlPath string, ignoreMissing bool) (string, err
 	defer os.Remove(yamlPathOnDisk)
 
 	if ignoreMissing {
-		return Kubectl(fmt.Sprintf("delete --ignore-not-found=true --wait=false --filename %s", yamlPathOnDisk))
+		return Kubectl(ctx, fmt.Sprintf("delete --ignore-not-found=true --wait=false --filename %s", yamlPathOnDisk))
 	} else {
-		return Kubectl(fmt.Sprintf("delete --filename %s", yamlPathOnDisk))
+		return Kubectl(ctx, fmt.Sprintf("delete --filename %s", yamlPathOnDisk))
 	}
 }
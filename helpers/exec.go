@@ -2,11 +2,13 @@ package helpers
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"syscall"
 	"time"
 
 	"github.com/codeskyblue/kexec"
@@ -19,12 +21,20 @@ type ExternalFuncWithString func() (output string, err error)
 
 type ExternalFunc func() (err error)
 
-func RunProc(cmd, dir string, toStdout bool) (string, error) {
-	return RunProcEnv(cmd, dir, toStdout, make([]string, 0))
+// CommandHook is called with every executed command and its output, e.g. to write the audit log
+var CommandHook = func(command string, exitCode int, duration time.Duration, output string) {}
+
+// processTerminationGracePeriod is the time given to the terminated processes to clean up before they are killed
+const processTerminationGracePeriod = 5 * time.Second
+
+// RunProc runs the shell command in given directory. When the context is cancelled,
+// the command is terminated, together with all of its child processes.
+func RunProc(ctx context.Context, cmd, dir string, toStdout bool) (string, error) {
+	return RunProcEnv(ctx, cmd, dir, toStdout, make([]string, 0))
 }
 
 // run process with the list of env variables
-func RunProcEnv(cmd, dir string, toStdout bool, env []string) (string, error) {
+func RunProcEnv(ctx context.Context, cmd, dir string, toStdout bool, env []string) (string, error) {
 	if os.Getenv("DEBUG") == "true" {
 		fmt.Println("Executing ", cmd)
 	}
@@ -44,15 +54,13 @@ func RunProcEnv(cmd, dir string, toStdout bool, env []string) (string, error) {
 
 	p.Dir = dir
 
-	if err := p.Run(); err != nil {
-		return b.String(), err
-	}
-
-	err := p.Wait()
+	start := time.Now()
+	err := runWithContext(ctx, p)
+	CommandHook(cmd, exitCode(p), time.Since(start), b.String())
 	return b.String(), err
 }
 
-func RunProcNoErr(cmd, dir string, toStdout bool) (string, error) {
+func RunProcNoErr(ctx context.Context, cmd, dir string, toStdout bool) (string, error) {
 	if os.Getenv("DEBUG") == "true" {
 		fmt.Println("Executing ", cmd)
 	}
@@ -69,12 +77,48 @@ func RunProcNoErr(cmd, dir string, toStdout bool) (string, error) {
 
 	p.Dir = dir
 
-	if err := p.Run(); err != nil {
-		return b.String(), err
+	start := time.Now()
+	err := runWithContext(ctx, p)
+	CommandHook(cmd, exitCode(p), time.Since(start), b.String())
+	return b.String(), err
+}
+
+// exit code of the finished process, -1 when it was not started or was killed by a signal
+func exitCode(p *kexec.KCommand) int {
+	if p.ProcessState == nil {
+		return -1
 	}
+	return p.ProcessState.ExitCode()
+}
 
-	err := p.Wait()
-	return b.String(), err
+// runWithContext runs the process and waits for it to finish. The process runs in its own
+// session, so on cancellation the whole process group is terminated (e.g. helm with its plugins).
+func runWithContext(ctx context.Context, p *kexec.KCommand) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := p.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		_ = p.Terminate(syscall.SIGTERM)
+		select {
+		case <-done:
+		case <-time.After(processTerminationGracePeriod):
+			_ = p.Terminate(syscall.SIGKILL)
+			<-done
+		}
+		return ctx.Err()
+	}
 }
 
 // CreateTmpFile creates a temporary file on the disk with the given contents
@@ -96,7 +140,7 @@ func CreateTmpFile(contents string) (string, error) {
 
 // Kubectl invoces the `kubectl` command in PATH, running the specified command.
 // It returns the command output and/or error.
-func Kubectl(command string) (string, error) {
+func Kubectl(ctx context.Context, command string) (string, error) {
 	_, err := exec.LookPath("kubectl")
 	if err != nil {
 		return "", errors.Wrap(err, "kubectl not in path")
@@ -109,13 +153,13 @@ func Kubectl(command string) (string, error) {
 
 	cmd := fmt.Sprintf("kubectl " + command)
 
-	return RunProc(cmd, currentdir, false)
+	return RunProc(ctx, cmd, currentdir, false)
 }
 
-func KubectlWithProgress(ui *ui.UI, command string) (string, error) {
+func KubectlWithProgress(ctx context.Context, ui *ui.UI, command string) (string, error) {
 	s := ui.Progressf(" Executing: kubectl %s", command)
 	defer s.Stop()
-	return Kubectl(command)
+	return Kubectl(ctx, command)
 }
 
 func WaitForCommandCompletion(ui *ui.UI, message string, funk ExternalFuncWithString) (string, error) {
@@ -126,26 +170,29 @@ func WaitForCommandCompletion(ui *ui.UI, message string, funk ExternalFuncWithSt
 }
 
 // WaitForKubernetesResourceToExist waits for a kubernetes resource to exist for 'timeout' seconds
-func WaitForKubernetesResourceToExist(ui *ui.UI, namespace, kind, name string, timeout int) (string, error) {
+func WaitForKubernetesResourceToExist(ctx context.Context, ui *ui.UI, namespace, kind, name string, timeout int) (string, error) {
 	s := ui.Progressf(" Waiting for %s %s", kind, name)
 	defer s.Stop()
 
-	return ExecToSuccessWithTimeout(func() (string, error) {
-		return Kubectl(fmt.Sprintf("get %s %s -n %s", kind, name, namespace))
+	return ExecToSuccessWithTimeout(ctx, func() (string, error) {
+		return Kubectl(ctx, fmt.Sprintf("get %s %s -n %s", kind, name, namespace))
 	}, time.Duration(timeout)*time.Second, time.Second)
 }
 
 // ExecToSuccessWithTimeout retries the given function with stirng & error return,
 // until it either succeeds of the timeout is reached. It retries every "interval" duration.
-func ExecToSuccessWithTimeout(funk ExternalFuncWithString, timeout, interval time.Duration) (string, error) {
+// Waiting stops when the context is cancelled.
+func ExecToSuccessWithTimeout(ctx context.Context, funk ExternalFuncWithString, timeout, interval time.Duration) (string, error) {
 	timeoutChan := time.After(timeout)
 	for {
 		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
 		case <-timeoutChan:
 			return "", errors.New(fmt.Sprintf("Timed out after %s", timeout.String()))
 		default:
 			if out, err := funk(); err != nil {
-				time.Sleep(interval)
+				sleep(ctx, interval)
 			} else {
 				return out, nil
 			}
@@ -155,18 +202,29 @@ func ExecToSuccessWithTimeout(funk ExternalFuncWithString, timeout, interval tim
 
 // RunToSuccessWithTimeout retries the given function with error return,
 // until it either succeeds or the timeout is reached. It retries every "interval" duration.
-func RunToSuccessWithTimeout(funk ExternalFunc, timeout, interval time.Duration) error {
+// Waiting stops when the context is cancelled.
+func RunToSuccessWithTimeout(ctx context.Context, funk ExternalFunc, timeout, interval time.Duration) error {
 	timeoutChan := time.After(timeout)
 	for {
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case <-timeoutChan:
 			return fmt.Errorf("timed out after %s", timeout.String())
 		default:
 			if err := funk(); err != nil {
-				time.Sleep(interval)
+				sleep(ctx, interval)
 			} else {
 				return nil
 			}
 		}
 	}
 }
+
+// sleep waits for the given duration, unless the context is cancelled sooner
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
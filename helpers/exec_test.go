@@ -0,0 +1,44 @@
+package helpers_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	. "github.com/fuseml/fuseml/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RunProc", func() {
+	It("returns the output of the command", func() {
+		out, err := RunProc(context.Background(), "echo hello", "", false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("hello\n"))
+	})
+
+	It("terminates the command and its children when the context is cancelled", func() {
+		directory, err := ioutil.TempDir("", "fuseml-test")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(directory)
+		marker := path.Join(directory, "marker")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err = RunProc(ctx, "(sleep 1; touch "+marker+") & wait", "", false)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+		Consistently(func() string { return marker }, 1500*time.Millisecond).ShouldNot(BeARegularFile())
+	})
+
+	It("does not start the command when the context is already cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := RunProc(ctx, "echo hello", "", false)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
@@ -1,14 +1,31 @@
 package helpers
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"text/template"
 )
 
-// CreateIstioIngressGateway creates a temporary ingress gateway definition for teh specified service
-func CreateIstioIngressGateway(name string, namespace string, host string, serviceHost string, servicePort int) (string, error) {
+// IstioGateway describes an istio ingress gateway (and optionally a VirtualService)
+// exposing a service under a host name
+type IstioGateway struct {
+	Name        string
+	Namespace   string
+	Host        string
+	ServiceHost string
+	ServicePort int
+	// Name of the TLS secret used by the gateway; when set, the gateway serves HTTPS
+	CredentialName string
+	// Labels of the ingress gateway pods; Istio default gateway is used when empty
+	Selector map[string]string
+	// Labels of the Gateway and VirtualService
+	Labels map[string]string
+}
+
+// ApplyIstioGateway creates the ingress gateway definition (and VirtualService) for the specified service
+func ApplyIstioGateway(ctx context.Context, gw IstioGateway) (string, error) {
 
 	tmpFile, err := ioutil.TempFile("", "fuseml")
 	if err != nil {
@@ -25,9 +42,18 @@ metadata:
   namespace: {{ .Namespace }}
   labels:
     "app.kubernetes.io/name": {{ .Name }}
+{{- range $key, $value := .Labels }}
+    "{{ $key }}": "{{ $value }}"
+{{- end }}
 spec:
   selector:
+{{- if .Selector }}
+{{- range $key, $value := .Selector }}
+    "{{ $key }}": "{{ $value }}"
+{{- end }}
+{{- else }}
     istio: ingressgateway # use Istio default gateway implementation
+{{- end }}
   servers:
   - port:
       number: 80
@@ -35,6 +61,17 @@ spec:
       protocol: HTTP
     hosts:
     - "{{ .Host }}"
+{{- if .CredentialName }}
+  - port:
+      number: 443
+      name: https
+      protocol: HTTPS
+    tls:
+      mode: SIMPLE
+      credentialName: {{ .CredentialName }}
+    hosts:
+    - "{{ .Host }}"
+{{- end }}
 {{ if .ServiceHost }}
 ---
 apiVersion: networking.istio.io/v1alpha3
@@ -42,6 +79,12 @@ kind: VirtualService
 metadata:
   name: {{ .Name }}
   namespace: {{ .Namespace }}
+{{- if .Labels }}
+  labels:
+{{- range $key, $value := .Labels }}
+    "{{ $key }}": "{{ $value }}"
+{{- end }}
+{{- end }}
 spec:
   hosts:
   - "{{ .Host }}"
@@ -62,21 +105,9 @@ spec:
 		return tmpFile.Name(), err
 	}
 
-	err = istioGatewayTmpl.Execute(tmpFile, struct {
-		Name        string
-		Namespace   string
-		Host        string
-		ServiceHost string
-		ServicePort int
-	}{
-		Name:        name,
-		Namespace:   namespace,
-		Host:        host,
-		ServiceHost: serviceHost,
-		ServicePort: servicePort,
-	})
+	err = istioGatewayTmpl.Execute(tmpFile, gw)
 	if err != nil {
 		return tmpFile.Name(), err
 	}
-	return Kubectl(fmt.Sprintf("apply --filename %s", tmpFile.Name()))
+	return Kubectl(ctx, fmt.Sprintf("apply --filename %s", tmpFile.Name()))
 }
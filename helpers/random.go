@@ -0,0 +1,24 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+const randomStringCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// RandomString generates a random alphanumeric string of the given length, e.g. for passwords
+func RandomString(length int) (string, error) {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(randomStringCharset)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to generate random string")
+		}
+		b[i] = randomStringCharset[n.Int64()]
+	}
+	return string(b), nil
+}
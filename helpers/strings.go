@@ -13,11 +13,13 @@ func StringInSlice(s []string, str string) bool {
 }
 
 // Check if given slices of strings have same content
-// (different order of items counts as same slice)
+// (different order of items counts as same slice), the slices are not modified
 func StringSlicesEqual(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
 	}
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
 	sort.Strings(a)
 	sort.Strings(b)
 	for i, v := range a {
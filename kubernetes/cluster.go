@@ -5,27 +5,38 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 
+	aks "github.com/fuseml/fuseml/cli/kubernetes/platform/aks"
+	dockerdesktop "github.com/fuseml/fuseml/cli/kubernetes/platform/dockerdesktop"
+	eks "github.com/fuseml/fuseml/cli/kubernetes/platform/eks"
 	generic "github.com/fuseml/fuseml/cli/kubernetes/platform/generic"
+	gke "github.com/fuseml/fuseml/cli/kubernetes/platform/gke"
 	ibm "github.com/fuseml/fuseml/cli/kubernetes/platform/ibm"
 	k3s "github.com/fuseml/fuseml/cli/kubernetes/platform/k3s"
 	kind "github.com/fuseml/fuseml/cli/kubernetes/platform/kind"
+	microk8s "github.com/fuseml/fuseml/cli/kubernetes/platform/microk8s"
 	minikube "github.com/fuseml/fuseml/cli/kubernetes/platform/minikube"
+	openshift "github.com/fuseml/fuseml/cli/kubernetes/platform/openshift"
+	rke2 "github.com/fuseml/fuseml/cli/kubernetes/platform/rke2"
 	"github.com/fuseml/fuseml/cli/paas/ui"
 
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/api/extensions/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/remotecommand"
 
@@ -44,26 +55,46 @@ var (
 )
 
 type Platform interface {
-	Detect(*kubernetes.Clientset) bool
+	Detect(kubernetes.Interface) bool
 	Describe() string
 	String() string
-	Load(*kubernetes.Clientset) error
+	Load(kubernetes.Interface) error
 	ExternalIPs() []string
+	// DefaultStorageClass returns the name of the default StorageClass, empty if there is none
+	DefaultStorageClass() string
+	// LoadBalancerIP returns the IP address of the LoadBalancer service ingress
+	LoadBalancerIP(v1.LoadBalancerIngress) (string, error)
 }
 
+// SupportedPlatforms are checked in order, the first detected one is used.
+// OpenShift goes first, as it runs on the nodes of other providers.
 var SupportedPlatforms []Platform = []Platform{
+	openshift.NewPlatform(),
 	kind.NewPlatform(),
 	k3s.NewPlatform(),
+	rke2.NewPlatform(),
 	ibm.NewPlatform(),
 	minikube.NewPlatform(),
+	microk8s.NewPlatform(),
+	dockerdesktop.NewPlatform(),
+	eks.NewPlatform(),
+	gke.NewPlatform(),
+	aks.NewPlatform(),
 }
 
 type Cluster struct {
 	//	InternalIPs []string
 	//	Ingress     bool
-	Kubectl    *kubernetes.Clientset
+	Kubectl    kubernetes.Interface
 	RestConfig *restclient.Config
 	platform   Platform
+
+	// Dynamic client and Mapper finding its resources, to work with objects of any kind
+	Dynamic dynamic.Interface
+	Mapper  meta.RESTMapper
+
+	// preferred Ingress API version, discovered on first use
+	ingressAPIVersion string
 }
 
 // NewClusterFromClient creates a new Cluster from a Kubernetes rest client config
@@ -71,19 +102,61 @@ func NewClusterFromClient(restConfig *restclient.Config) (*Cluster, error) {
 	c := &Cluster{}
 
 	c.RestConfig = restConfig
-	clientset, err := kubernetes.NewForConfig(restConfig)
+	clientset, err := kubernetes.NewForConfig(auditedConfig(restConfig))
 	if err != nil {
 		return nil, err
 	}
 	c.Kubectl = clientset
-	c.detectPlatform()
-	if c.platform == nil {
-		c.platform = generic.NewPlatform()
+	c.platform = DetectPlatform(clientset)
+	if err := c.connectDynamic(restConfig, clientset); err != nil {
+		return nil, err
 	}
 
 	return c, c.platform.Load(clientset)
 }
 
+// connectDynamic creates the dynamic client and the mapper discovering the resources on the first use
+func (c *Cluster) connectDynamic(restConfig *restclient.Config, clientset kubernetes.Interface) error {
+	dynamicClient, err := dynamic.NewForConfig(auditedConfig(restConfig))
+	if err != nil {
+		return err
+	}
+	c.Dynamic = dynamicClient
+	discoveryClient := memory.NewMemCacheClient(clientset.Discovery())
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient)
+	c.Mapper = resettableMapper{restmapper.NewShortcutExpander(mapper, discoveryClient), mapper.Reset}
+	return nil
+}
+
+// resettableMapper lets the shortcut expander discard the resources discovered by the mapper it wraps
+type resettableMapper struct {
+	meta.RESTMapper
+	reset func()
+}
+
+func (m resettableMapper) Reset() {
+	m.reset()
+}
+
+// resetMapper discards the resources discovered by the Mapper, so they are discovered again on the next use
+func (c *Cluster) resetMapper() {
+	if mapper, ok := c.Mapper.(interface{ Reset() }); ok {
+		mapper.Reset()
+	}
+}
+
+// TransportHook wraps the transport of the clients, e.g. to record the changes made to the cluster in the audit log
+var TransportHook = func(rt http.RoundTripper) http.RoundTripper { return rt }
+
+// auditedConfig returns a copy of the config with the transport wrapped by the TransportHook
+func auditedConfig(restConfig *restclient.Config) *restclient.Config {
+	config := restclient.CopyConfig(restConfig)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return TransportHook(rt)
+	})
+	return config
+}
+
 func NewCluster(kubeconfig string) (*Cluster, error) {
 	c := &Cluster{}
 	return c, c.Connect(kubeconfig)
@@ -99,14 +172,14 @@ func (c *Cluster) Connect(config string) error {
 		return err
 	}
 	c.RestConfig = restConfig
-	clientset, err := kubernetes.NewForConfig(restConfig)
+	clientset, err := kubernetes.NewForConfig(auditedConfig(restConfig))
 	if err != nil {
 		return err
 	}
 	c.Kubectl = clientset
-	c.detectPlatform()
-	if c.platform == nil {
-		c.platform = generic.NewPlatform()
+	c.platform = DetectPlatform(clientset)
+	if err := c.connectDynamic(restConfig, clientset); err != nil {
+		return err
 	}
 
 	err = c.platform.Load(clientset)
@@ -116,20 +189,22 @@ func (c *Cluster) Connect(config string) error {
 	return err
 }
 
-func (c *Cluster) detectPlatform() {
+// DetectPlatform returns the first of supported platforms detected in the cluster,
+// generic platform is returned when none is detected
+func DetectPlatform(kube kubernetes.Interface) Platform {
 	for _, p := range SupportedPlatforms {
-		if p.Detect(c.Kubectl) {
-			c.platform = p
-			return
+		if p.Detect(kube) {
+			return p
 		}
 	}
+	return generic.NewPlatform()
 }
 
 // IsPodRunningAndReady returns a condition function that indicates whether the given pod is
 // currently running and ready
-func (c *Cluster) IsPodRunningAndReady(podName, namespace string) wait.ConditionFunc {
+func (c *Cluster) IsPodRunningAndReady(ctx context.Context, podName, namespace string) wait.ConditionFunc {
 	return func() (bool, error) {
-		pod, err := c.Kubectl.CoreV1().Pods(namespace).Get(context.Background(), podName, metav1.GetOptions{})
+		pod, err := c.Kubectl.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 		if err != nil {
 			return false, err
 		}
@@ -163,9 +238,9 @@ func (c *Cluster) IsPodRunningAndReady(podName, namespace string) wait.Condition
 	}
 }
 
-func (c *Cluster) PodExists(namespace, selector string) wait.ConditionFunc {
+func (c *Cluster) PodExists(ctx context.Context, namespace, selector string) wait.ConditionFunc {
 	return func() (bool, error) {
-		podList, err := c.ListPods(namespace, selector)
+		podList, err := c.ListPods(ctx, namespace, selector)
 		if err != nil {
 			return false, err
 		}
@@ -178,17 +253,30 @@ func (c *Cluster) PodExists(namespace, selector string) wait.ConditionFunc {
 
 // Poll up to timeout seconds for pod to enter running and ready state.
 // Returns an error if the pod never enters such state.
-func (c *Cluster) WaitForPodRunning(namespace, podName string, timeout time.Duration) error {
-	return wait.PollImmediate(time.Second, timeout, c.IsPodRunningAndReady(podName, namespace))
+func (c *Cluster) WaitForPodRunning(ctx context.Context, namespace, podName string, timeout time.Duration) error {
+	return PollImmediate(ctx, time.Second, timeout, c.IsPodRunningAndReady(ctx, podName, namespace))
+}
+
+// PollImmediate works like wait.PollImmediate, but stops as soon as the context is cancelled.
+// The error of the context is returned in such case, instead of a timeout.
+func PollImmediate(ctx context.Context, interval, timeout time.Duration, condition wait.ConditionFunc) error {
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := wait.PollImmediateUntil(interval, condition, pollCtx.Done())
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
 }
 
 // ListPods returns the list of currently scheduled or running pods in `namespace` with the given selector
-func (c *Cluster) ListPods(namespace, selector string) (*v1.PodList, error) {
+func (c *Cluster) ListPods(ctx context.Context, namespace, selector string) (*v1.PodList, error) {
 	listOptions := metav1.ListOptions{}
 	if len(selector) > 0 {
 		listOptions.LabelSelector = selector
 	}
-	podList, err := c.Kubectl.CoreV1().Pods(namespace).List(context.Background(), listOptions)
+	podList, err := c.Kubectl.CoreV1().Pods(namespace).List(ctx, listOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -197,21 +285,21 @@ func (c *Cluster) ListPods(namespace, selector string) (*v1.PodList, error) {
 
 // Wait up to timeout seconds for all pods in 'namespace' with given 'selector' to enter running state.
 // Returns an error if no pods are found or not all discovered pods enter running state.
-func (c *Cluster) WaitUntilPodBySelectorExist(ui *ui.UI, namespace, selector string, timeout int) error {
+func (c *Cluster) WaitUntilPodBySelectorExist(ctx context.Context, ui *ui.UI, namespace, selector string, timeout int) error {
 	s := ui.Progressf("Creating %s in %s", selector, namespace)
 	defer s.Stop()
 
-	return wait.PollImmediate(time.Second, time.Duration(timeout)*time.Second, c.PodExists(namespace, selector))
+	return PollImmediate(ctx, time.Second, time.Duration(timeout)*time.Second, c.PodExists(ctx, namespace, selector))
 }
 
 // WaitForPodBySelectorRunning waits timeout seconds for all pods in 'namespace'
 // with given 'selector' to enter running state. Returns an error if no pods are
 // found or not all discovered pods enter running state.
-func (c *Cluster) WaitForPodBySelectorRunning(ui *ui.UI, namespace, selector string, timeout int) error {
+func (c *Cluster) WaitForPodBySelectorRunning(ctx context.Context, ui *ui.UI, namespace, selector string, timeout int) error {
 	s := ui.Progressf("Starting %s in %s", selector, namespace)
 	defer s.Stop()
 
-	podList, err := c.ListPods(namespace, selector)
+	podList, err := c.ListPods(ctx, namespace, selector)
 	if err != nil {
 		return errors.Wrapf(err, "failed listingpods with selector %s", selector)
 	}
@@ -222,8 +310,11 @@ func (c *Cluster) WaitForPodBySelectorRunning(ui *ui.UI, namespace, selector str
 
 	for _, pod := range podList.Items {
 		s.ChangeMessagef("  Starting pod %s in %s", pod.Name, namespace)
-		if err := c.WaitForPodRunning(namespace, pod.Name, time.Duration(timeout)*time.Second); err != nil {
-			events, err2 := c.GetPodEvents(namespace, pod.Name)
+		if err := c.WaitForPodRunning(ctx, namespace, pod.Name, time.Duration(timeout)*time.Second); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			events, err2 := c.GetPodEvents(ctx, namespace, pod.Name)
 			if err2 != nil {
 				return errors.Wrap(err, err2.Error())
 			} else {
@@ -234,16 +325,41 @@ func (c *Cluster) WaitForPodBySelectorRunning(ui *ui.UI, namespace, selector str
 	return nil
 }
 
+// PodsReady checks, without waiting, that there are pods in 'namespace' with given 'selector'
+// and all of them are running and ready. Returns an error describing the first pod which is not.
+func (c *Cluster) PodsReady(ctx context.Context, namespace, selector string) error {
+	podList, err := c.ListPods(ctx, namespace, selector)
+	if err != nil {
+		return errors.Wrapf(err, "failed listing pods with selector %s", selector)
+	}
+
+	if len(podList.Items) == 0 {
+		return fmt.Errorf("no pods in %s with selector %s", namespace, selector)
+	}
+
+	for _, pod := range podList.Items {
+		ready, err := c.IsPodRunningAndReady(ctx, pod.Name, namespace)()
+		if err != nil {
+			return err
+		}
+		if !ready {
+			return fmt.Errorf("pod %s in %s is not ready", pod.Name, namespace)
+		}
+	}
+	return nil
+}
+
 // GetPodEventsWithSelector tries to find a pod using the provided selector and
 // namespace. If found it returns the events on that Pod. If not found it returns
 // an error.
 // An equivalent kubectl command would look like this
 // (label selector being "app.kubernetes.io/name=container-registry"):
-//   kubectl get event --namespace my-namespace \
-//   --field-selector involvedObject.name=$( \
-//     kubectl get pods -o=jsonpath='{.items[0].metadata.name}' --selector=app.kubernetes.io/name=container-registry -n my-namespace)
-func (c *Cluster) GetPodEventsWithSelector(namespace, selector string) (string, error) {
-	podList, err := c.Kubectl.CoreV1().Pods(namespace).List(context.Background(),
+//
+//	kubectl get event --namespace my-namespace \
+//	--field-selector involvedObject.name=$( \
+//	  kubectl get pods -o=jsonpath='{.items[0].metadata.name}' --selector=app.kubernetes.io/name=container-registry -n my-namespace)
+func (c *Cluster) GetPodEventsWithSelector(ctx context.Context, namespace, selector string) (string, error) {
+	podList, err := c.Kubectl.CoreV1().Pods(namespace).List(ctx,
 		metav1.ListOptions{LabelSelector: selector})
 	if err != nil {
 		return "", err
@@ -253,11 +369,11 @@ func (c *Cluster) GetPodEventsWithSelector(namespace, selector string) (string,
 	}
 	podName := podList.Items[0].Name
 
-	return c.GetPodEvents(namespace, podName)
+	return c.GetPodEvents(ctx, namespace, podName)
 }
 
-func (c *Cluster) GetPodEvents(namespace, podName string) (string, error) {
-	eventList, err := c.Kubectl.CoreV1().Events(namespace).List(context.Background(),
+func (c *Cluster) GetPodEvents(ctx context.Context, namespace, podName string) (string, error) {
+	eventList, err := c.Kubectl.CoreV1().Events(namespace).List(ctx,
 		metav1.ListOptions{
 			FieldSelector: "involvedObject.name=" + podName,
 		})
@@ -286,8 +402,8 @@ func (c *Cluster) Exec(namespace, podName, containerName string, command, stdin
 }
 
 // GetSecret gets a secret's values
-func (c *Cluster) GetSecret(namespace, name string) (*v1.Secret, error) {
-	secret, err := c.Kubectl.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (c *Cluster) GetSecret(ctx context.Context, namespace, name string) (*v1.Secret, error) {
+	secret, err := c.Kubectl.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get secret")
 	}
@@ -297,7 +413,7 @@ func (c *Cluster) GetSecret(namespace, name string) (*v1.Secret, error) {
 
 // GetVersion get the kube server version
 func (c *Cluster) GetVersion() (string, error) {
-	v, err := c.Kubectl.ServerVersion()
+	v, err := c.Kubectl.Discovery().ServerVersion()
 	if err != nil {
 		return "", errors.Wrap(err, "failed to get kube server version")
 	}
@@ -307,9 +423,9 @@ func (c *Cluster) GetVersion() (string, error) {
 
 // DeploymentStatus returns running status for a Deployment
 // If the deployment doesn't exist, the status is set to 0/0
-func (c *Cluster) DeploymentStatus(namespace, selector string) (string, error) {
+func (c *Cluster) DeploymentStatus(ctx context.Context, namespace, selector string) (string, error) {
 	result, err := c.Kubectl.AppsV1().Deployments(namespace).List(
-		context.Background(),
+		ctx,
 		metav1.ListOptions{
 			LabelSelector: selector,
 		},
@@ -326,37 +442,24 @@ func (c *Cluster) DeploymentStatus(namespace, selector string) (string, error) {
 	return fmt.Sprintf("%d/%d", result.Items[0].Status.ReadyReplicas, result.Items[0].Status.Replicas), nil
 }
 
-// ListIngressRoutes returns a list of all routes for ingresses in `namespace` with the given selector
-func (c *Cluster) ListIngressRoutes(namespace, name string) ([]string, error) {
-	ingress, err := c.Kubectl.NetworkingV1().Ingresses(namespace).Get(context.Background(), name, metav1.GetOptions{})
+// ListIngressRoutes returns a list of all routes for ingresses in `namespace` with the given name
+func (c *Cluster) ListIngressRoutes(ctx context.Context, namespace, name string) ([]string, error) {
+	ingresses, err := c.ListIngress(ctx, namespace, "")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to list ingresses")
 	}
 
 	result := []string{}
 
-	for _, rule := range ingress.Spec.Rules {
-		result = append(result, rule.Host)
+	for _, ingress := range ingresses {
+		if ingress.Name == name {
+			result = append(result, ingress.Hosts...)
+		}
 	}
 
 	return result, nil
 }
 
-// ListIngress returns the list of available ingresses in `namespace` with the given selector
-func (c *Cluster) ListIngress(namespace, selector string) (*v1beta1.IngressList, error) {
-	listOptions := metav1.ListOptions{}
-	if len(selector) > 0 {
-		listOptions.LabelSelector = selector
-	}
-
-	// TODO: Switch to networking v1 when we don't care about <1.18 clusters
-	ingressList, err := c.Kubectl.ExtensionsV1beta1().Ingresses(namespace).List(context.Background(), listOptions)
-	if err != nil {
-		return nil, err
-	}
-	return ingressList, nil
-}
-
 func (c *Cluster) execPod(namespace, podName, containerName string,
 	command string, stdin io.Reader, stdout, stderr io.Writer) error {
 	cmd := []string{
@@ -398,10 +501,10 @@ func (c *Cluster) execPod(namespace, podName, containerName string,
 }
 
 // LabelNamespace adds a label to the namespace
-func (c *Cluster) LabelNamespace(namespace, labelKey, labelValue string) error {
+func (c *Cluster) LabelNamespace(ctx context.Context, namespace, labelKey, labelValue string) error {
 	patchContents := fmt.Sprintf(`{ "metadata": { "labels": { "%s": "%s" } } }`, labelKey, labelValue)
 
-	_, err := c.Kubectl.CoreV1().Namespaces().Patch(context.Background(), namespace,
+	_, err := c.Kubectl.CoreV1().Namespaces().Patch(ctx, namespace,
 		types.StrategicMergePatchType, []byte(patchContents), metav1.PatchOptions{})
 
 	if err != nil {
@@ -411,8 +514,8 @@ func (c *Cluster) LabelNamespace(namespace, labelKey, labelValue string) error {
 	return nil
 }
 
-func (c *Cluster) NamespaceOwned(namespaceName string) (bool, error) {
-	owned, err := c.NamespaceLabelExists(namespaceName, FusemlDeploymentLabelKey)
+func (c *Cluster) NamespaceOwned(ctx context.Context, namespaceName string) (bool, error) {
+	owned, err := c.NamespaceLabelExists(ctx, namespaceName, FusemlDeploymentLabelKey)
 	if err != nil {
 		return false, err
 	}
@@ -421,8 +524,8 @@ func (c *Cluster) NamespaceOwned(namespaceName string) (bool, error) {
 
 // NamespaceExistsAndOwned checks if the namespace exists
 // and is created by fuseml or not.
-func (c *Cluster) NamespaceExistsAndOwned(namespaceName string) (bool, error) {
-	exists, err := c.NamespaceExists(namespaceName)
+func (c *Cluster) NamespaceExistsAndOwned(ctx context.Context, namespaceName string) (bool, error) {
+	exists, err := c.NamespaceExists(ctx, namespaceName)
 	if err != nil {
 		return false, err
 	}
@@ -430,20 +533,20 @@ func (c *Cluster) NamespaceExistsAndOwned(namespaceName string) (bool, error) {
 		return false, nil
 	}
 
-	return c.NamespaceOwned(namespaceName)
+	return c.NamespaceOwned(ctx, namespaceName)
 }
 
 // NamespaceExistsAndNotOwned returns true only if the namespace exists
 // but was NOT created by fuseml
-func (c *Cluster) NamespaceExistsAndNotOwned(namespaceName string) (bool, error) {
-	exists, err := c.NamespaceExists(namespaceName)
+func (c *Cluster) NamespaceExistsAndNotOwned(ctx context.Context, namespaceName string) (bool, error) {
+	exists, err := c.NamespaceExists(ctx, namespaceName)
 	if err != nil {
 		return false, err
 	}
 	if !exists {
 		return false, nil
 	}
-	owned, err := c.NamespaceOwned(namespaceName)
+	owned, err := c.NamespaceOwned(ctx, namespaceName)
 	if err != nil {
 		return false, err
 	}
@@ -451,8 +554,8 @@ func (c *Cluster) NamespaceExistsAndNotOwned(namespaceName string) (bool, error)
 }
 
 // NamespaceExists checks if a namespace exists or not
-func (c *Cluster) NamespaceExists(namespaceName string) (bool, error) {
-	_, err := c.Kubectl.CoreV1().Namespaces().Get(context.Background(), namespaceName, metav1.GetOptions{})
+func (c *Cluster) NamespaceExists(ctx context.Context, namespaceName string) (bool, error) {
+	_, err := c.Kubectl.CoreV1().Namespaces().Get(ctx, namespaceName, metav1.GetOptions{})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			return false, nil
@@ -463,8 +566,8 @@ func (c *Cluster) NamespaceExists(namespaceName string) (bool, error) {
 }
 
 // NamespaceLabelExists checks if a specific label exits on the namespace
-func (c *Cluster) NamespaceLabelExists(namespaceName, labelKey string) (bool, error) {
-	namespace, err := c.Kubectl.CoreV1().Namespaces().Get(context.Background(), namespaceName, metav1.GetOptions{})
+func (c *Cluster) NamespaceLabelExists(ctx context.Context, namespaceName, labelKey string) (bool, error) {
+	namespace, err := c.Kubectl.CoreV1().Namespaces().Get(ctx, namespaceName, metav1.GetOptions{})
 	if err != nil {
 		return false, err
 	}
@@ -476,9 +579,27 @@ func (c *Cluster) NamespaceLabelExists(namespaceName, labelKey string) (bool, er
 	return false, nil
 }
 
+// CreateOwnedNamespace creates the namespace labeled as owned by fuseml, unless it already exists
+func (c *Cluster) CreateOwnedNamespace(ctx context.Context, namespace string) error {
+	exists, err := c.NamespaceExists(ctx, namespace)
+	if err != nil || exists {
+		return err
+	}
+	_, err = c.Kubectl.CoreV1().Namespaces().Create(ctx,
+		&v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   namespace,
+				Labels: map[string]string{FusemlDeploymentLabelKey: FusemlDeploymentLabelValue},
+			},
+		},
+		metav1.CreateOptions{},
+	)
+	return err
+}
+
 // DeleteNamespace deletes the namepace
-func (c *Cluster) DeleteNamespace(namespace string) error {
-	err := c.Kubectl.CoreV1().Namespaces().Delete(context.Background(), namespace, metav1.DeleteOptions{})
+func (c *Cluster) DeleteNamespace(ctx context.Context, namespace string) error {
+	err := c.Kubectl.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{})
 	if err != nil {
 		return err
 	}
@@ -486,9 +607,9 @@ func (c *Cluster) DeleteNamespace(namespace string) error {
 }
 
 // HasIstio checks if istio is installed on the cluster
-func (c *Cluster) HasIstio() bool {
+func (c *Cluster) HasIstio(ctx context.Context) bool {
 	_, err := c.Kubectl.CoreV1().Services("istio-system").Get(
-		context.Background(),
+		ctx,
 		"istiod",
 		metav1.GetOptions{},
 	)
@@ -499,9 +620,9 @@ func (c *Cluster) HasIstio() bool {
 }
 
 // HasKnative checks if Knative serving is installed on the cluster
-func (c *Cluster) HasKnative() bool {
+func (c *Cluster) HasKnative(ctx context.Context) bool {
 	_, err := c.Kubectl.CoreV1().Services("knative-serving").Get(
-		context.Background(),
+		ctx,
 		"controller",
 		metav1.GetOptions{},
 	)
@@ -0,0 +1,45 @@
+package kubernetes_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/kubernetes"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func appPod(name string, ready bool) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "gitea", Labels: map[string]string{"app": "gitea"}},
+		Status: v1.PodStatus{
+			Phase:             v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{{Name: "gitea", Ready: ready}},
+		},
+	}
+}
+
+var _ = Describe("Cluster", func() {
+	Describe("PodsReady", func() {
+		ctx := context.Background()
+
+		It("succeeds when all pods are ready", func() {
+			cluster := &Cluster{Kubectl: fake.NewSimpleClientset(appPod("gitea-0", true), appPod("gitea-1", true))}
+			Expect(cluster.PodsReady(ctx, "gitea", "app=gitea")).To(Succeed())
+		})
+
+		It("fails when some pod is not ready", func() {
+			cluster := &Cluster{Kubectl: fake.NewSimpleClientset(appPod("gitea-0", true), appPod("gitea-1", false))}
+			Expect(cluster.PodsReady(ctx, "gitea", "app=gitea")).To(MatchError("pod gitea-1 in gitea is not ready"))
+		})
+
+		It("fails when there are no pods", func() {
+			cluster := &Cluster{Kubectl: fake.NewSimpleClientset()}
+			Expect(cluster.PodsReady(ctx, "gitea", "app=gitea")).To(MatchError("no pods in gitea with selector app=gitea"))
+		})
+	})
+})
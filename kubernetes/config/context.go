@@ -0,0 +1,43 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// WithContext writes a copy of the kubeconfig (the default one when the path is empty) with
+// the context selected as the current one, so it is used by the API client as well as by
+// kubectl and helm. The returned function removes the copy.
+func WithContext(configPath, context string) (string, func(), error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if paths := filepath.SplitList(configPath); len(paths) == 1 {
+		loadingRules.ExplicitPath = paths[0]
+	} else if len(paths) > 1 {
+		loadingRules.Precedence = paths
+	}
+	// relative paths of the certificates are resolved, so the copy can be anywhere
+	rawConfig, err := loadingRules.Load()
+	if err != nil {
+		return "", nil, &getConfigError{err}
+	}
+	if _, ok := rawConfig.Contexts[context]; !ok {
+		return "", nil, errors.Errorf("context '%s' not found in kubeconfig", context)
+	}
+	rawConfig.CurrentContext = context
+
+	f, err := ioutil.TempFile("", "fuseml-kubeconfig-*.yaml")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to create kubeconfig")
+	}
+	f.Close()
+	cleanup := func() { os.Remove(f.Name()) }
+	if err := clientcmd.WriteToFile(*rawConfig, f.Name()); err != nil {
+		cleanup()
+		return "", nil, errors.Wrap(err, "failed to write kubeconfig")
+	}
+	return f.Name(), cleanup, nil
+}
@@ -1,17 +1,21 @@
 package kubernetes
 
 import (
+	"context"
+
 	"github.com/fuseml/fuseml/cli/paas/ui"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Deployment
 type Deployment interface {
-	Deploy(*Cluster, *ui.UI, InstallationOptions) error
-	Upgrade(*Cluster, *ui.UI, InstallationOptions) error
-	Delete(*Cluster, *ui.UI) error
+	Deploy(context.Context, *Cluster, *ui.UI, InstallationOptions) error
+	Upgrade(context.Context, *Cluster, *ui.UI, InstallationOptions) error
+	Delete(context.Context, *Cluster, *ui.UI) error
 	Describe() string
 	GetVersion() string
-	Restore(*Cluster, *ui.UI, string) error
-	Backup(*Cluster, *ui.UI, string) error
+	Restore(context.Context, *Cluster, *ui.UI, string) error
+	Backup(context.Context, *Cluster, *ui.UI, string) error
+	// Verify checks that the deployment is present and healthy, without changing anything
+	Verify(context.Context, *Cluster) error
 	ID() string
 }
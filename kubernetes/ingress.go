@@ -0,0 +1,391 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Ingress API versions known to the installer, in the order of preference
+const (
+	IngressAPINetworkingV1      = "networking.k8s.io/v1"
+	IngressAPINetworkingV1beta1 = "networking.k8s.io/v1beta1"
+	IngressAPIExtensionsV1beta1 = "extensions/v1beta1"
+
+	// used instead of ingressClassName by clusters without IngressClass resources
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+	// marks the IngressClass used by ingresses that do not specify any class
+	defaultIngressClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+)
+
+// Ingress describes an ingress exposing a single service under a host name,
+// independently of the Ingress API version served by the cluster
+type Ingress struct {
+	Name      string
+	Namespace string
+	Host      string
+	// Hosts of all the rules of the ingress, as listed by ListIngress (the first one is the Host)
+	Hosts       []string
+	ServiceName string
+	ServicePort int
+	// Name of the secret holding TLS certificate for the host; no TLS is configured when empty
+	TLSSecret string
+	// Name of the IngressClass; the default class of the cluster is used when empty
+	ClassName   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// servesResource checks if the cluster serves the resource in the given API group version
+func (c *Cluster) servesResource(groupVersion, resource string) (bool, error) {
+	resources, err := c.Kubectl.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to discover resources of %s", groupVersion)
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IngressAPIVersion returns the preferred Ingress API version served by the cluster
+func (c *Cluster) IngressAPIVersion() (string, error) {
+	if c.ingressAPIVersion != "" {
+		return c.ingressAPIVersion, nil
+	}
+	for _, version := range []string{IngressAPINetworkingV1, IngressAPINetworkingV1beta1, IngressAPIExtensionsV1beta1} {
+		served, err := c.servesResource(version, "ingresses")
+		if err != nil {
+			return "", err
+		}
+		if served {
+			c.ingressAPIVersion = version
+			return version, nil
+		}
+	}
+	return "", errors.New("the cluster does not serve any known Ingress API version")
+}
+
+// IngressClasses returns the names of the IngressClasses available in the cluster together
+// with the name of the default one. Empty list is returned for clusters without IngressClass API.
+func (c *Cluster) IngressClasses(ctx context.Context) ([]string, string, error) {
+	classes := []string{}
+	defaultClass := ""
+
+	served, err := c.servesResource(IngressAPINetworkingV1, "ingressclasses")
+	if err != nil {
+		return nil, "", err
+	}
+	if served {
+		list, err := c.Kubectl.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to list ingress classes")
+		}
+		for _, class := range list.Items {
+			classes = append(classes, class.Name)
+			if class.Annotations[defaultIngressClassAnnotation] == "true" {
+				defaultClass = class.Name
+			}
+		}
+		return classes, defaultClass, nil
+	}
+
+	served, err = c.servesResource(IngressAPINetworkingV1beta1, "ingressclasses")
+	if err != nil {
+		return nil, "", err
+	}
+	if served {
+		list, err := c.Kubectl.NetworkingV1beta1().IngressClasses().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to list ingress classes")
+		}
+		for _, class := range list.Items {
+			classes = append(classes, class.Name)
+			if class.Annotations[defaultIngressClassAnnotation] == "true" {
+				defaultClass = class.Name
+			}
+		}
+	}
+	return classes, defaultClass, nil
+}
+
+// ingressClassFor returns the IngressClass which should be used by the ingress, and whether
+// it has to be set by the legacy annotation (when there is no IngressClass resource for it)
+func (c *Cluster) ingressClassFor(ctx context.Context, ingress Ingress) (string, bool, error) {
+	classes, defaultClass, err := c.IngressClasses(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	if len(classes) == 0 {
+		return ingress.ClassName, true, nil
+	}
+	if ingress.ClassName != "" {
+		for _, class := range classes {
+			if class == ingress.ClassName {
+				return class, false, nil
+			}
+		}
+		// controllers without IngressClass resource still watch the annotation
+		return ingress.ClassName, true, nil
+	}
+	if defaultClass != "" {
+		return defaultClass, false, nil
+	}
+	if len(classes) == 1 {
+		return classes[0], false, nil
+	}
+	return "", false, errors.Errorf("no default ingress class in the cluster, choose one of: %v", classes)
+}
+
+// CreateIngress creates the ingress using the Ingress API version served by the cluster
+func (c *Cluster) CreateIngress(ctx context.Context, ingress Ingress) error {
+	version, err := c.IngressAPIVersion()
+	if err != nil {
+		return err
+	}
+	class, legacy, err := c.ingressClassFor(ctx, ingress)
+	if err != nil {
+		return err
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:        ingress.Name,
+		Namespace:   ingress.Namespace,
+		Labels:      ingress.Labels,
+		Annotations: map[string]string{},
+	}
+	for key, value := range ingress.Annotations {
+		meta.Annotations[key] = value
+	}
+	var className *string
+	if legacy {
+		if class != "" {
+			meta.Annotations[ingressClassAnnotation] = class
+		}
+	} else {
+		className = &class
+	}
+	var tlsHosts []string
+	if ingress.TLSSecret != "" {
+		tlsHosts = []string{ingress.Host}
+	}
+
+	switch version {
+	case IngressAPINetworkingV1:
+		pathType := networkingv1.PathTypePrefix
+		obj := &networkingv1.Ingress{
+			ObjectMeta: meta,
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: className,
+				Rules: []networkingv1.IngressRule{{
+					Host: ingress.Host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &pathType,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: ingress.ServiceName,
+										Port: networkingv1.ServiceBackendPort{
+											Number: int32(ingress.ServicePort),
+										},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		if tlsHosts != nil {
+			obj.Spec.TLS = []networkingv1.IngressTLS{{Hosts: tlsHosts, SecretName: ingress.TLSSecret}}
+		}
+		_, err = c.Kubectl.NetworkingV1().Ingresses(ingress.Namespace).Create(ctx, obj, metav1.CreateOptions{})
+	case IngressAPINetworkingV1beta1:
+		pathType := networkingv1beta1.PathTypePrefix
+		obj := &networkingv1beta1.Ingress{
+			ObjectMeta: meta,
+			Spec: networkingv1beta1.IngressSpec{
+				IngressClassName: className,
+				Rules: []networkingv1beta1.IngressRule{{
+					Host: ingress.Host,
+					IngressRuleValue: networkingv1beta1.IngressRuleValue{
+						HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+							Paths: []networkingv1beta1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &pathType,
+								Backend: networkingv1beta1.IngressBackend{
+									ServiceName: ingress.ServiceName,
+									ServicePort: intstr.FromInt(ingress.ServicePort),
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		if tlsHosts != nil {
+			obj.Spec.TLS = []networkingv1beta1.IngressTLS{{Hosts: tlsHosts, SecretName: ingress.TLSSecret}}
+		}
+		_, err = c.Kubectl.NetworkingV1beta1().Ingresses(ingress.Namespace).Create(ctx, obj, metav1.CreateOptions{})
+	default:
+		obj := &extensionsv1beta1.Ingress{
+			ObjectMeta: meta,
+			Spec: extensionsv1beta1.IngressSpec{
+				IngressClassName: className,
+				Rules: []extensionsv1beta1.IngressRule{{
+					Host: ingress.Host,
+					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
+						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
+							Paths: []extensionsv1beta1.HTTPIngressPath{{
+								Path: "/",
+								Backend: extensionsv1beta1.IngressBackend{
+									ServiceName: ingress.ServiceName,
+									ServicePort: intstr.FromInt(ingress.ServicePort),
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		if tlsHosts != nil {
+			obj.Spec.TLS = []extensionsv1beta1.IngressTLS{{Hosts: tlsHosts, SecretName: ingress.TLSSecret}}
+		}
+		_, err = c.Kubectl.ExtensionsV1beta1().Ingresses(ingress.Namespace).Create(ctx, obj, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to create ingress %s", ingress.Name)
+	}
+	return nil
+}
+
+// DeleteIngress deletes the ingress using the Ingress API version served by the cluster,
+// the missing ingress is not an error
+func (c *Cluster) DeleteIngress(ctx context.Context, namespace, name string) error {
+	version, err := c.IngressAPIVersion()
+	if err != nil {
+		return err
+	}
+	switch version {
+	case IngressAPINetworkingV1:
+		err = c.Kubectl.NetworkingV1().Ingresses(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	case IngressAPINetworkingV1beta1:
+		err = c.Kubectl.NetworkingV1beta1().Ingresses(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	default:
+		err = c.Kubectl.ExtensionsV1beta1().Ingresses(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete ingress %s", name)
+	}
+	return nil
+}
+
+// ListIngress returns the list of available ingresses in `namespace` with the given selector
+func (c *Cluster) ListIngress(ctx context.Context, namespace, selector string) ([]Ingress, error) {
+	version, err := c.IngressAPIVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	listOptions := metav1.ListOptions{}
+	if len(selector) > 0 {
+		listOptions.LabelSelector = selector
+	}
+
+	result := []Ingress{}
+	switch version {
+	case IngressAPINetworkingV1:
+		list, err := c.Kubectl.NetworkingV1().Ingresses(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list ingresses")
+		}
+		for _, obj := range list.Items {
+			ingress := listedIngress(obj.ObjectMeta, obj.Spec.IngressClassName)
+			for _, rule := range obj.Spec.Rules {
+				ingress.addHost(rule.Host)
+				if ingress.ServiceName == "" && rule.HTTP != nil && len(rule.HTTP.Paths) > 0 && rule.HTTP.Paths[0].Backend.Service != nil {
+					ingress.ServiceName = rule.HTTP.Paths[0].Backend.Service.Name
+					ingress.ServicePort = int(rule.HTTP.Paths[0].Backend.Service.Port.Number)
+				}
+			}
+			if len(obj.Spec.TLS) > 0 {
+				ingress.TLSSecret = obj.Spec.TLS[0].SecretName
+			}
+			result = append(result, ingress)
+		}
+	case IngressAPINetworkingV1beta1:
+		list, err := c.Kubectl.NetworkingV1beta1().Ingresses(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list ingresses")
+		}
+		for _, obj := range list.Items {
+			ingress := listedIngress(obj.ObjectMeta, obj.Spec.IngressClassName)
+			for _, rule := range obj.Spec.Rules {
+				ingress.addHost(rule.Host)
+				if ingress.ServiceName == "" && rule.HTTP != nil && len(rule.HTTP.Paths) > 0 {
+					ingress.ServiceName = rule.HTTP.Paths[0].Backend.ServiceName
+					ingress.ServicePort = rule.HTTP.Paths[0].Backend.ServicePort.IntValue()
+				}
+			}
+			if len(obj.Spec.TLS) > 0 {
+				ingress.TLSSecret = obj.Spec.TLS[0].SecretName
+			}
+			result = append(result, ingress)
+		}
+	default:
+		list, err := c.Kubectl.ExtensionsV1beta1().Ingresses(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list ingresses")
+		}
+		for _, obj := range list.Items {
+			ingress := listedIngress(obj.ObjectMeta, obj.Spec.IngressClassName)
+			for _, rule := range obj.Spec.Rules {
+				ingress.addHost(rule.Host)
+				if ingress.ServiceName == "" && rule.HTTP != nil && len(rule.HTTP.Paths) > 0 {
+					ingress.ServiceName = rule.HTTP.Paths[0].Backend.ServiceName
+					ingress.ServicePort = rule.HTTP.Paths[0].Backend.ServicePort.IntValue()
+				}
+			}
+			if len(obj.Spec.TLS) > 0 {
+				ingress.TLSSecret = obj.Spec.TLS[0].SecretName
+			}
+			result = append(result, ingress)
+		}
+	}
+	return result, nil
+}
+
+// listedIngress returns the ingress with the metadata and class of the listed object
+func listedIngress(meta metav1.ObjectMeta, className *string) Ingress {
+	ingress := Ingress{Name: meta.Name, Namespace: meta.Namespace, Labels: meta.Labels, Annotations: meta.Annotations}
+	ingress.ClassName = meta.Annotations[ingressClassAnnotation]
+	if className != nil {
+		ingress.ClassName = *className
+	}
+	return ingress
+}
+
+// addHost records the host of the rule, the rules without host (matching any host) are skipped
+func (i *Ingress) addHost(host string) {
+	if host == "" {
+		return
+	}
+	if i.Host == "" {
+		i.Host = host
+	}
+	i.Hosts = append(i.Hosts, host)
+}
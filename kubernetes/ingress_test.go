@@ -0,0 +1,177 @@
+package kubernetes_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/kubernetes"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// ingressCluster returns the cluster serving the resources of the API group versions
+func ingressCluster(served map[string][]string, objects ...runtime.Object) *Cluster {
+	clientset := fake.NewSimpleClientset(objects...)
+	discovery := clientset.Discovery().(*fakediscovery.FakeDiscovery)
+	for _, version := range []string{IngressAPINetworkingV1, IngressAPINetworkingV1beta1, IngressAPIExtensionsV1beta1} {
+		list := &metav1.APIResourceList{GroupVersion: version}
+		for _, resource := range served[version] {
+			list.APIResources = append(list.APIResources, metav1.APIResource{Name: resource})
+		}
+		discovery.Resources = append(discovery.Resources, list)
+	}
+	return &Cluster{Kubectl: clientset}
+}
+
+func ingressRule(host, service string) networkingv1.IngressRule {
+	return networkingv1.IngressRule{
+		Host: host,
+		IngressRuleValue: networkingv1.IngressRuleValue{
+			HTTP: &networkingv1.HTTPIngressRuleValue{
+				Paths: []networkingv1.HTTPIngressPath{{
+					Path: "/",
+					Backend: networkingv1.IngressBackend{
+						Service: &networkingv1.IngressServiceBackend{
+							Name: service,
+							Port: networkingv1.ServiceBackendPort{Number: 80},
+						},
+					},
+				}},
+			},
+		},
+	}
+}
+
+var _ = Describe("Ingress", func() {
+	ctx := context.Background()
+
+	Describe("IngressAPIVersion", func() {
+		It("prefers networking.k8s.io/v1", func() {
+			cluster := ingressCluster(map[string][]string{
+				IngressAPINetworkingV1:      {"ingresses"},
+				IngressAPINetworkingV1beta1: {"ingresses"},
+				IngressAPIExtensionsV1beta1: {"ingresses"},
+			})
+			Expect(cluster.IngressAPIVersion()).To(Equal(IngressAPINetworkingV1))
+		})
+
+		It("falls back to the older versions", func() {
+			cluster := ingressCluster(map[string][]string{
+				IngressAPINetworkingV1:      {"ingressclasses"},
+				IngressAPIExtensionsV1beta1: {"ingresses"},
+			})
+			Expect(cluster.IngressAPIVersion()).To(Equal(IngressAPIExtensionsV1beta1))
+		})
+
+		It("fails when no known version is served", func() {
+			cluster := ingressCluster(nil)
+			_, err := cluster.IngressAPIVersion()
+			Expect(err).To(MatchError("the cluster does not serve any known Ingress API version"))
+		})
+	})
+
+	Describe("IngressClasses", func() {
+		It("lists the classes with the default one", func() {
+			cluster := ingressCluster(map[string][]string{IngressAPINetworkingV1: {"ingresses", "ingressclasses"}},
+				&networkingv1.IngressClass{ObjectMeta: metav1.ObjectMeta{Name: "nginx"}},
+				&networkingv1.IngressClass{ObjectMeta: metav1.ObjectMeta{
+					Name:        "traefik",
+					Annotations: map[string]string{"ingressclass.kubernetes.io/is-default-class": "true"},
+				}},
+			)
+			classes, defaultClass, err := cluster.IngressClasses(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(classes).To(ConsistOf("nginx", "traefik"))
+			Expect(defaultClass).To(Equal("traefik"))
+		})
+
+		It("lists the v1beta1 classes", func() {
+			cluster := ingressCluster(map[string][]string{IngressAPINetworkingV1beta1: {"ingresses", "ingressclasses"}},
+				&networkingv1beta1.IngressClass{ObjectMeta: metav1.ObjectMeta{Name: "nginx"}},
+			)
+			classes, defaultClass, err := cluster.IngressClasses(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(classes).To(ConsistOf("nginx"))
+			Expect(defaultClass).To(BeEmpty())
+		})
+
+		It("returns no classes for clusters without IngressClass API", func() {
+			cluster := ingressCluster(map[string][]string{IngressAPIExtensionsV1beta1: {"ingresses"}})
+			classes, defaultClass, err := cluster.IngressClasses(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(classes).To(BeEmpty())
+			Expect(defaultClass).To(BeEmpty())
+		})
+	})
+
+	Describe("ListIngress", func() {
+		It("lists the hosts of all the rules", func() {
+			class := "nginx"
+			cluster := ingressCluster(map[string][]string{IngressAPINetworkingV1: {"ingresses"}},
+				&networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{Name: "gitea", Namespace: "gitea", Labels: map[string]string{"app": "gitea"}},
+					Spec: networkingv1.IngressSpec{
+						IngressClassName: &class,
+						Rules: []networkingv1.IngressRule{
+							ingressRule("gitea.example.com", "gitea-http"),
+							ingressRule("git.example.com", "gitea-http"),
+						},
+						TLS: []networkingv1.IngressTLS{{SecretName: "gitea-tls"}},
+					},
+				},
+			)
+			ingresses, err := cluster.ListIngress(ctx, "gitea", "app=gitea")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ingresses).To(HaveLen(1))
+			Expect(ingresses[0].Host).To(Equal("gitea.example.com"))
+			Expect(ingresses[0].Hosts).To(Equal([]string{"gitea.example.com", "git.example.com"}))
+			Expect(ingresses[0].ServiceName).To(Equal("gitea-http"))
+			Expect(ingresses[0].ServicePort).To(Equal(80))
+			Expect(ingresses[0].ClassName).To(Equal("nginx"))
+			Expect(ingresses[0].TLSSecret).To(Equal("gitea-tls"))
+
+			routes, err := cluster.ListIngressRoutes(ctx, "gitea", "gitea")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(routes).To(Equal([]string{"gitea.example.com", "git.example.com"}))
+		})
+
+		It("lists the v1beta1 ingresses", func() {
+			cluster := ingressCluster(map[string][]string{IngressAPINetworkingV1beta1: {"ingresses"}},
+				&networkingv1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "registry",
+						Namespace:   "fuseml-core",
+						Annotations: map[string]string{"kubernetes.io/ingress.class": "traefik"},
+					},
+					Spec: networkingv1beta1.IngressSpec{
+						Rules: []networkingv1beta1.IngressRule{
+							{Host: "registry.example.com", IngressRuleValue: networkingv1beta1.IngressRuleValue{
+								HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+									Paths: []networkingv1beta1.HTTPIngressPath{{
+										Backend: networkingv1beta1.IngressBackend{ServiceName: "registry", ServicePort: intstr.FromInt(5000)},
+									}},
+								},
+							}},
+							{Host: "registry.internal"},
+						},
+					},
+				},
+			)
+			ingresses, err := cluster.ListIngress(ctx, "fuseml-core", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ingresses).To(HaveLen(1))
+			Expect(ingresses[0].Hosts).To(Equal([]string{"registry.example.com", "registry.internal"}))
+			Expect(ingresses[0].ServiceName).To(Equal("registry"))
+			Expect(ingresses[0].ServicePort).To(Equal(5000))
+			Expect(ingresses[0].ClassName).To(Equal("traefik"))
+		})
+	})
+})
@@ -9,16 +9,42 @@ import (
 	"strings"
 )
 
+// Choice is one of the values offered to the user for a ListType option
+type Choice struct {
+	Value       string
+	Description string
+	Requires    []string
+}
+
+// ChoicesFunc returns the values offered for an option. It gets the options read
+// so far, so the choices may depend on the previous answers.
+type ChoicesFunc func(answered InstallationOptions) ([]Choice, error)
+
 type InteractiveOptionsReader struct {
-	in  io.Reader
+	in  *bufio.Reader
 	out io.Writer
+
+	choices  map[string]ChoicesFunc
+	answered *InstallationOptions
 }
 
 // NewInteractiveOptionsReader is the default reader used by the Installer
 // when one is not defined. It asks the user questions on stdout and gets
 // answers on stdin.
 func NewInteractiveOptionsReader(stdout io.Writer, stdin io.Reader) InteractiveOptionsReader {
-	return InteractiveOptionsReader{in: stdin, out: stdout}
+	return InteractiveOptionsReader{
+		in:       bufio.NewReader(stdin),
+		out:      stdout,
+		choices:  map[string]ChoicesFunc{},
+		answered: &InstallationOptions{},
+	}
+}
+
+// WithChoices makes the reader offer a multi-select of the values returned by the function
+// for the ListType option of given name. When the function fails, any values may be entered.
+func (reader InteractiveOptionsReader) WithChoices(optionName string, choices ChoicesFunc) InteractiveOptionsReader {
+	reader.choices[optionName] = choices
+	return reader
 }
 
 // Read asks the user what value should the given InstallationOption have and
@@ -31,12 +57,14 @@ func (reader InteractiveOptionsReader) Read(option *InstallationOption) error {
 	case BooleanType:
 	case StringType:
 	case IntType:
+	case ListType:
 	default:
 		return errors.New("Internal error: option Type not supported")
 	}
 
 	// Ignore anything which is already set by the user (cli option or similar).
 	if option.UserSpecified {
+		*reader.answered = append(*reader.answered, *option)
 		return nil
 	}
 
@@ -47,25 +75,47 @@ func (reader InteractiveOptionsReader) Read(option *InstallationOption) error {
 		deployment = string(option.DeploymentID)
 	}
 
-	possibleOptions := ""
-	if option.Type == BooleanType {
-		possibleOptions = " (y/n)"
-	}
-
 	// Prefill with a default the user may accept (**)
 	err := option.SetDefault()
 	if err != nil {
 		return err
 	}
 
+	// Ask until the value passes the validators
+	for {
+		if option.Type == ListType {
+			err = reader.readList(deployment, option)
+		} else {
+			err = reader.readValue(deployment, option)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = option.Validate(*reader.answered)
+		if err == nil {
+			break
+		}
+		fmt.Fprintf(reader.out, "%s, please try again\n", err)
+		if err := option.SetDefault(); err != nil {
+			return err
+		}
+	}
+	*reader.answered = append(*reader.answered, *option)
+	return nil
+}
+
+func (reader InteractiveOptionsReader) readValue(deployment string, option *InstallationOption) error {
+	possibleOptions := ""
+	if option.Type == BooleanType {
+		possibleOptions = " (y/n)"
+	}
+
 	prompt := fmt.Sprintf("[%s] %s %s%s [%v]: ", deployment, option.Name, option.Description, possibleOptions, option.Value)
-	reader.out.Write([]byte(prompt))
-	bufReader := bufio.NewReader(reader.in)
-	userValue, err := bufReader.ReadString('\n')
+	userValue, err := reader.Ask(prompt)
 	if err != nil {
 		return err
 	}
-	userValue = strings.TrimSpace(userValue)
 
 	if userValue == "" {
 		// Keep the default set by (**). And claim it as
@@ -74,15 +124,6 @@ func (reader InteractiveOptionsReader) Read(option *InstallationOption) error {
 		return nil
 	}
 
-	// TODO (post MVP): String and integer types should be
-	// extended to call an option-specific validation function, if
-	// present, which would perform additional checks on the
-	// user's value. For example range limits, proper syntax of
-	// the string, etc. They would then loop similar to the entry
-	// for booleans. The loop could then actually move outside of
-	// the switch, and boolean validation would use a standard
-	// validation function.
-
 	switch option.Type {
 	case BooleanType:
 		for {
@@ -96,12 +137,10 @@ func (reader InteractiveOptionsReader) Read(option *InstallationOption) error {
 				return nil
 			}
 
-			reader.out.Write([]byte("It's either 'y' or 'n', please try again"))
-			userValue, err = bufReader.ReadString('\n')
+			userValue, err = reader.Ask("It's either 'y' or 'n', please try again")
 			if err != nil {
 				return err
 			}
-			userValue = strings.TrimSpace(userValue)
 		}
 	case StringType:
 		option.Value = userValue
@@ -116,14 +155,174 @@ func (reader InteractiveOptionsReader) Read(option *InstallationOption) error {
 				return nil
 			}
 
-			reader.out.Write([]byte("Please provide an integer value"))
-			userValue, err = bufReader.ReadString('\n')
+			userValue, err = reader.Ask("Please provide an integer value")
 			if err != nil {
 				return err
 			}
-			userValue = strings.TrimSpace(userValue)
 		}
 	default:
 		return errors.New("Internal error: option Type not supported")
 	}
 }
+
+// readList edits the list: the entered values (separated by commas) replace the current ones,
+// values prefixed with + or - are added to or removed from the current ones, "none" clears it.
+// With choices, they are listed and can be selected by their numbers too.
+func (reader InteractiveOptionsReader) readList(deployment string, option *InstallationOption) error {
+	current, _ := option.Value.([]string)
+	if current == nil {
+		current = []string{}
+	}
+
+	var choices []Choice
+	if choicesFunc, ok := reader.choices[option.Name]; ok {
+		var err error
+		choices, err = choicesFunc(*reader.answered)
+		if err != nil {
+			fmt.Fprintf(reader.out, "Could not list the values of %s: %s\n", option.Name, err)
+		}
+	}
+
+	fmt.Fprintf(reader.out, "[%s] %s %s\n", deployment, option.Name, option.Description)
+	for i, choice := range choices {
+		line := fmt.Sprintf("  %2d) %s", i+1, choice.Value)
+		if choice.Description != "" {
+			line += " - " + choice.Description
+		}
+		if len(choice.Requires) > 0 {
+			line += fmt.Sprintf(" (requires %s)", strings.Join(choice.Requires, ", "))
+		}
+		fmt.Fprintln(reader.out, line)
+	}
+	prompt := "Values separated by commas (+value adds, -value removes, none clears)"
+	if len(choices) > 0 {
+		prompt = "Numbers or names separated by commas (+ adds, - removes, none clears)"
+	}
+	prompt = fmt.Sprintf("%s [%s]: ", prompt, strings.Join(current, ","))
+
+	userValue, err := reader.Ask(prompt)
+	if err != nil {
+		return err
+	}
+	for {
+		values, err := editList(current, userValue, choices)
+		if err == nil {
+			option.Value = values
+			option.UserSpecified = true
+			return nil
+		}
+
+		userValue, err = reader.Ask(fmt.Sprintf("%s, please try again: ", err))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// editList applies the user input to the current list. Numbers select the choices,
+// names have to be one of them when there are any.
+func editList(current []string, input string, choices []Choice) ([]string, error) {
+	if input == "" {
+		return current, nil
+	}
+	if input == "none" {
+		return []string{}, nil
+	}
+
+	type edit struct {
+		value  string
+		remove bool
+	}
+	edits := []edit{}
+	relative := true
+	for _, token := range strings.Split(input, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		e := edit{value: token}
+		switch token[0] {
+		case '+':
+			e.value = strings.TrimSpace(token[1:])
+		case '-':
+			e.value = strings.TrimSpace(token[1:])
+			e.remove = true
+		default:
+			relative = false
+		}
+
+		value, err := resolveChoice(e.value, choices)
+		if err != nil {
+			return nil, err
+		}
+		e.value = value
+		edits = append(edits, e)
+	}
+
+	result := []string{}
+	if relative {
+		result = append(result, current...)
+	}
+	for _, e := range edits {
+		index := -1
+		for i, value := range result {
+			if value == e.value {
+				index = i
+			}
+		}
+		if e.remove && index >= 0 {
+			result = append(result[:index], result[index+1:]...)
+		} else if !e.remove && index < 0 {
+			result = append(result, e.value)
+		}
+	}
+	return result, nil
+}
+
+func resolveChoice(value string, choices []Choice) (string, error) {
+	if len(choices) == 0 {
+		if value == "" {
+			return "", errors.New("empty value")
+		}
+		return value, nil
+	}
+	if number, err := strconv.Atoi(value); err == nil {
+		if number < 1 || number > len(choices) {
+			return "", fmt.Errorf("%d is not one of the listed numbers", number)
+		}
+		return choices[number-1].Value, nil
+	}
+	for _, choice := range choices {
+		if choice.Value == value {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("'%s' is not one of the listed values", value)
+}
+
+// Confirm asks the yes/no question, until one of them is answered
+func (reader InteractiveOptionsReader) Confirm(question string) (bool, error) {
+	answer, err := reader.Ask(question + " (y/n): ")
+	for {
+		if err != nil {
+			return false, err
+		}
+		switch answer {
+		case "y":
+			return true, nil
+		case "n":
+			return false, nil
+		}
+		answer, err = reader.Ask("It's either 'y' or 'n', please try again")
+	}
+}
+
+// Ask shows the prompt and returns the (trimmed) line entered by the user
+func (reader InteractiveOptionsReader) Ask(prompt string) (string, error) {
+	reader.out.Write([]byte(prompt))
+	userValue, err := reader.in.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(userValue), nil
+}
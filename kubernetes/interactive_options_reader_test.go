@@ -46,6 +46,10 @@ var _ = Describe("InteractiveOptionsReader", func() {
 		Type:        StringType,
 	}
 
+	BeforeEach(func() {
+		reader = NewInteractiveOptionsReader(stdout, stdin)
+	})
+
 	Describe("Read", func() {
 		It("ignores an option with a user-specified value", func() {
 			err := reader.Read(&optionSpecified)
@@ -191,6 +195,131 @@ var _ = Describe("InteractiveOptionsReader", func() {
 			})
 		})
 
+		It("asks again if the value is not valid", func() {
+			option := InstallationOption{
+				Name:       "mode",
+				Default:    "none",
+				Type:       StringType,
+				Validators: []OptionValidator{OneOf("none", "custom")},
+			}
+			stdin.Write([]byte("other\ncustom\n"))
+			err := reader.Read(&option)
+			Expect(err).ToNot(HaveOccurred())
+
+			prompt, err := ioutil.ReadAll(stdout)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(prompt)).To(ContainSubstring("mode: 'other' is not supported (use none, custom), please try again"))
+			Expect(option.Value).To(Equal("custom"))
+		})
+
+		When("the option is ListType", func() {
+			var option InstallationOption
+
+			BeforeEach(func() {
+				option = InstallationOption{
+					Name:        "Option",
+					Default:     []string{"a", "b"},
+					Description: "This is a list option",
+					Type:        ListType,
+				}
+			})
+
+			It("replaces the list with the entered values", func() {
+				stdin.Write([]byte("c, d\n"))
+				err := reader.Read(&option)
+				Expect(err).ToNot(HaveOccurred())
+
+				prompt, err := ioutil.ReadAll(stdout)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(prompt)).To(ContainSubstring("[a,b]"))
+
+				Expect(option.Value).To(Equal([]string{"c", "d"}))
+				Expect(option.UserSpecified).To(BeTrue())
+			})
+
+			It("adds and removes the values", func() {
+				stdin.Write([]byte("+c,-a\n"))
+				err := reader.Read(&option)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(option.Value).To(Equal([]string{"b", "c"}))
+			})
+
+			It("clears the list", func() {
+				stdin.Write([]byte("none\n"))
+				err := reader.Read(&option)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(option.Value).To(Equal([]string{}))
+			})
+
+			It("keeps the default when no value is entered by the user", func() {
+				stdin.Write([]byte("\n"))
+				err := reader.Read(&option)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(option.Value).To(Equal([]string{"a", "b"}))
+				Expect(option.UserSpecified).To(BeTrue())
+			})
+		})
+
+		When("the option has choices", func() {
+			var (
+				option   InstallationOption
+				answered InstallationOptions
+			)
+
+			BeforeEach(func() {
+				option = InstallationOption{
+					Name:        "extensions",
+					Default:     []string{},
+					Description: "Extensions to install",
+					Type:        ListType,
+				}
+				reader = NewInteractiveOptionsReader(stdout, stdin).WithChoices("extensions",
+					func(opts InstallationOptions) ([]Choice, error) {
+						answered = opts
+						return []Choice{
+							{Value: "minio", Description: "S3 storage"},
+							{Value: "mlflow", Description: "Experiment tracking", Requires: []string{"minio"}},
+						}, nil
+					})
+			})
+
+			It("lists the choices with their descriptions and requirements", func() {
+				stdin.Write([]byte("2\n"))
+				err := reader.Read(&option)
+				Expect(err).ToNot(HaveOccurred())
+
+				prompt, err := ioutil.ReadAll(stdout)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(prompt)).To(ContainSubstring("1) minio - S3 storage\n"))
+				Expect(string(prompt)).To(ContainSubstring("2) mlflow - Experiment tracking (requires minio)\n"))
+
+				Expect(option.Value).To(Equal([]string{"mlflow"}))
+			})
+
+			It("gets the options read before", func() {
+				previous := InstallationOption{Name: "repository", Type: StringType, Value: "/repo", UserSpecified: true}
+				Expect(reader.Read(&previous)).To(Succeed())
+
+				stdin.Write([]byte("mlflow\n"))
+				Expect(reader.Read(&option)).To(Succeed())
+				Expect(answered).To(HaveLen(1))
+				Expect(answered[0].Value).To(Equal("/repo"))
+			})
+
+			It("asks again if the value is not one of the choices", func() {
+				stdin.Write([]byte("3\nknative\nminio\n"))
+				err := reader.Read(&option)
+				Expect(err).ToNot(HaveOccurred())
+
+				prompt, err := ioutil.ReadAll(stdout)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(prompt)).To(ContainSubstring("3 is not one of the listed numbers, please try again"))
+				Expect(string(prompt)).To(ContainSubstring("'knative' is not one of the listed values, please try again"))
+
+				Expect(option.Value).To(Equal([]string{"minio"}))
+			})
+		})
+
 		When("the option is bogus", func() {
 			var option InstallationOption
 
@@ -211,3 +340,17 @@ var _ = Describe("InteractiveOptionsReader", func() {
 		})
 	})
 })
+
+var _ = Describe("InteractiveOptionsReader", func() {
+	Describe("Confirm", func() {
+		It("asks until the answer is 'y' or 'n'", func() {
+			stdout := &bytes.Buffer{}
+			reader := NewInteractiveOptionsReader(stdout, bytes.NewBufferString("yes\nn\n"))
+
+			confirmed, err := reader.Confirm("Continue?")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(confirmed).To(BeFalse())
+			Expect(stdout.String()).To(ContainSubstring("It's either 'y' or 'n', please try again"))
+		})
+	})
+})
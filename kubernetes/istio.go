@@ -0,0 +1,69 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IstioInstallation describes Istio found in the cluster
+type IstioInstallation struct {
+	// Namespace of the control plane
+	Namespace string
+	Revision  string
+	// Name and namespace of the ingress gateway service
+	GatewayName      string
+	GatewayNamespace string
+	// Labels of the ingress gateway pods, used as a selector by Gateway resources
+	GatewaySelector map[string]string
+}
+
+// DetectIstio looks for the Istio control plane and ingress gateway in all namespaces.
+// Nil is returned when Istio is not installed.
+func (c *Cluster) DetectIstio(ctx context.Context) (*IstioInstallation, error) {
+	deployments, err := c.Kubectl.AppsV1().Deployments("").List(ctx, metav1.ListOptions{
+		LabelSelector: "app=istiod",
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list istiod deployments")
+	}
+	if len(deployments.Items) == 0 {
+		return nil, nil
+	}
+
+	istiod := deployments.Items[0]
+	installation := &IstioInstallation{
+		Namespace: istiod.Namespace,
+		Revision:  istiod.Labels["istio.io/rev"],
+	}
+	if installation.Revision == "" {
+		installation.Revision = "default"
+	}
+
+	services, err := c.Kubectl.CoreV1().Services("").List(ctx, metav1.ListOptions{
+		LabelSelector: "istio=ingressgateway",
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list istio ingress gateways")
+	}
+	if len(services.Items) == 0 {
+		return installation, nil
+	}
+
+	// prefer the gateway reachable from outside of the cluster
+	gateway := services.Items[0]
+	for _, service := range services.Items {
+		if service.Spec.Type == v1.ServiceTypeLoadBalancer {
+			gateway = service
+			break
+		}
+	}
+	installation.GatewayName = gateway.Name
+	installation.GatewayNamespace = gateway.Namespace
+	installation.GatewaySelector = gateway.Spec.Selector
+
+	return installation, nil
+}
@@ -0,0 +1,28 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetJSONPathValue reads the value at the JSONPath (e.g. {.status.loadBalancer.ingress[0].ip}) of the object
+// of any kind, given in the form accepted by kubectl. The resources are discovered again once when the kind is unknown.
+func (c *Cluster) GetJSONPathValue(ctx context.Context, kind, namespace, name, path string) (string, error) {
+	if c.Dynamic == nil || c.Mapper == nil {
+		return "", errors.New("the dynamic client is not initialized")
+	}
+	gvr, namespaced, err := c.resourceFor(ctx, kind, 0)
+	if err != nil {
+		return "", err
+	}
+	if !namespaced {
+		namespace = ""
+	}
+	obj, err := c.Dynamic.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get %s %s", kind, name)
+	}
+	return jsonPathValue(obj, path)
+}
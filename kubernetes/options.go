@@ -48,6 +48,7 @@ type InstallationOption struct {
 	Description    string                           // Short description of the variable
 	Type           InstallationOptionType           // Type information for `Value` and `Default`.
 	DeploymentID   string                           // If set, this option will be passed only to this deployment (private)
+	Validators     []OptionValidator                // Checks of the value, run by `Validate`.
 }
 
 type InstallationOptions []InstallationOption
@@ -176,7 +177,7 @@ func (opts InstallationOptions) GetString(optionName string, deploymentID string
 
 	result, ok := option.Value.(string)
 	if !ok {
-		panic("wrong type assertion")
+		return "", errors.New(fmt.Sprintf("value of %s is not a string", optionName))
 	}
 
 	return result, nil
@@ -190,7 +191,7 @@ func (opts InstallationOptions) GetBool(optionName string, deploymentID string)
 
 	result, ok := option.Value.(bool)
 	if !ok {
-		panic("wrong type assertion")
+		return false, errors.New(fmt.Sprintf("value of %s is not a boolean", optionName))
 	}
 
 	return result, nil
@@ -204,7 +205,7 @@ func (opts InstallationOptions) GetInt(optionName string, deploymentID string) (
 
 	result, ok := option.Value.(int)
 	if !ok {
-		panic("wrong type assertion")
+		return 0, errors.New(fmt.Sprintf("value of %s is not an integer", optionName))
 	}
 
 	return result, nil
@@ -273,9 +273,9 @@ var _ = Describe("InstallationOptions", func() {
 					},
 				}
 			})
-			It("panics", func() {
-				Expect(func() { options.GetString("Option", "") }).
-					To(PanicWith(MatchRegexp("wrong type assertion")))
+			It("returns an error", func() {
+				_, err := options.GetString("Option", "")
+				Expect(err).To(MatchError("value of Option is not a string"))
 			})
 		})
 
@@ -320,9 +320,9 @@ var _ = Describe("InstallationOptions", func() {
 					},
 				}
 			})
-			It("panics", func() {
-				Expect(func() { options.GetInt("Option", "") }).
-					To(PanicWith(MatchRegexp("wrong type assertion")))
+			It("returns an error", func() {
+				_, err := options.GetInt("Option", "")
+				Expect(err).To(MatchError("value of Option is not an integer"))
 			})
 		})
 
@@ -367,9 +367,9 @@ var _ = Describe("InstallationOptions", func() {
 					},
 				}
 			})
-			It("panics", func() {
-				Expect(func() { options.GetBool("Option", "") }).
-					To(PanicWith(MatchRegexp("wrong type assertion")))
+			It("returns an error", func() {
+				_, err := options.GetBool("Option", "")
+				Expect(err).To(MatchError("value of Option is not a boolean"))
 			})
 		})
 
@@ -0,0 +1,111 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// FusemlComponentLabelKey marks the resources created by the installer with the ID of the owning component
+	FusemlComponentLabelKey = fmt.Sprintf("%s/%s", APISGroupName, "component")
+	// FusemlExtensionLabelKey marks the resources created by the installer with the name of the owning extension
+	FusemlExtensionLabelKey = fmt.Sprintf("%s/%s", APISGroupName, "extension")
+)
+
+// Owner is the component or the extension owning the resources created by the installer
+type Owner struct {
+	LabelKey string
+	Name     string
+}
+
+// ComponentOwner returns the owner for the component with the given deployment ID
+func ComponentOwner(id string) Owner {
+	return Owner{LabelKey: FusemlComponentLabelKey, Name: id}
+}
+
+// ExtensionOwner returns the owner for the extension with the given name
+func ExtensionOwner(name string) Owner {
+	return Owner{LabelKey: FusemlExtensionLabelKey, Name: name}
+}
+
+// IsExtension is true for the extension owners
+func (o Owner) IsExtension() bool {
+	return o.LabelKey == FusemlExtensionLabelKey
+}
+
+// Label returns the label marking the resources of the owner, in the key=value form
+func (o Owner) Label() string {
+	return fmt.Sprintf("%s=%s", o.LabelKey, o.Name)
+}
+
+// Labels returns the labels set on the resources of the owner when they are created
+func (o Owner) Labels() map[string]string {
+	return map[string]string{o.LabelKey: o.Name}
+}
+
+func (o Owner) String() string {
+	if o.IsExtension() {
+		return "extension " + o.Name
+	}
+	return "component " + o.Name
+}
+
+// OwnedResource is the resource labeled with its owner
+type OwnedResource struct {
+	// Resource is the type of the resource in the form accepted by kubectl, e.g. clusterrole.rbac.authorization.k8s.io
+	Resource  string
+	Namespace string
+	Name      string
+	Owner     Owner
+}
+
+func (r OwnedResource) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Resource, r.Name)
+	}
+	return fmt.Sprintf("%s/%s (namespace %s)", r.Resource, r.Name, r.Namespace)
+}
+
+type resourceList struct {
+	Items []struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Metadata   struct {
+			Name      string            `json:"name"`
+			Namespace string            `json:"namespace"`
+			Labels    map[string]string `json:"labels"`
+		} `json:"metadata"`
+	} `json:"items"`
+}
+
+// ParseOwnedResources reads the resources labeled with an owner from the list printed by
+// `kubectl get -o json`. The resources without the owner labels are skipped.
+func ParseOwnedResources(data []byte) ([]OwnedResource, error) {
+	list := resourceList{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the list of resources")
+	}
+
+	resources := []OwnedResource{}
+	for _, item := range list.Items {
+		resource := strings.ToLower(item.Kind)
+		if i := strings.Index(item.APIVersion, "/"); i > 0 {
+			resource = resource + "." + item.APIVersion[:i]
+		}
+		for _, key := range []string{FusemlComponentLabelKey, FusemlExtensionLabelKey} {
+			if name, ok := item.Metadata.Labels[key]; ok && name != "" {
+				resources = append(resources, OwnedResource{
+					Resource:  resource,
+					Namespace: item.Metadata.Namespace,
+					Name:      item.Metadata.Name,
+					Owner:     Owner{LabelKey: key, Name: name},
+				})
+				break
+			}
+		}
+	}
+	return resources, nil
+}
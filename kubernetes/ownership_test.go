@@ -0,0 +1,51 @@
+package kubernetes_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/kubernetes"
+)
+
+var _ = Describe("Ownership", func() {
+	It("labels the resources of the components and extensions", func() {
+		Expect(ComponentOwner("tekton-pipelines").Label()).To(Equal("fuse.ml/component=tekton-pipelines"))
+		Expect(ExtensionOwner("mlflow").Label()).To(Equal("fuse.ml/extension=mlflow"))
+		Expect(ExtensionOwner("mlflow").String()).To(Equal("extension mlflow"))
+	})
+
+	It("parses the owned resources listed by kubectl", func() {
+		resources, err := ParseOwnedResources([]byte(`{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "apiVersion": "rbac.authorization.k8s.io/v1",
+      "kind": "ClusterRole",
+      "metadata": {"name": "tekton-operator", "labels": {"fuse.ml/component": "tekton-pipelines"}}
+    },
+    {
+      "apiVersion": "v1",
+      "kind": "ConfigMap",
+      "metadata": {"name": "mlflow-config", "namespace": "fuseml-workloads", "labels": {"fuse.ml/extension": "mlflow"}}
+    },
+    {
+      "apiVersion": "v1",
+      "kind": "Secret",
+      "metadata": {"name": "unrelated", "namespace": "default"}
+    }
+  ]
+}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(Equal([]OwnedResource{
+			{Resource: "clusterrole.rbac.authorization.k8s.io", Name: "tekton-operator", Owner: ComponentOwner("tekton-pipelines")},
+			{Resource: "configmap", Namespace: "fuseml-workloads", Name: "mlflow-config", Owner: ExtensionOwner("mlflow")},
+		}))
+		Expect(resources[1].String()).To(Equal("configmap/mlflow-config (namespace fuseml-workloads)"))
+	})
+
+	It("fails on invalid list", func() {
+		_, err := ParseOwnedResources([]byte("error: the server doesn't have a resource type"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,37 @@
+package aks
+
+import (
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/kubernetes/platform/generic"
+	"github.com/kyokomi/emoji"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type aks struct {
+	generic.Generic
+}
+
+func (k *aks) Describe() string {
+	return emoji.Sprintf(":anchor:Detected kubernetes platform: %s\n:earth_americas:ExternalIPs: %s\n:curly_loop:InternalIPs: %s", k.String(), k.ExternalIPs(), k.InternalIPs)
+}
+
+func (k *aks) String() string { return "aks" }
+
+func (k *aks) Detect(kube kubernetes.Interface) bool {
+	return generic.AnyNode(kube, func(n v1.Node) bool {
+		if _, found := n.Labels["kubernetes.azure.com/cluster"]; found {
+			return true
+		}
+		return strings.HasPrefix(n.Spec.ProviderID, "azure://")
+	})
+}
+
+func (k *aks) ExternalIPs() []string {
+	return k.Generic.ExternalIP
+}
+
+func NewPlatform() *aks {
+	return &aks{}
+}
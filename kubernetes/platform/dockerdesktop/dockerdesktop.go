@@ -0,0 +1,33 @@
+package dockerdesktop
+
+import (
+	"github.com/fuseml/fuseml/cli/kubernetes/platform/generic"
+	"github.com/kyokomi/emoji"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Docker Desktop publishes LoadBalancer services on localhost of the host machine
+type dockerDesktop struct {
+	generic.Generic
+}
+
+func (k *dockerDesktop) Describe() string {
+	return emoji.Sprintf(":anchor:Detected kubernetes platform: %s\n:earth_americas:ExternalIPs: %s\n:curly_loop:InternalIPs: %s", k.String(), k.ExternalIPs(), k.InternalIPs)
+}
+
+func (k *dockerDesktop) String() string { return "docker-desktop" }
+
+func (k *dockerDesktop) Detect(kube kubernetes.Interface) bool {
+	return generic.AnyNode(kube, func(n v1.Node) bool {
+		return n.Name == "docker-desktop"
+	})
+}
+
+func (k *dockerDesktop) ExternalIPs() []string {
+	return []string{"127.0.0.1"}
+}
+
+func NewPlatform() *dockerDesktop {
+	return &dockerDesktop{}
+}
@@ -0,0 +1,39 @@
+package eks
+
+import (
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/kubernetes/platform/generic"
+	"github.com/kyokomi/emoji"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Amazon EKS load balancers only provide host names, which are resolved by LoadBalancerIP
+// (the installer warns that the resolved address may change)
+type eks struct {
+	generic.Generic
+}
+
+func (k *eks) Describe() string {
+	return emoji.Sprintf(":anchor:Detected kubernetes platform: %s\n:earth_americas:ExternalIPs: %s\n:curly_loop:InternalIPs: %s", k.String(), k.ExternalIPs(), k.InternalIPs)
+}
+
+func (k *eks) String() string { return "eks" }
+
+func (k *eks) Detect(kube kubernetes.Interface) bool {
+	return generic.AnyNode(kube, func(n v1.Node) bool {
+		if _, found := n.Labels["eks.amazonaws.com/nodegroup"]; found {
+			return true
+		}
+		return strings.Contains(n.Status.NodeInfo.KubeletVersion, "-eks-")
+	})
+}
+
+func (k *eks) ExternalIPs() []string {
+	return k.Generic.ExternalIP
+}
+
+func NewPlatform() *eks {
+	return &eks{}
+}
@@ -2,14 +2,26 @@ package generic
 
 import (
 	"context"
+	"net"
 
 	"github.com/kyokomi/emoji"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
+const (
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// LookupIP resolves host names of LoadBalancer ingresses (replaceable in tests)
+var LookupIP = net.LookupIP
+
 type Generic struct {
 	InternalIPs, ExternalIP []string
+	StorageClass            string
 }
 
 func (k *Generic) Describe() string {
@@ -18,11 +30,11 @@ func (k *Generic) Describe() string {
 
 func (k *Generic) String() string { return "generic" }
 
-func (k *Generic) Detect(kube *kubernetes.Clientset) bool {
+func (k *Generic) Detect(kube kubernetes.Interface) bool {
 	return false
 }
 
-func (k *Generic) Load(kube *kubernetes.Clientset) error {
+func (k *Generic) Load(kube kubernetes.Interface) error {
 	nodes, err := kube.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
 	if err != nil {
 		return err
@@ -43,6 +55,18 @@ func (k *Generic) Load(kube *kubernetes.Clientset) error {
 	k.InternalIPs = internalIPs
 	k.ExternalIP = externalIPs
 
+	// Missing permissions for listing storage classes are not fatal, only the default one is unknown
+	k.StorageClass = ""
+	classes, err := kube.StorageV1().StorageClasses().List(context.Background(), metav1.ListOptions{})
+	if err == nil {
+		for _, class := range classes.Items {
+			if class.Annotations[defaultStorageClassAnnotation] == "true" || class.Annotations[betaDefaultStorageClassAnnotation] == "true" {
+				k.StorageClass = class.Name
+				break
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -50,6 +74,48 @@ func (k *Generic) ExternalIPs() []string {
 	return k.ExternalIP
 }
 
+func (k *Generic) DefaultStorageClass() string {
+	return k.StorageClass
+}
+
+// LoadBalancerIP returns the IP of the ingress, resolving its host name if there is no IP
+// (for example AWS load balancers only provide the host name)
+func (k *Generic) LoadBalancerIP(ingress v1.LoadBalancerIngress) (string, error) {
+	if ingress.IP != "" {
+		return ingress.IP, nil
+	}
+	if ingress.Hostname == "" {
+		return "", errors.New("load balancer ingress has neither IP nor hostname")
+	}
+	ips, err := LookupIP(ingress.Hostname)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve load balancer hostname %s", ingress.Hostname)
+	}
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip.String(), nil
+		}
+	}
+	if len(ips) == 0 {
+		return "", errors.Errorf("load balancer hostname %s does not resolve to any IP", ingress.Hostname)
+	}
+	return ips[0].String(), nil
+}
+
+// AnyNode returns true when the match function returns true for at least one of the cluster nodes
+func AnyNode(kube kubernetes.Interface, match func(v1.Node) bool) bool {
+	nodes, err := kube.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return false
+	}
+	for _, n := range nodes.Items {
+		if match(n) {
+			return true
+		}
+	}
+	return false
+}
+
 func NewPlatform() *Generic {
 	return &Generic{}
 }
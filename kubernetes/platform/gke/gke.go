@@ -0,0 +1,37 @@
+package gke
+
+import (
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/kubernetes/platform/generic"
+	"github.com/kyokomi/emoji"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type gke struct {
+	generic.Generic
+}
+
+func (k *gke) Describe() string {
+	return emoji.Sprintf(":anchor:Detected kubernetes platform: %s\n:earth_americas:ExternalIPs: %s\n:curly_loop:InternalIPs: %s", k.String(), k.ExternalIPs(), k.InternalIPs)
+}
+
+func (k *gke) String() string { return "gke" }
+
+func (k *gke) Detect(kube kubernetes.Interface) bool {
+	return generic.AnyNode(kube, func(n v1.Node) bool {
+		if _, found := n.Labels["cloud.google.com/gke-nodepool"]; found {
+			return true
+		}
+		return strings.Contains(n.Status.NodeInfo.KubeletVersion, "-gke.")
+	})
+}
+
+func (k *gke) ExternalIPs() []string {
+	return k.Generic.ExternalIP
+}
+
+func NewPlatform() *gke {
+	return &gke{}
+}
@@ -20,7 +20,7 @@ func (k *ibm) Describe() string {
 
 func (k *ibm) String() string { return "ibm" }
 
-func (k *ibm) Detect(kube *kubernetes.Clientset) bool {
+func (k *ibm) Detect(kube kubernetes.Interface) bool {
 	nodes, err := kube.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
 	if err != nil {
 		return false
@@ -21,7 +21,7 @@ func (k *kind) Describe() string {
 
 func (k *kind) String() string { return "kind" }
 
-func (k *kind) Detect(kube *kubernetes.Clientset) bool {
+func (k *kind) Detect(kube kubernetes.Interface) bool {
 	nodes, err := kube.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return false
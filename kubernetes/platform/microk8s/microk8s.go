@@ -0,0 +1,32 @@
+package microk8s
+
+import (
+	"github.com/fuseml/fuseml/cli/kubernetes/platform/generic"
+	"github.com/kyokomi/emoji"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type microk8s struct {
+	generic.Generic
+}
+
+func (k *microk8s) Describe() string {
+	return emoji.Sprintf(":anchor:Detected kubernetes platform: %s\n:earth_americas:ExternalIPs: %s\n:curly_loop:InternalIPs: %s", k.String(), k.ExternalIPs(), k.InternalIPs)
+}
+
+func (k *microk8s) String() string { return "microk8s" }
+
+func (k *microk8s) Detect(kube kubernetes.Interface) bool {
+	return generic.AnyNode(kube, func(n v1.Node) bool {
+		return n.Labels["microk8s.io/cluster"] == "true"
+	})
+}
+
+func (k *microk8s) ExternalIPs() []string {
+	return k.Generic.InternalIPs
+}
+
+func NewPlatform() *microk8s {
+	return &microk8s{}
+}
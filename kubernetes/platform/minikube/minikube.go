@@ -23,7 +23,7 @@ func (m *Minikube) Describe() string {
 func (m *Minikube) String() string { return "minikube" }
 
 // Detect detects if it is a minikube platform.
-func (m *Minikube) Detect(kube *kubernetes.Clientset) bool {
+func (m *Minikube) Detect(kube kubernetes.Interface) bool {
 	nodes, err := kube.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return false
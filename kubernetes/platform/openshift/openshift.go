@@ -0,0 +1,32 @@
+package openshift
+
+import (
+	"github.com/fuseml/fuseml/cli/kubernetes/platform/generic"
+	"github.com/kyokomi/emoji"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// OpenShift is detected by its API groups, it is otherwise handled as the generic platform
+type openshift struct {
+	generic.Generic
+}
+
+func (k *openshift) Describe() string {
+	return emoji.Sprintf(":anchor:Detected kubernetes platform: %s\n:earth_americas:ExternalIPs: %s\n:curly_loop:InternalIPs: %s", k.String(), k.ExternalIPs(), k.InternalIPs)
+}
+
+func (k *openshift) String() string { return "openshift" }
+
+func (k *openshift) Detect(kube kubernetes.Interface) bool {
+	_, err := kube.Discovery().ServerResourcesForGroupVersion("route.openshift.io/v1")
+	return err == nil
+}
+
+func (k *openshift) ExternalIPs() []string {
+	return k.Generic.ExternalIP
+}
+
+func NewPlatform() *openshift {
+	return &openshift{}
+}
@@ -0,0 +1,41 @@
+package rke2
+
+import (
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/kubernetes/platform/generic"
+	"github.com/kyokomi/emoji"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type rke2 struct {
+	generic.Generic
+}
+
+func (k *rke2) Describe() string {
+	return emoji.Sprintf(":anchor:Detected kubernetes platform: %s\n:earth_americas:ExternalIPs: %s\n:curly_loop:InternalIPs: %s", k.String(), k.ExternalIPs(), k.InternalIPs)
+}
+
+func (k *rke2) String() string { return "rke2" }
+
+func (k *rke2) Detect(kube kubernetes.Interface) bool {
+	return generic.AnyNode(kube, func(n v1.Node) bool {
+		if _, found := n.Annotations["rke2.io/node-args"]; found {
+			return true
+		}
+		return strings.Contains(n.Status.NodeInfo.KubeletVersion, "+rke2")
+	})
+}
+
+// Nodes of on-premise clusters do not have to have external IPs
+func (k *rke2) ExternalIPs() []string {
+	if len(k.Generic.ExternalIP) > 0 {
+		return k.Generic.ExternalIP
+	}
+	return k.Generic.InternalIPs
+}
+
+func NewPlatform() *rke2 {
+	return &rke2{}
+}
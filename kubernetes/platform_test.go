@@ -0,0 +1,123 @@
+package kubernetes_test
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/kubernetes/platform/generic"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func node(name string, labels, annotations map[string]string, providerID, kubeletVersion string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: v1.NodeSpec{ProviderID: providerID},
+		Status: v1.NodeStatus{
+			NodeInfo: v1.NodeSystemInfo{KubeletVersion: kubeletVersion},
+			Addresses: []v1.NodeAddress{
+				{Type: v1.NodeInternalIP, Address: "10.0.0.2"},
+				{Type: v1.NodeExternalIP, Address: "1.2.3.4"},
+			},
+		},
+	}
+}
+
+var _ = Describe("DetectPlatform", func() {
+	DescribeTable("detects the platform from the nodes",
+		func(n *v1.Node, expected string) {
+			platform := DetectPlatform(fake.NewSimpleClientset(n))
+			Expect(platform.String()).To(Equal(expected))
+		},
+		Entry("eks", node("ip-10-0-0-2", map[string]string{"eks.amazonaws.com/nodegroup": "ng-1"}, nil, "aws:///eu-west-1a/i-0123", "v1.21.2-eks-0389ca3"), "eks"),
+		Entry("gke", node("gke-pool-1", map[string]string{"cloud.google.com/gke-nodepool": "pool-1"}, nil, "gce://project/zone/gke-pool-1", "v1.21.5-gke.1302"), "gke"),
+		Entry("aks", node("aks-nodepool1-0", map[string]string{"kubernetes.azure.com/cluster": "MC_rg"}, nil, "azure:///subscriptions/id", "v1.21.2"), "aks"),
+		Entry("rke2", node("server-0", nil, map[string]string{"rke2.io/node-args": "[]"}, "", "v1.21.5+rke2r2"), "rke2"),
+		Entry("microk8s", node("ubuntu", map[string]string{"microk8s.io/cluster": "true"}, nil, "", "v1.21.5-3+83e2bb7ee39726"), "microk8s"),
+		Entry("docker desktop", node("docker-desktop", nil, nil, "", "v1.21.5"), "docker-desktop"),
+		Entry("k3s", node("k3d-server-0", nil, nil, "k3s://k3d-server-0", "v1.21.5+k3s2"), "k3s"),
+		Entry("kind", node("kind-control-plane", nil, nil, "kind://docker/kind/kind-control-plane", "v1.21.1"), "kind"),
+		Entry("minikube", node("minikube", map[string]string{"minikube.k8s.io/version": "v1.23.2"}, nil, "", "v1.22.2"), "minikube"),
+		Entry("unknown", node("node-0", nil, nil, "", "v1.21.0"), "generic"),
+	)
+
+	It("detects openshift from its API groups", func() {
+		kube := fake.NewSimpleClientset(node("master-0", nil, nil, "aws:///eu-west-1a/i-0123", "v1.21.1+6438632"))
+		kube.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{{
+			GroupVersion: "route.openshift.io/v1",
+			APIResources: []metav1.APIResource{{Name: "routes", Kind: "Route"}},
+		}}
+
+		Expect(DetectPlatform(kube).String()).To(Equal("openshift"))
+	})
+})
+
+var _ = Describe("Platform", func() {
+	Describe("Load", func() {
+		It("finds the node IPs and the default storage class", func() {
+			kube := fake.NewSimpleClientset([]runtime.Object{
+				node("node-0", nil, nil, "", "v1.21.0"),
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "slow"}},
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{
+					Name:        "standard",
+					Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"},
+				}},
+			}...)
+			platform := DetectPlatform(kube)
+
+			Expect(platform.Load(kube)).To(Succeed())
+			Expect(platform.ExternalIPs()).To(Equal([]string{"1.2.3.4"}))
+			Expect(platform.DefaultStorageClass()).To(Equal("standard"))
+		})
+
+		It("uses internal IPs as external on microk8s", func() {
+			kube := fake.NewSimpleClientset(node("ubuntu", map[string]string{"microk8s.io/cluster": "true"}, nil, "", "v1.21.5"))
+			platform := DetectPlatform(kube)
+
+			Expect(platform.Load(kube)).To(Succeed())
+			Expect(platform.ExternalIPs()).To(Equal([]string{"10.0.0.2"}))
+			Expect(platform.DefaultStorageClass()).To(BeEmpty())
+		})
+	})
+
+	Describe("LoadBalancerIP", func() {
+		var lookupIP func(string) ([]net.IP, error)
+
+		BeforeEach(func() {
+			lookupIP = generic.LookupIP
+			generic.LookupIP = func(host string) ([]net.IP, error) {
+				return []net.IP{net.ParseIP("::1"), net.ParseIP("5.6.7.8")}, nil
+			}
+		})
+
+		AfterEach(func() {
+			generic.LookupIP = lookupIP
+		})
+
+		It("prefers the IP of the ingress", func() {
+			ip, err := generic.NewPlatform().LoadBalancerIP(v1.LoadBalancerIngress{IP: "1.2.3.4", Hostname: "lb.example.com"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ip).To(Equal("1.2.3.4"))
+		})
+
+		It("resolves the host name of EKS load balancers", func() {
+			kube := fake.NewSimpleClientset(node("ip-10-0-0-2", nil, nil, "aws:///eu-west-1a/i-0123", "v1.21.2-eks-0389ca3"))
+			ip, err := DetectPlatform(kube).LoadBalancerIP(v1.LoadBalancerIngress{Hostname: "a1b2.elb.amazonaws.com"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ip).To(Equal("5.6.7.8"))
+		})
+	})
+})
@@ -0,0 +1,194 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// OptionValidator checks the value of the option and returns the problem found.
+// It gets all the options (known so far), so it can implement the rules
+// involving more of them.
+type OptionValidator func(opt *InstallationOption, opts InstallationOptions) error
+
+// ValidationError lists all the problems found in the options
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid options:\n  - %s", strings.Join(e.Problems, "\n  - "))
+}
+
+var dnsNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// Validate checks that the value has the type of the option and passes its validators
+func (opt *InstallationOption) Validate(opts InstallationOptions) error {
+	var ok bool
+	switch opt.Type {
+	case BooleanType:
+		_, ok = opt.Value.(bool)
+	case StringType:
+		_, ok = opt.Value.(string)
+	case IntType:
+		_, ok = opt.Value.(int)
+	case ListType:
+		_, ok = opt.Value.([]string)
+	}
+	if !ok {
+		return errors.New(fmt.Sprintf("%s: wrong type of value %v", opt.Name, opt.Value))
+	}
+
+	for _, validator := range opt.Validators {
+		if err := validator(opt, opts); err != nil {
+			return errors.New(fmt.Sprintf("%s: %s", opt.Name, err))
+		}
+	}
+	return nil
+}
+
+// Validate checks all the options, returning the ValidationError with all the problems found
+func (opts InstallationOptions) Validate() error {
+	problems := []string{}
+	for i := range opts {
+		if err := opts[i].Validate(opts); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Matches requires the (non-empty) string value to match the pattern, described by what
+func Matches(pattern *regexp.Regexp, what string) OptionValidator {
+	return func(opt *InstallationOption, opts InstallationOptions) error {
+		value := opt.Value.(string)
+		if value != "" && !pattern.MatchString(value) {
+			return errors.New(fmt.Sprintf("'%s' is not %s", value, what))
+		}
+		return nil
+	}
+}
+
+// DNSName requires the (non-empty) string value to be a valid DNS name
+func DNSName() OptionValidator {
+	regexpValidator := Matches(dnsNamePattern, "a valid DNS name")
+	return func(opt *InstallationOption, opts InstallationOptions) error {
+		if len(opt.Value.(string)) > 253 {
+			return errors.New("DNS name can't be longer than 253 characters")
+		}
+		return regexpValidator(opt, opts)
+	}
+}
+
+// OneOf requires the string value to be one of the values
+func OneOf(values ...string) OptionValidator {
+	return func(opt *InstallationOption, opts InstallationOptions) error {
+		value := opt.Value.(string)
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		valid := []string{}
+		for _, v := range values {
+			if v != "" {
+				valid = append(valid, v)
+			}
+		}
+		return errors.New(fmt.Sprintf("'%s' is not supported (use %s)", value, strings.Join(valid, ", ")))
+	}
+}
+
+// SubsetOf requires all values of the list to be some of the values
+func SubsetOf(values ...string) OptionValidator {
+	return func(opt *InstallationOption, opts InstallationOptions) error {
+		for _, value := range opt.Value.([]string) {
+			if !contains(values, value) {
+				return errors.New(fmt.Sprintf("'%s' is not supported (use %s)", value, strings.Join(values, ", ")))
+			}
+		}
+		return nil
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// InRange requires the integer value to be between min and max (inclusive)
+func InRange(min, max int) OptionValidator {
+	return func(opt *InstallationOption, opts InstallationOptions) error {
+		value := opt.Value.(int)
+		if value < min || value > max {
+			return errors.New(fmt.Sprintf("%d is not between %d and %d", value, min, max))
+		}
+		return nil
+	}
+}
+
+// URLOrDirectory requires the (non-empty) string value to be either http(s) URL or existing directory
+func URLOrDirectory() OptionValidator {
+	return func(opt *InstallationOption, opts InstallationOptions) error {
+		value := opt.Value.(string)
+		if value == "" {
+			return nil
+		}
+		if u, err := url.Parse(value); err == nil && u.IsAbs() && u.Host != "" {
+			if u.Scheme != "http" && u.Scheme != "https" {
+				return errors.New(fmt.Sprintf("'%s' is not http(s) URL", value))
+			}
+			return nil
+		}
+		info, err := os.Stat(value)
+		if err != nil {
+			return errors.New(fmt.Sprintf("'%s' is neither URL nor existing directory", value))
+		}
+		if !info.IsDir() {
+			return errors.New(fmt.Sprintf("'%s' is not a directory", value))
+		}
+		return nil
+	}
+}
+
+// FileExists requires the (non-empty) string value to be a path of existing file
+func FileExists() OptionValidator {
+	return func(opt *InstallationOption, opts InstallationOptions) error {
+		value := opt.Value.(string)
+		if value == "" {
+			return nil
+		}
+		info, err := os.Stat(value)
+		if err != nil {
+			return errors.New(fmt.Sprintf("file '%s' does not exist", value))
+		}
+		if info.IsDir() {
+			return errors.New(fmt.Sprintf("'%s' is a directory", value))
+		}
+		return nil
+	}
+}
+
+// RequiredWhen requires the string value to be set when the other option has given value
+func RequiredWhen(optionName, optionValue string) OptionValidator {
+	return func(opt *InstallationOption, opts InstallationOptions) error {
+		other, err := opts.GetOpt(optionName, opt.DeploymentID)
+		if err != nil || other.Value != optionValue {
+			return nil
+		}
+		if opt.Value.(string) == "" {
+			return errors.New(fmt.Sprintf("has to be provided when %s is %s", optionName, optionValue))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,128 @@
+package kubernetes_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/kubernetes"
+)
+
+var _ = Describe("Validators", func() {
+	validate := func(validator OptionValidator, value interface{}, others ...InstallationOption) error {
+		opt := InstallationOption{Name: "option", Value: value}
+		return validator(&opt, InstallationOptions(others))
+	}
+
+	DescribeTable("DNSName",
+		func(value string, valid bool) {
+			err := validate(DNSName(), value)
+			if valid {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("empty", "", true),
+		Entry("nip.io domain", "10.0.0.1.nip.io", true),
+		Entry("upper case", "FuseML.example.com", false),
+		Entry("underscore", "fuse_ml.example.com", false),
+		Entry("trailing dash", "fuseml-.example.com", false),
+		Entry("scheme", "http://example.com", false),
+	)
+
+	It("Matches describes the expected value", func() {
+		err := validate(Matches(regexp.MustCompile(`^v\d+$`), "a version"), "1.0")
+		Expect(err).To(MatchError("'1.0' is not a version"))
+	})
+
+	It("OneOf lists the supported values", func() {
+		Expect(validate(OneOf("", "a", "b"), "")).To(Succeed())
+		Expect(validate(OneOf("", "a", "b"), "c")).To(MatchError("'c' is not supported (use a, b)"))
+	})
+
+	It("SubsetOf checks each value of the list", func() {
+		Expect(validate(SubsetOf("a", "b"), []string{"b", "a"})).To(Succeed())
+		Expect(validate(SubsetOf("a", "b"), []string{"a", "c"})).To(MatchError("'c' is not supported (use a, b)"))
+	})
+
+	It("InRange checks the bounds", func() {
+		Expect(validate(InRange(1, 10), 10)).To(Succeed())
+		Expect(validate(InRange(1, 10), 0)).To(MatchError("0 is not between 1 and 10"))
+	})
+
+	Describe("paths", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "fuseml-validators")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "cert.pem"), []byte{}, 0600)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("URLOrDirectory accepts http(s) URLs and existing directories", func() {
+			Expect(validate(URLOrDirectory(), "https://raw.githubusercontent.com/fuseml/extensions/main/installer/")).To(Succeed())
+			Expect(validate(URLOrDirectory(), dir)).To(Succeed())
+			Expect(validate(URLOrDirectory(), "ftp://example.com/")).To(HaveOccurred())
+			Expect(validate(URLOrDirectory(), filepath.Join(dir, "cert.pem"))).To(HaveOccurred())
+			Expect(validate(URLOrDirectory(), filepath.Join(dir, "missing"))).To(HaveOccurred())
+		})
+
+		It("FileExists accepts existing files", func() {
+			Expect(validate(FileExists(), filepath.Join(dir, "cert.pem"))).To(Succeed())
+			Expect(validate(FileExists(), "")).To(Succeed())
+			Expect(validate(FileExists(), dir)).To(HaveOccurred())
+			Expect(validate(FileExists(), filepath.Join(dir, "missing"))).To(HaveOccurred())
+		})
+	})
+
+	It("RequiredWhen checks the other option", func() {
+		tls := func(mode string) InstallationOption {
+			return InstallationOption{Name: "tls", Type: StringType, Value: mode}
+		}
+		Expect(validate(RequiredWhen("tls", "custom"), "", tls("none"))).To(Succeed())
+		Expect(validate(RequiredWhen("tls", "custom"), "cert.pem", tls("custom"))).To(Succeed())
+		Expect(validate(RequiredWhen("tls", "custom"), "")).To(Succeed())
+		Expect(validate(RequiredWhen("tls", "custom"), "", tls("custom"))).To(MatchError("has to be provided when tls is custom"))
+	})
+})
+
+var _ = Describe("InstallationOptions", func() {
+	Describe("Validate", func() {
+		It("reports all problems at once", func() {
+			options := InstallationOptions{
+				{Name: "domain", Type: StringType, Value: "Not Valid", Validators: []OptionValidator{DNSName()}},
+				{Name: "mode", Type: StringType, Value: "custom", Validators: []OptionValidator{OneOf("none", "custom")}},
+				{Name: "cert", Type: StringType, Value: "", Validators: []OptionValidator{RequiredWhen("mode", "custom")}},
+				{Name: "count", Type: IntType, Value: "3"},
+			}
+
+			err := options.Validate()
+			Expect(err).To(BeAssignableToTypeOf(&ValidationError{}))
+			Expect(err.(*ValidationError).Problems).To(Equal([]string{
+				"domain: 'Not Valid' is not a valid DNS name",
+				"cert: has to be provided when mode is custom",
+				"count: wrong type of value 3",
+			}))
+			Expect(err.Error()).To(HavePrefix("invalid options:\n  - domain: "))
+		})
+
+		It("succeeds when all options are valid", func() {
+			options := InstallationOptions{
+				{Name: "domain", Type: StringType, Value: "example.com", Validators: []OptionValidator{DNSName()}},
+				{Name: "extensions", Type: ListType, Value: []string{}},
+			}
+			Expect(options.Validate()).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,101 @@
+package kubernetes
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// ValuesOptionsReader fills the options from the values by the option names, like
+// the answers saved by the interactive installation or the settings of a profile.
+type ValuesOptionsReader struct {
+	source string
+	values map[string]interface{}
+}
+
+// NewValuesOptionsReader returns the reader of the values, the source describes where they come from
+func NewValuesOptionsReader(source string, values map[string]interface{}) ValuesOptionsReader {
+	return ValuesOptionsReader{source: source, values: values}
+}
+
+// NewFileOptionsReader loads the answers file, a YAML file with the values by the option names
+func NewFileOptionsReader(file string) (ValuesOptionsReader, error) {
+	values := map[string]interface{}{}
+	reader := NewValuesOptionsReader(fmt.Sprintf("answers file '%s'", file), values)
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return reader, errors.Wrapf(err, "failed to read answers file '%s'", file)
+	}
+	if err := yaml.Unmarshal(data, &reader.values); err != nil {
+		return reader, errors.Wrapf(err, "failed to parse answers file '%s'", file)
+	}
+	return reader, nil
+}
+
+// Read sets the option from the values, unless the user specified it already (on the command line)
+func (reader ValuesOptionsReader) Read(option *InstallationOption) error {
+	if option.UserSpecified {
+		return nil
+	}
+	value, ok := reader.values[option.Name]
+	if !ok {
+		return nil
+	}
+
+	// numbers may come as float64 from the yaml (json) parser, lists as []interface{}
+	var valid bool
+	switch option.Type {
+	case BooleanType:
+		_, valid = value.(bool)
+	case StringType:
+		_, valid = value.(string)
+	case IntType:
+		switch number := value.(type) {
+		case int:
+			valid = true
+		case float64:
+			value, valid = int(number), number == float64(int(number))
+		}
+	case ListType:
+		switch items := value.(type) {
+		case []string:
+			valid = true
+		case []interface{}:
+			list := []string{}
+			valid = true
+			for _, item := range items {
+				s, ok := item.(string)
+				valid = valid && ok
+				list = append(list, s)
+			}
+			value = list
+		case nil:
+			value, valid = []string{}, true
+		}
+	}
+	if !valid {
+		return errors.New(fmt.Sprintf("wrong type of %s in %s", option.Name, reader.source))
+	}
+
+	option.Value = value
+	option.UserSpecified = true
+	return nil
+}
+
+// WriteOptionsFile saves the values of the options, so they can be read by NewFileOptionsReader
+func WriteOptionsFile(file string, opts InstallationOptions) error {
+	values := map[string]interface{}{}
+	for _, opt := range opts {
+		values[opt.Name] = opt.Value
+	}
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize the answers")
+	}
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write answers file '%s'", file)
+	}
+	return nil
+}
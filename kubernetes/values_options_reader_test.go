@@ -0,0 +1,105 @@
+package kubernetes_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/kubernetes"
+)
+
+var _ = Describe("ValuesOptionsReader", func() {
+	var (
+		dir  string
+		file string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fuseml-answers")
+		Expect(err).ToNot(HaveOccurred())
+		file = filepath.Join(dir, "answers.yaml")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("reads the options written by WriteOptionsFile", func() {
+		Expect(WriteOptionsFile(file, InstallationOptions{
+			{Name: "domain", Type: StringType, Value: "example.com"},
+			{Name: "reinstall", Type: BooleanType, Value: true},
+			{Name: "timeout", Type: IntType, Value: 300},
+			{Name: "extensions", Type: ListType, Value: []string{"mlflow", "kfserving"}},
+		})).To(Succeed())
+
+		reader, err := NewFileOptionsReader(file)
+		Expect(err).ToNot(HaveOccurred())
+
+		options := InstallationOptions{
+			{Name: "domain", Type: StringType, Default: ""},
+			{Name: "reinstall", Type: BooleanType, Default: false},
+			{Name: "timeout", Type: IntType, Default: 0},
+			{Name: "extensions", Type: ListType, Default: []string{}},
+			{Name: "other", Type: StringType, Default: ""},
+		}
+		result, err := options.Populate(reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect((*result)[0].Value).To(Equal("example.com"))
+		Expect((*result)[1].Value).To(Equal(true))
+		Expect((*result)[2].Value).To(Equal(300))
+		Expect((*result)[3].Value).To(Equal([]string{"mlflow", "kfserving"}))
+		for _, opt := range (*result)[:4] {
+			Expect(opt.UserSpecified).To(BeTrue())
+		}
+		Expect((*result)[4].UserSpecified).To(BeFalse())
+	})
+
+	It("does not override the options specified by the user", func() {
+		Expect(ioutil.WriteFile(file, []byte("domain: example.com\n"), 0600)).To(Succeed())
+		reader, err := NewFileOptionsReader(file)
+		Expect(err).ToNot(HaveOccurred())
+
+		option := InstallationOption{Name: "domain", Type: StringType, Value: "cli.example.com", UserSpecified: true}
+		Expect(reader.Read(&option)).To(Succeed())
+		Expect(option.Value).To(Equal("cli.example.com"))
+	})
+
+	It("returns an error when the value has a wrong type", func() {
+		Expect(ioutil.WriteFile(file, []byte("timeout: soon\n"), 0600)).To(Succeed())
+		reader, err := NewFileOptionsReader(file)
+		Expect(err).ToNot(HaveOccurred())
+
+		option := InstallationOption{Name: "timeout", Type: IntType}
+		err = reader.Read(&option)
+		Expect(err).To(MatchError("wrong type of timeout in answers file '" + file + "'"))
+	})
+
+	It("reads the values of a profile", func() {
+		reader := NewValuesOptionsReader("profile 'prod'", map[string]interface{}{
+			"system_domain": "example.com",
+			"extensions":    []interface{}{"mlflow"},
+			"timeout":       "300",
+		})
+
+		domain := InstallationOption{Name: "system_domain", Type: StringType}
+		Expect(reader.Read(&domain)).To(Succeed())
+		Expect(domain.Value).To(Equal("example.com"))
+
+		extensions := InstallationOption{Name: "extensions", Type: ListType}
+		Expect(reader.Read(&extensions)).To(Succeed())
+		Expect(extensions.Value).To(Equal([]string{"mlflow"}))
+
+		timeout := InstallationOption{Name: "timeout", Type: IntType}
+		Expect(reader.Read(&timeout)).To(MatchError("wrong type of timeout in profile 'prod'"))
+	})
+
+	It("returns an error when the file does not exist", func() {
+		_, err := NewFileOptionsReader(file)
+		Expect(err).To(HaveOccurred())
+	})
+})
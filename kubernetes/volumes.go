@@ -0,0 +1,84 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var (
+	// RetainedNamespaceLabelKey marks the persistent volumes kept by the uninstallation with the namespace of their claims
+	RetainedNamespaceLabelKey = fmt.Sprintf("%s/%s", APISGroupName, "retained-namespace")
+	// RetainedClaimAnnotationKey holds the name of the claim the kept volume was bound to
+	RetainedClaimAnnotationKey = fmt.Sprintf("%s/%s", APISGroupName, "retained-claim")
+)
+
+// RetainVolumes makes the persistent volumes bound to the claims in the namespace survive its deletion
+// (by Retain reclaim policy) and marks them, so they can be re-attached to the same claims later.
+// It returns the names of the volumes.
+func (c *Cluster) RetainVolumes(ctx context.Context, namespace string) ([]string, error) {
+	claims, err := c.Kubectl.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list persistent volume claims in %s", namespace)
+	}
+
+	volumes := []string{}
+	for _, claim := range claims.Items {
+		if claim.Spec.VolumeName == "" {
+			continue
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels":      map[string]string{RetainedNamespaceLabelKey: namespace},
+				"annotations": map[string]string{RetainedClaimAnnotationKey: claim.Name},
+			},
+			"spec": map[string]interface{}{
+				"persistentVolumeReclaimPolicy": v1.PersistentVolumeReclaimRetain,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = c.Kubectl.CoreV1().PersistentVolumes().Patch(ctx, claim.Spec.VolumeName, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to retain persistent volume %s of %s/%s", claim.Spec.VolumeName, namespace, claim.Name)
+		}
+		volumes = append(volumes, claim.Spec.VolumeName)
+	}
+	return volumes, nil
+}
+
+// ReattachVolumes binds the volumes kept by RetainVolumes in advance to the claims of the same names
+// in the namespace, so that the claims created again use them instead of new volumes. It returns the
+// names of the volumes.
+func (c *Cluster) ReattachVolumes(ctx context.Context, namespace string) ([]string, error) {
+	pvs, err := c.Kubectl.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", RetainedNamespaceLabelKey, namespace),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list persistent volumes")
+	}
+
+	volumes := []string{}
+	for i := range pvs.Items {
+		pv := &pvs.Items[i]
+		claim := pv.Annotations[RetainedClaimAnnotationKey]
+		if claim == "" || pv.Status.Phase == v1.VolumeBound {
+			continue
+		}
+		delete(pv.Labels, RetainedNamespaceLabelKey)
+		delete(pv.Annotations, RetainedClaimAnnotationKey)
+		// the reference without UID binds the volume to the claim which is yet to be created
+		pv.Spec.ClaimRef = &v1.ObjectReference{Kind: "PersistentVolumeClaim", APIVersion: "v1", Namespace: namespace, Name: claim}
+		if _, err := c.Kubectl.CoreV1().PersistentVolumes().Update(ctx, pv, metav1.UpdateOptions{}); err != nil {
+			return nil, errors.Wrapf(err, "failed to re-attach persistent volume %s to %s/%s", pv.Name, namespace, claim)
+		}
+		volumes = append(volumes, pv.Name)
+	}
+	return volumes, nil
+}
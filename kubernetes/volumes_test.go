@@ -0,0 +1,66 @@
+package kubernetes_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/kubernetes"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Volumes", func() {
+	ctx := context.Background()
+	var cluster *Cluster
+
+	BeforeEach(func() {
+		cluster = &Cluster{Kubectl: fake.NewSimpleClientset(
+			&v1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "data-gitea-0", Namespace: "gitea"},
+				Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pvc-1"},
+			},
+			&v1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "gitea"},
+			},
+			&v1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pvc-1"},
+				Spec: v1.PersistentVolumeSpec{
+					PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimDelete,
+					ClaimRef:                      &v1.ObjectReference{Namespace: "gitea", Name: "data-gitea-0", UID: "old"},
+				},
+				Status: v1.PersistentVolumeStatus{Phase: v1.VolumeBound},
+			},
+		)}
+	})
+
+	It("retains the volumes of the namespace and re-attaches them to the same claims", func() {
+		Expect(cluster.RetainVolumes(ctx, "gitea")).To(Equal([]string{"pvc-1"}))
+
+		pv, err := cluster.Kubectl.CoreV1().PersistentVolumes().Get(ctx, "pvc-1", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pv.Spec.PersistentVolumeReclaimPolicy).To(Equal(v1.PersistentVolumeReclaimRetain))
+		Expect(pv.Labels).To(HaveKeyWithValue(RetainedNamespaceLabelKey, "gitea"))
+		Expect(pv.Annotations).To(HaveKeyWithValue(RetainedClaimAnnotationKey, "data-gitea-0"))
+
+		// bound volume is not touched
+		Expect(cluster.ReattachVolumes(ctx, "gitea")).To(BeEmpty())
+
+		pv.Status.Phase = v1.VolumeReleased
+		_, err = cluster.Kubectl.CoreV1().PersistentVolumes().Update(ctx, pv, metav1.UpdateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cluster.ReattachVolumes(ctx, "fuseml-registry")).To(BeEmpty())
+		Expect(cluster.ReattachVolumes(ctx, "gitea")).To(Equal([]string{"pvc-1"}))
+
+		pv, err = cluster.Kubectl.CoreV1().PersistentVolumes().Get(ctx, "pvc-1", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pv.Spec.ClaimRef.Name).To(Equal("data-gitea-0"))
+		Expect(pv.Spec.ClaimRef.UID).To(BeEmpty())
+		Expect(pv.Labels).ToNot(HaveKey(RetainedNamespaceLabelKey))
+		Expect(pv.Annotations).ToNot(HaveKey(RetainedClaimAnnotationKey))
+	})
+})
@@ -0,0 +1,366 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// interval of looking up the kinds unknown to the Mapper
+const mapperPollInterval = time.Second
+
+// WaitCondition selects the objects of any kind, including the custom resources, and the state
+// all of them have to reach. Exactly one of Condition, JSONPath, Rollout and Exists is expected,
+// the condition is used when none is set.
+type WaitCondition struct {
+	// Kind is the resource in the form accepted by kubectl, e.g. pod, deployments.apps or
+	// inferenceservice.serving.kubeflow.org
+	Kind      string
+	Namespace string
+	// Name selects the single object, otherwise the objects matching the label Selector
+	// (all the objects in the namespace when empty or "all") are selected
+	Name     string
+	Selector string
+
+	// Condition is the type of the status condition which has to be True
+	Condition string
+	// JSONPath is the value of the object (e.g. {.status.phase}) which has to be equal to Value,
+	// or not empty when no Value is given
+	JSONPath string
+	Value    string
+	// Rollout waits until the Deployment, StatefulSet or DaemonSet is rolled out
+	Rollout bool
+	// Exists waits only for the objects to exist
+	Exists bool
+
+	Timeout time.Duration
+}
+
+func (w WaitCondition) String() string {
+	object := w.Kind
+	if w.Name != "" {
+		object = object + "/" + w.Name
+	} else if w.Selector != "" && w.Selector != "all" {
+		object = fmt.Sprintf("%s (%s)", object, w.Selector)
+	}
+	if w.Namespace != "" {
+		object = object + " in " + w.Namespace
+	}
+
+	switch {
+	case w.Exists:
+		return object + " to exist"
+	case w.Rollout:
+		return object + " to be rolled out"
+	case w.JSONPath != "" && w.Value != "":
+		return fmt.Sprintf("%s to have %s equal to %s", object, w.JSONPath, w.Value)
+	case w.JSONPath != "":
+		return fmt.Sprintf("%s to have %s", object, w.JSONPath)
+	}
+	return fmt.Sprintf("%s to be %s", object, w.condition())
+}
+
+func (w WaitCondition) condition() string {
+	if w.Condition == "" {
+		return "Ready"
+	}
+	return w.Condition
+}
+
+// WaitError is returned when the objects did not reach the state in time, with the last observed status
+type WaitError struct {
+	Condition WaitCondition
+	// Status of each selected object, when it was last observed
+	Status []string
+	Err    error
+}
+
+func (e *WaitError) Error() string {
+	status := "no objects found"
+	if len(e.Status) > 0 {
+		status = strings.Join(e.Status, "\n  - ")
+	}
+	return fmt.Sprintf("timed out waiting for %s, last status:\n  - %s", e.Condition, status)
+}
+
+// Wait watches the selected objects until all of them reach the state. It fails with WaitError
+// after the timeout.
+func (c *Cluster) Wait(ctx context.Context, w WaitCondition) error {
+	if c.Dynamic == nil || c.Mapper == nil {
+		return errors.New("the dynamic client is not initialized")
+	}
+	// the custom resources may be defined while waiting
+	gvr, namespaced, err := c.resourceFor(ctx, w.Kind, w.Timeout)
+	if err != nil {
+		return err
+	}
+	if w.JSONPath != "" {
+		if _, err := parseJSONPath(w.JSONPath); err != nil {
+			return err
+		}
+	}
+
+	resource := c.Dynamic.Resource(gvr)
+	namespace := ""
+	if namespaced {
+		namespace = w.Namespace
+	}
+	options := func(options *metav1.ListOptions) {
+		if w.Name != "" {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", w.Name).String()
+		} else if w.Selector != "all" {
+			options.LabelSelector = w.Selector
+		}
+	}
+	lw := &cache.ListWatch{
+		ListFunc: func(o metav1.ListOptions) (runtime.Object, error) {
+			options(&o)
+			return resource.Namespace(namespace).List(ctx, o)
+		},
+		WatchFunc: func(o metav1.ListOptions) (watch.Interface, error) {
+			options(&o)
+			return resource.Namespace(namespace).Watch(ctx, o)
+		},
+	}
+
+	waitCtx := ctx
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+
+	var store cache.Store
+	status := []string{}
+	check := func() (bool, error) {
+		done, observed, err := Evaluate(store.List(), w)
+		status = observed
+		return done, err
+	}
+	_, err = watchtools.UntilWithSync(waitCtx, lw, &unstructured.Unstructured{},
+		func(s cache.Store) (bool, error) {
+			store = s
+			return check()
+		},
+		func(watch.Event) (bool, error) {
+			return check()
+		},
+	)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err == wait.ErrWaitTimeout || waitCtx.Err() != nil {
+		return &WaitError{Condition: w, Status: status, Err: err}
+	}
+	return errors.Wrapf(err, "failed waiting for %s", w)
+}
+
+// resourceFor finds the resource of the kind given in the form accepted by kubectl. The kind unknown
+// to the Mapper is looked up again after resetting it, until the timeout, as its CRD may be created
+// after the resources were discovered.
+func (c *Cluster) resourceFor(ctx context.Context, kind string, timeout time.Duration) (schema.GroupVersionResource, bool, error) {
+	gvr, namespaced, err := c.mapResource(kind)
+	if err == nil || !meta.IsNoMatchError(errors.Cause(err)) {
+		return gvr, namespaced, err
+	}
+	lookup := func() (bool, error) {
+		c.resetMapper()
+		gvr, namespaced, err = c.mapResource(kind)
+		return err == nil || !meta.IsNoMatchError(errors.Cause(err)), nil
+	}
+	if timeout <= 0 {
+		lookup()
+		return gvr, namespaced, err
+	}
+	if pollErr := PollImmediate(ctx, mapperPollInterval, timeout, lookup); pollErr != nil && ctx.Err() != nil {
+		return schema.GroupVersionResource{}, false, ctx.Err()
+	}
+	return gvr, namespaced, err
+}
+
+// mapResource finds the resource of the kind by the resources known to the Mapper
+func (c *Cluster) mapResource(kind string) (schema.GroupVersionResource, bool, error) {
+	arg := strings.ToLower(kind)
+	gvr, gr := schema.ParseResourceArg(arg)
+	var err error
+	found := false
+	if gvr != nil {
+		var resolved schema.GroupVersionResource
+		if resolved, err = c.Mapper.ResourceFor(*gvr); err == nil {
+			gvr, found = &resolved, true
+		}
+	}
+	if !found {
+		var resolved schema.GroupVersionResource
+		if resolved, err = c.Mapper.ResourceFor(gr.WithVersion("")); err != nil {
+			return schema.GroupVersionResource{}, false, errors.Wrapf(err, "unknown kind %s", kind)
+		}
+		gvr = &resolved
+	}
+
+	gvk, err := c.Mapper.KindFor(*gvr)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, errors.Wrapf(err, "unknown kind %s", kind)
+	}
+	mapping, err := c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, errors.Wrapf(err, "unknown kind %s", kind)
+	}
+	return *gvr, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// Evaluate checks whether all the objects reached the state of the wait condition, at least one
+// object is required. It returns the status of each object.
+func Evaluate(objects []interface{}, w WaitCondition) (bool, []string, error) {
+	status := []string{}
+	done := true
+	found := false
+	for _, o := range objects {
+		obj, ok := o.(*unstructured.Unstructured)
+		if !ok || (w.Name != "" && obj.GetName() != w.Name) {
+			continue
+		}
+		found = true
+		ready, observed, err := evaluate(obj, w)
+		if err != nil {
+			return false, nil, err
+		}
+		done = done && ready
+		status = append(status, fmt.Sprintf("%s/%s: %s", strings.ToLower(obj.GetKind()), obj.GetName(), observed))
+	}
+	sort.Strings(status)
+	return done && found, status, nil
+}
+
+func evaluate(obj *unstructured.Unstructured, w WaitCondition) (bool, string, error) {
+	switch {
+	case w.Exists:
+		return true, "exists", nil
+	case w.Rollout:
+		return rolloutStatus(obj)
+	case w.JSONPath != "":
+		value, err := jsonPathValue(obj, w.JSONPath)
+		if err != nil {
+			return false, "", err
+		}
+		if value == "" {
+			return false, fmt.Sprintf("%s is empty", w.JSONPath), nil
+		}
+		if w.Value != "" && value != w.Value {
+			return false, fmt.Sprintf("%s is %s", w.JSONPath, value), nil
+		}
+		return true, fmt.Sprintf("%s is %s", w.JSONPath, value), nil
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || !strings.EqualFold(fmt.Sprint(condition["type"]), w.condition()) {
+			continue
+		}
+		observed := fmt.Sprintf("%s is %v", w.condition(), condition["status"])
+		if message, ok := condition["message"]; ok && message != "" {
+			observed = fmt.Sprintf("%s (%v)", observed, message)
+		}
+		return strings.EqualFold(fmt.Sprint(condition["status"]), "True"), observed, nil
+	}
+	return false, fmt.Sprintf("no %s condition", w.condition()), nil
+}
+
+// rolloutStatus follows the checks of `kubectl rollout status`
+func rolloutStatus(obj *unstructured.Unstructured) (bool, string, error) {
+	generation := obj.GetGeneration()
+	observedGeneration, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if observedGeneration < generation {
+		return false, "waiting for the update to be observed", nil
+	}
+	value := func(fields ...string) int64 {
+		v, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+		return v
+	}
+
+	switch obj.GetKind() {
+	case "Deployment":
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		updated := value("status", "updatedReplicas")
+		if updated < replicas {
+			return false, fmt.Sprintf("%d of %d replicas updated", updated, replicas), nil
+		}
+		if total := value("status", "replicas"); total > updated {
+			return false, fmt.Sprintf("%d old replicas pending termination", total-updated), nil
+		}
+		if available := value("status", "availableReplicas"); available < updated {
+			return false, fmt.Sprintf("%d of %d updated replicas available", available, updated), nil
+		}
+		return true, "rolled out", nil
+	case "StatefulSet":
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		if ready := value("status", "readyReplicas"); ready < replicas {
+			return false, fmt.Sprintf("%d of %d replicas ready", ready, replicas), nil
+		}
+		current, _, _ := unstructured.NestedString(obj.Object, "status", "currentRevision")
+		update, _, _ := unstructured.NestedString(obj.Object, "status", "updateRevision")
+		if update != "" && current != update {
+			return false, fmt.Sprintf("%d of %d replicas updated", value("status", "updatedReplicas"), replicas), nil
+		}
+		return true, "rolled out", nil
+	case "DaemonSet":
+		desired := value("status", "desiredNumberScheduled")
+		if updated := value("status", "updatedNumberScheduled"); updated < desired {
+			return false, fmt.Sprintf("%d of %d pods updated", updated, desired), nil
+		}
+		if available := value("status", "numberAvailable"); available < desired {
+			return false, fmt.Sprintf("%d of %d updated pods available", available, desired), nil
+		}
+		return true, "rolled out", nil
+	}
+	return false, "", errors.New(fmt.Sprintf("rollout status is not supported for %s", obj.GetKind()))
+}
+
+// parseJSONPath parses the path given with or without the braces, e.g. .status.phase or {.status.phase}
+func parseJSONPath(path string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+	parser := jsonpath.New("wait").AllowMissingKeys(true)
+	if err := parser.Parse(path); err != nil {
+		return nil, errors.Wrapf(err, "invalid JSONPath %s", path)
+	}
+	return parser, nil
+}
+
+func jsonPathValue(obj *unstructured.Unstructured, path string) (string, error) {
+	parser, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := parser.Execute(&b, obj.Object); err != nil {
+		return "", errors.Wrapf(err, "failed to evaluate JSONPath %s", path)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,175 @@
+package kubernetes_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/kubernetes"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/restmapper"
+)
+
+func object(apiVersion, kind, name string, generation int64, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": "fuseml-workloads", "labels": map[string]interface{}{"app": name}},
+		"status":     status,
+	}}
+	obj.SetGeneration(generation)
+	return obj
+}
+
+var _ = Describe("Wait", func() {
+	podGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	deploymentGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	phaseGVR := schema.GroupVersionResource{Group: "serving.kubeflow.org", Version: "v1beta1", Resource: "inferenceservices"}
+
+	var (
+		ctx     context.Context
+		cluster *Cluster
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mapper := meta.NewDefaultRESTMapper(nil)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+		mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+		mapper.Add(schema.GroupVersionKind{Group: "serving.kubeflow.org", Version: "v1beta1", Kind: "InferenceService"}, meta.RESTScopeNamespace)
+
+		cluster = &Cluster{
+			Mapper: mapper,
+			Dynamic: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					podGVR:        "PodList",
+					deploymentGVR: "DeploymentList",
+					phaseGVR:      "InferenceServiceList",
+				},
+				object("v1", "Pod", "ready", 1, map[string]interface{}{
+					"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+				}),
+				object("v1", "Pod", "pending", 1, map[string]interface{}{
+					"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "False", "message": "containers not ready"}},
+				}),
+				object("apps/v1", "Deployment", "mlflow", 2, map[string]interface{}{
+					"observedGeneration": int64(2), "replicas": int64(1), "updatedReplicas": int64(1), "availableReplicas": int64(1),
+				}),
+				object("serving.kubeflow.org/v1beta1", "InferenceService", "predictor", 1, map[string]interface{}{
+					"phase": "Pending",
+				}),
+			),
+		}
+	})
+
+	It("waits for the objects which are ready", func() {
+		Expect(cluster.Wait(ctx, WaitCondition{Kind: "pod", Namespace: "fuseml-workloads", Name: "ready", Timeout: time.Second})).To(Succeed())
+		Expect(cluster.Wait(ctx, WaitCondition{Kind: "deployments.apps", Namespace: "fuseml-workloads", Selector: "app=mlflow", Rollout: true, Timeout: time.Second})).To(Succeed())
+		Expect(cluster.Wait(ctx, WaitCondition{Kind: "inferenceservice", Namespace: "fuseml-workloads", Name: "predictor", Exists: true, Timeout: time.Second})).To(Succeed())
+	})
+
+	It("reports the last status of the objects on timeout", func() {
+		err := cluster.Wait(ctx, WaitCondition{Kind: "pod", Namespace: "fuseml-workloads", Selector: "all", Timeout: 200 * time.Millisecond})
+		Expect(err).To(BeAssignableToTypeOf(&WaitError{}))
+		Expect(err.Error()).To(Equal("timed out waiting for pod in fuseml-workloads to be Ready, last status:\n" +
+			"  - pod/pending: Ready is False (containers not ready)\n" +
+			"  - pod/ready: Ready is True"))
+
+		err = cluster.Wait(ctx, WaitCondition{Kind: "pod", Namespace: "fuseml-workloads", Name: "missing", Exists: true, Timeout: 200 * time.Millisecond})
+		Expect(err).To(MatchError("timed out waiting for pod/missing in fuseml-workloads to exist, last status:\n  - no objects found"))
+	})
+
+	It("watches the objects until they reach the state", func() {
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(100 * time.Millisecond)
+			updated := object("serving.kubeflow.org/v1beta1", "InferenceService", "predictor", 1, map[string]interface{}{"phase": "Ready"})
+			_, err := cluster.Dynamic.Resource(phaseGVR).Namespace("fuseml-workloads").Update(ctx, updated, metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		}()
+		Expect(cluster.Wait(ctx, WaitCondition{Kind: "inferenceservice.serving.kubeflow.org", Namespace: "fuseml-workloads",
+			Name: "predictor", JSONPath: "{.status.phase}", Value: "Ready", Timeout: 5 * time.Second})).To(Succeed())
+	})
+
+	It("fails for unknown kinds", func() {
+		Expect(cluster.Wait(ctx, WaitCondition{Kind: "widget", Name: "x"})).To(MatchError(ContainSubstring("unknown kind widget")))
+	})
+
+	It("finds the kinds defined after the first lookup", func() {
+		discovery := fake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+		discovery.Resources = []*metav1.APIResourceList{{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{{Name: "pods", Kind: "Pod", Namespaced: true}},
+		}}
+		cluster.Mapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discovery))
+		Expect(cluster.Wait(ctx, WaitCondition{Kind: "pod", Namespace: "fuseml-workloads", Name: "ready", Timeout: time.Second})).To(Succeed())
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			discovery.Resources = append(discovery.Resources, &metav1.APIResourceList{
+				GroupVersion: "serving.kubeflow.org/v1beta1",
+				APIResources: []metav1.APIResource{{Name: "inferenceservices", Kind: "InferenceService", Namespaced: true}},
+			})
+		}()
+		Expect(cluster.Wait(ctx, WaitCondition{Kind: "inferenceservice", Namespace: "fuseml-workloads", Name: "predictor", Exists: true,
+			Timeout: 5 * time.Second})).To(Succeed())
+		Expect(cluster.GetJSONPathValue(ctx, "inferenceservices.serving.kubeflow.org", "fuseml-workloads", "predictor", "{.status.phase}")).
+			To(Equal("Pending"))
+	})
+
+	table.DescribeTable("rollout status",
+		func(kind string, generation int64, status map[string]interface{}, done bool, observed string) {
+			obj := object("apps/v1", kind, "app", generation, status)
+			obj.Object["spec"] = map[string]interface{}{"replicas": int64(2)}
+			ready, result, err := Evaluate([]interface{}{obj}, WaitCondition{Rollout: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(Equal(done))
+			Expect(result).To(Equal([]string{observed}))
+		},
+		table.Entry("deployment not observed", "Deployment", int64(3), map[string]interface{}{"observedGeneration": int64(2)},
+			false, "deployment/app: waiting for the update to be observed"),
+		table.Entry("deployment updating", "Deployment", int64(1), map[string]interface{}{"observedGeneration": int64(1), "updatedReplicas": int64(1)},
+			false, "deployment/app: 1 of 2 replicas updated"),
+		table.Entry("deployment with old replicas", "Deployment", int64(1),
+			map[string]interface{}{"observedGeneration": int64(1), "updatedReplicas": int64(2), "replicas": int64(3)},
+			false, "deployment/app: 1 old replicas pending termination"),
+		table.Entry("deployment rolled out", "Deployment", int64(1),
+			map[string]interface{}{"observedGeneration": int64(1), "updatedReplicas": int64(2), "replicas": int64(2), "availableReplicas": int64(2)},
+			true, "deployment/app: rolled out"),
+		table.Entry("statefulset updating", "StatefulSet", int64(1),
+			map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(2), "updatedReplicas": int64(1), "currentRevision": "a", "updateRevision": "b"},
+			false, "statefulset/app: 1 of 2 replicas updated"),
+		table.Entry("daemonset rolled out", "DaemonSet", int64(1),
+			map[string]interface{}{"observedGeneration": int64(1), "desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(3), "numberAvailable": int64(3)},
+			true, "daemonset/app: rolled out"),
+	)
+
+	It("compares the JSONPath values", func() {
+		service := object("v1", "Service", "gateway", 1, map[string]interface{}{
+			"loadBalancer": map[string]interface{}{},
+		})
+		ready, status, err := Evaluate([]interface{}{service}, WaitCondition{JSONPath: ".status.loadBalancer.ingress[0].ip"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ready).To(BeFalse())
+		Expect(status).To(Equal([]string{"service/gateway: .status.loadBalancer.ingress[0].ip is empty"}))
+
+		service.Object["status"] = map[string]interface{}{"loadBalancer": map[string]interface{}{
+			"ingress": []interface{}{map[string]interface{}{"ip": "10.0.0.1"}},
+		}}
+		ready, _, err = Evaluate([]interface{}{service}, WaitCondition{JSONPath: ".status.loadBalancer.ingress[0].ip"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ready).To(BeTrue())
+	})
+})
@@ -0,0 +1,171 @@
+// Package audit writes the log of everything the installer did to the cluster and its
+// surroundings: the commands it executed (with their output), the changes made through
+// the kubernetes API and the requests sent to the extension registry.
+package audit
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	logDir          = "logs"
+	timestampFormat = "2006-01-02T15:04:05.000Z07:00"
+	redacted        = "<redacted>"
+)
+
+var (
+	mu     sync.Mutex
+	writer io.WriteCloser
+	path   string
+
+	// values of the keys (name=value, yaml or json) and flags with these names are never logged
+	secretValuePattern = regexp.MustCompile(`(?i)((?:password|passwd|token|secret|api[-_]?key|access[-_]?key)[\w.-]*"?\s*[=:]\s*)("[^"]*"|'[^']*'|[^\s,;&|]+)`)
+	secretFlagPattern  = regexp.MustCompile(`(?i)(--[\w-]*(?:password|passwd|token|secret|api-key|access-key)[\w-]*\s+)("[^"]*"|'[^']*'|[^\s-]\S*)`)
+	// basic auth in URLs
+	userinfoPattern = regexp.MustCompile(`(://[^/\s:@]+):[^/\s@]+@`)
+)
+
+// DefaultLocation returns the path of a new log file, in the logs directory next to the config file
+func DefaultLocation(configFile string, now time.Time) string {
+	return filepath.Join(filepath.Dir(configFile), logDir, fmt.Sprintf("fuseml-installer-%s.log", now.Format("20060102-150405")))
+}
+
+// Open starts writing the audit log to the file, creating its directory when needed.
+// Nothing is logged until the log is opened.
+func Open(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return errors.Wrapf(err, "failed to create log dir '%s'", filepath.Dir(file))
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open log file '%s'", file)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if writer != nil {
+		writer.Close()
+	}
+	writer = f
+	path = file
+	return nil
+}
+
+// Close stops writing the audit log
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if writer == nil {
+		return nil
+	}
+	err := writer.Close()
+	writer = nil
+	return err
+}
+
+// Path returns the file the audit log is written to, empty when it is not open
+func Path() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if writer == nil {
+		return ""
+	}
+	return path
+}
+
+// Start records the start of the installer run with its (redacted) arguments
+func Start(args []string) {
+	write("run", Redact(strings.Join(args, " ")), "")
+}
+
+// Finish records the end of the installer run
+func Finish(duration time.Duration, err error) {
+	if err != nil {
+		write("run", fmt.Sprintf("failed duration=%s", duration.Round(time.Millisecond)), err.Error())
+		return
+	}
+	write("run", fmt.Sprintf("succeeded duration=%s", duration.Round(time.Millisecond)), "")
+}
+
+// Command records the command executed by the installer, together with its output
+func Command(command string, exitCode int, duration time.Duration, output string) {
+	write("exec", fmt.Sprintf("%q exit=%d duration=%s", Redact(command), exitCode, duration.Round(time.Millisecond)), output)
+}
+
+// Request records the HTTP request sent by the installer. Status is 0 when no response was received.
+func Request(kind, method, url string, status int, duration time.Duration, err error) {
+	message := fmt.Sprintf("%s %s status=%d duration=%s", method, Redact(url), status, duration.Round(time.Millisecond))
+	if err != nil {
+		message += " error=" + Redact(err.Error())
+	}
+	write(kind, message, "")
+}
+
+// Redact replaces the values of passwords, tokens and other secrets in the text
+func Redact(text string) string {
+	text = secretValuePattern.ReplaceAllString(text, "${1}"+redacted)
+	text = secretFlagPattern.ReplaceAllString(text, "${1}"+redacted)
+	return userinfoPattern.ReplaceAllString(text, "${1}:"+redacted+"@")
+}
+
+// Transport returns the round tripper recording the requests of given kind. When onlyMutations
+// is set, only the requests changing something (i.e. not GET, HEAD, OPTIONS) are recorded.
+func Transport(kind string, onlyMutations bool, rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &transport{kind: kind, onlyMutations: onlyMutations, rt: rt}
+}
+
+type transport struct {
+	kind          string
+	onlyMutations bool
+	rt            http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.onlyMutations {
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return t.rt.RoundTrip(req)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.rt.RoundTrip(req)
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	Request(t.kind, req.Method, req.URL.String(), status, time.Since(start), err)
+	return resp, err
+}
+
+// each entry starts with the timestamp and its kind, the (redacted) output is indented below it
+func write(kind, message, output string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if writer == nil {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5s %s\n", time.Now().Format(timestampFormat), kind, message)
+	output = strings.TrimRight(Redact(output), "\n")
+	if output != "" {
+		for _, line := range strings.Split(output, "\n") {
+			fmt.Fprintf(&b, "    | %s\n", line)
+		}
+	}
+	_, _ = io.WriteString(writer, b.String())
+}
@@ -0,0 +1,89 @@
+package paas
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/pkg/errors"
+)
+
+// Cleanup lists the resources labeled with the component or extension which is no longer
+// installed, and removes them when remove is set.
+func (c *InstallClient) Cleanup(ctx context.Context, remove bool) error {
+	log := c.Log.WithName("Cleanup")
+	log.Info("start")
+	defer log.Info("return")
+	details := log.V(1)
+
+	resources, err := deployments.ListOwnedResources(ctx)
+	if err != nil {
+		return err
+	}
+	details.Info("owned resources", "Count", len(resources))
+
+	extensions, err := c.registeredExtensions(ctx)
+	if err != nil {
+		return err
+	}
+	orphans, err := deployments.OrphanedResources(ctx, c.kubeClient, resources, extensions)
+	if err != nil {
+		return err
+	}
+
+	if len(orphans) == 0 {
+		c.ui.Success().Msg("No orphaned FuseML resources found.")
+		return nil
+	}
+
+	msg := c.ui.Note().WithTable("Owner", "Resource", "Namespace", "Name")
+	for _, orphan := range orphans {
+		msg = msg.WithTableRow(orphan.Owner.String(), orphan.Resource, orphan.Namespace, orphan.Name)
+	}
+	msg.Msg("FuseML resources whose owner is no longer installed:")
+
+	if !remove {
+		c.ui.Note().Msg("Use --delete to remove them")
+		return nil
+	}
+	for _, orphan := range orphans {
+		details.Info("delete", "Resource", orphan.String())
+		if err := deployments.DeleteOwnedResource(ctx, orphan); err != nil {
+			return checkInterrupted(ctx, err, "removing "+orphan.String())
+		}
+	}
+	c.ui.Success().Msg(fmt.Sprintf("%d orphaned FuseML resources removed.", len(orphans)))
+	return nil
+}
+
+// registeredExtensions returns the extensions registered in the extension registry of FuseML core,
+// without FuseML core none of them is
+func (c *InstallClient) registeredExtensions(ctx context.Context) (map[string]bool, error) {
+	extensions := map[string]bool{}
+	coreInstalled, err := deployments.ComponentInstalled(ctx, c.kubeClient, deployments.CoreDeploymentID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check if FuseML core is installed")
+	}
+	if !coreInstalled {
+		return extensions, nil
+	}
+
+	domain := c.fetchExistingDomain(ctx)
+	if domain == "" {
+		return nil, errors.New("can't find the domain of FuseML core to list the installed extensions")
+	}
+	options := kubernetes.InstallationOptions{
+		{Name: "system_domain", Type: kubernetes.StringType, Value: domain},
+	}
+	registered, err := deployments.GetRegisteredExtensions(ctx, c.kubeClient, &options, c.ui.Verbose())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the installed extensions")
+	}
+	for _, extension := range registered {
+		if extension.ID != nil {
+			extensions[*extension.ID] = true
+		}
+	}
+	return extensions, nil
+}
@@ -30,9 +30,15 @@ func (c *FusemlClient) Info() error {
 		return errors.Wrap(err, "failed to get kube version")
 	}
 
+	storageClass := platform.DefaultStorageClass()
+	if storageClass == "" {
+		storageClass = "none"
+	}
+
 	c.ui.Success().
 		WithStringValue("Platform", platform.String()).
 		WithStringValue("Kubernetes Version", kubeVersion).
+		WithStringValue("Default Storage Class", storageClass).
 		Msg("Fuseml Environment")
 
 	return nil
@@ -0,0 +1,211 @@
+// Package compat checks the versions of the installer, fuseml-core, Kubernetes and the extensions
+// against the compatibility matrix embedded in the installer and the requirements of the extensions.
+package compat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+)
+
+// MatrixFile is the embedded file with the compatibility matrix
+const MatrixFile = "compatibility.yaml"
+
+// Constraint is the list of comparisons the version has to satisfy, separated by commas
+// or spaces, e.g. ">=1.18.0, <1.23". The operators are =, !=, >, >=, < and <=.
+type Constraint struct {
+	text        string
+	comparisons []comparison
+}
+
+type comparison struct {
+	operator string
+	version  *utilversion.Version
+}
+
+var operators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// ParseConstraint parses the constraint, the empty one allows any version
+func ParseConstraint(text string) (*Constraint, error) {
+	constraint := &Constraint{text: strings.TrimSpace(text)}
+	terms := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' })
+	for _, term := range terms {
+		c := comparison{operator: "="}
+		for _, op := range operators {
+			if strings.HasPrefix(term, op) {
+				c.operator = op
+				term = term[len(op):]
+				break
+			}
+		}
+		v, err := utilversion.ParseGeneric(term)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version constraint '%s'", text)
+		}
+		c.version = v
+		constraint.comparisons = append(constraint.comparisons, c)
+	}
+	return constraint, nil
+}
+
+// Allows checks that the version satisfies all comparisons
+func (c *Constraint) Allows(v *utilversion.Version) bool {
+	for _, comp := range c.comparisons {
+		cmp := 0
+		if v.LessThan(comp.version) {
+			cmp = -1
+		} else if comp.version.LessThan(v) {
+			cmp = 1
+		}
+		ok := false
+		switch comp.operator {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Constraint) String() string {
+	return c.text
+}
+
+// Versions of the components being combined, empty (or not a version number, like
+// the development builds) when not known
+type Versions struct {
+	Installer  string
+	Core       string
+	Kubernetes string
+	Extensions map[string]string
+}
+
+// Requirements are the versions of the components the installer release (in the matrix)
+// or the extension (in its description) works with
+type Requirements struct {
+	Installer  string            `json:"installer,omitempty"`
+	Core       string            `json:"core,omitempty"`
+	Kubernetes string            `json:"kubernetes,omitempty"`
+	Extensions map[string]string `json:"extensions,omitempty"`
+}
+
+// Problem is the version of the component not satisfying the requirement
+type Problem struct {
+	// Source is the installer release or the extension having the requirement
+	Source     string
+	Component  string
+	Version    string
+	Constraint string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s requires %s %s, found %s", p.Source, p.Component, p.Constraint, p.Version)
+}
+
+// IncompatibleError lists all the problems found
+type IncompatibleError struct {
+	Problems []Problem
+}
+
+func (e *IncompatibleError) Error() string {
+	problems := []string{}
+	for _, p := range e.Problems {
+		problems = append(problems, p.String())
+	}
+	return fmt.Sprintf("incompatible versions:\n  - %s", strings.Join(problems, "\n  - "))
+}
+
+// Check returns the versions not satisfying the requirements of the source. The unknown
+// versions can't be checked and are skipped.
+func (r Requirements) Check(source string, versions Versions) ([]Problem, error) {
+	problems := []Problem{}
+	check := func(component, constraint, version string) error {
+		if constraint == "" {
+			return nil
+		}
+		v, err := utilversion.ParseGeneric(version)
+		if err != nil {
+			return nil
+		}
+		c, err := ParseConstraint(constraint)
+		if err != nil {
+			return errors.Wrapf(err, "invalid requirement of %s", source)
+		}
+		if !c.Allows(v) {
+			problems = append(problems, Problem{Source: source, Component: component, Version: version, Constraint: c.String()})
+		}
+		return nil
+	}
+
+	if err := check("installer", r.Installer, versions.Installer); err != nil {
+		return nil, err
+	}
+	if err := check("fuseml-core", r.Core, versions.Core); err != nil {
+		return nil, err
+	}
+	if err := check("Kubernetes", r.Kubernetes, versions.Kubernetes); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for name := range r.Extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := check("extension "+name, r.Extensions[name], versions.Extensions[name]); err != nil {
+			return nil, err
+		}
+	}
+	return problems, nil
+}
+
+// Matrix lists the requirements of the installer releases, the installer constraint of each
+// entry selects the releases it applies to
+type Matrix []Requirements
+
+// ParseMatrix parses the matrix from YAML
+func ParseMatrix(data []byte) (Matrix, error) {
+	matrix := Matrix{}
+	if err := yaml.Unmarshal(data, &matrix); err != nil {
+		return nil, errors.Wrap(err, "failed to parse compatibility matrix")
+	}
+	return matrix, nil
+}
+
+// For returns the requirements of the installer version: the first entry matching it, or the first
+// entry for the development build. Nil is returned when the release is not in the matrix.
+func (m Matrix) For(installerVersion string) (*Requirements, error) {
+	v, err := utilversion.ParseGeneric(installerVersion)
+	if err != nil {
+		if len(m) == 0 {
+			return nil, nil
+		}
+		return &m[0], nil
+	}
+	for i := range m {
+		c, err := ParseConstraint(m[i].Installer)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid compatibility matrix")
+		}
+		if c.Allows(v) {
+			return &m[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,115 @@
+package compat_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/paas/compat"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+)
+
+var _ = Describe("Constraint", func() {
+	table.DescribeTable("compares the versions numerically",
+		func(constraint, version string, allowed bool) {
+			c, err := ParseConstraint(constraint)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c.Allows(utilversion.MustParseGeneric(version))).To(Equal(allowed))
+		},
+		table.Entry("empty", "", "0.1", true),
+		table.Entry("lower bound", ">=1.18.0", "1.18", true),
+		table.Entry("below lower bound", ">=1.18.0", "v1.9.3", false),
+		table.Entry("range", ">=1.18.0, <1.23", "v1.21.1+k3s1", true),
+		table.Entry("above range", ">=1.18.0 <1.23", "1.23.0", false),
+		table.Entry("minor as number", ">0.9", "0.10.0", true),
+		table.Entry("equal", "0.3.1", "v0.3.1", true),
+		table.Entry("not equal", "!=0.3.1", "0.3.1", false),
+		table.Entry("upper bound", "<=0.3", "0.3.0", true),
+	)
+
+	It("fails on invalid version", func() {
+		_, err := ParseConstraint(">=latest")
+		Expect(err).To(MatchError(ContainSubstring("invalid version constraint '>=latest'")))
+	})
+})
+
+var _ = Describe("Requirements", func() {
+	requirements := Requirements{
+		Core:       ">=0.3.0, <0.4.0",
+		Kubernetes: ">=1.18.0",
+		Extensions: map[string]string{"mlflow": ">=1.19", "minio": ">=2021.1"},
+	}
+
+	It("reports the versions not satisfying the requirements", func() {
+		problems, err := requirements.Check("installer v0.3.0", Versions{
+			Core:       "v0.2.1",
+			Kubernetes: "v1.21.1",
+			Extensions: map[string]string{"mlflow": "1.18.0"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Source: "installer v0.3.0", Component: "fuseml-core", Version: "v0.2.1", Constraint: ">=0.3.0, <0.4.0"},
+			{Source: "installer v0.3.0", Component: "extension mlflow", Version: "1.18.0", Constraint: ">=1.19"},
+		}))
+
+		err = &IncompatibleError{Problems: problems}
+		Expect(err.Error()).To(Equal("incompatible versions:\n" +
+			"  - installer v0.3.0 requires fuseml-core >=0.3.0, <0.4.0, found v0.2.1\n" +
+			"  - installer v0.3.0 requires extension mlflow >=1.19, found 1.18.0"))
+	})
+
+	It("skips the unknown versions", func() {
+		problems, err := requirements.Check("installer dev", Versions{Core: "dev", Kubernetes: ""})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(BeEmpty())
+	})
+
+	It("fails on invalid requirement", func() {
+		_, err := Requirements{Installer: "=>0.3"}.Check("extension mlflow", Versions{Installer: "v0.3.0"})
+		Expect(err).To(MatchError(ContainSubstring("invalid requirement of extension mlflow")))
+	})
+})
+
+var _ = Describe("Matrix", func() {
+	matrix, err := ParseMatrix([]byte(`
+- installer: ">=0.3.0, <0.4.0"
+  core: ">=0.3.0, <0.4.0"
+- installer: ">=0.2.0, <0.3.0"
+  core: ">=0.2.0, <0.3.0"
+`))
+
+	It("parses the entries", func() {
+		Expect(err).ToNot(HaveOccurred())
+		Expect(matrix).To(HaveLen(2))
+	})
+
+	table.DescribeTable("selects the entry of the installer version",
+		func(installer, core string) {
+			requirements, err := matrix.For(installer)
+			Expect(err).ToNot(HaveOccurred())
+			if core == "" {
+				Expect(requirements).To(BeNil())
+			} else {
+				Expect(requirements.Core).To(Equal(core))
+			}
+		},
+		table.Entry("release", "v0.2.4", ">=0.2.0, <0.3.0"),
+		table.Entry("development build", "dev", ">=0.3.0, <0.4.0"),
+		table.Entry("unknown release", "v0.5.0", ""),
+	)
+
+	It("embeds valid matrix", func() {
+		data, err := ioutil.ReadFile("../../embedded-files/" + MatrixFile)
+		Expect(err).ToNot(HaveOccurred())
+		matrix, err := ParseMatrix(data)
+		Expect(err).ToNot(HaveOccurred())
+		for _, requirements := range matrix {
+			_, err := requirements.Check("installer", Versions{Installer: "0.1", Core: "0.1", Kubernetes: "1.0"})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = ParseConstraint(requirements.Installer)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+})
@@ -0,0 +1,76 @@
+package paas
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/compat"
+	"github.com/fuseml/fuseml/cli/paas/version"
+)
+
+// checkCompatibility verifies the versions of the installer, fuseml-core (the one given, being installed
+// or already installed), Kubernetes and the extensions against the compatibility matrix and the requirements
+// of the extensions. Incompatible versions are refused, or only reported with the ignore_compatibility option.
+func (c *InstallClient) checkCompatibility(ctx context.Context, options *kubernetes.InstallationOptions, coreVersion string, extensions []*deployments.Extension) error {
+	details := c.Log.WithName("checkCompatibility").V(1)
+
+	data, err := helpers.ReadEmbeddedFile(compat.MatrixFile)
+	if err != nil {
+		return err
+	}
+	matrix, err := compat.ParseMatrix(data)
+	if err != nil {
+		return err
+	}
+
+	versions := compat.Versions{
+		Installer:  version.Version,
+		Core:       coreVersion,
+		Extensions: map[string]string{},
+	}
+	if versions.Kubernetes, err = c.kubeClient.GetVersion(); err != nil {
+		details.Info("unknown kubernetes version", "error", err)
+	}
+	for _, extension := range extensions {
+		versions.Extensions[extension.Name] = extension.Desc.Version
+	}
+	details.Info("versions", "Installer", versions.Installer, "Core", versions.Core, "Kubernetes", versions.Kubernetes)
+
+	problems := []compat.Problem{}
+	requirements, err := matrix.For(version.Version)
+	if err != nil {
+		return err
+	}
+	if requirements == nil {
+		c.ui.Exclamation().Msg(fmt.Sprintf("No compatibility information for installer version %s", version.Version))
+	} else {
+		found, err := requirements.Check("installer "+version.Version, versions)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, found...)
+	}
+	for _, extension := range extensions {
+		found, err := extension.Desc.Compatibility.Check("extension "+extension.Name, versions)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, found...)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	ignore, err := options.GetBool("ignore_compatibility", "")
+	if err == nil && ignore {
+		for _, problem := range problems {
+			c.ui.Exclamation().Msg(fmt.Sprintf("Incompatible versions: %s", problem))
+		}
+		return nil
+	}
+	c.ui.Note().Msg("Use --ignore-compatibility to continue anyway")
+	return &compat.IncompatibleError{Problems: problems}
+}
@@ -0,0 +1,160 @@
+package paas
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/pkg/errors"
+)
+
+// component is the part of FuseML which can be removed selectively
+type component struct {
+	name      string
+	namespace string
+	// the components it does not work without
+	requires   []string
+	deployment func(keepData bool) kubernetes.Deployment
+}
+
+// the components in the order of their removal, the ones depending on others first
+var components = []component{
+	{
+		name:      "core",
+		namespace: deployments.CoreDeploymentID,
+		requires:  []string{"gitea", "tekton", "workloads"},
+		deployment: func(keepData bool) kubernetes.Deployment {
+			return &deployments.Core{Timeout: DefaultTimeoutSec}
+		},
+	},
+	{
+		name:      "workloads",
+		namespace: deployments.WorkloadsDeploymentID,
+		requires:  []string{"tekton", "registry"},
+		deployment: func(keepData bool) kubernetes.Deployment {
+			return &deployments.Workloads{Timeout: DefaultTimeoutSec}
+		},
+	},
+	{
+		name:      "tekton",
+		namespace: deployments.TektonDeploymentID,
+		deployment: func(keepData bool) kubernetes.Deployment {
+			return &deployments.Tekton{Timeout: DefaultTimeoutSec}
+		},
+	},
+	{
+		name:      "registry",
+		namespace: deployments.RegistryDeploymentID,
+		deployment: func(keepData bool) kubernetes.Deployment {
+			return &deployments.Registry{Timeout: DefaultTimeoutSec, KeepData: keepData}
+		},
+	},
+	{
+		name:      "gitea",
+		namespace: deployments.GiteaDeploymentID,
+		deployment: func(keepData bool) kubernetes.Deployment {
+			return &deployments.Gitea{Timeout: DefaultTimeoutSec, KeepData: keepData}
+		},
+	},
+}
+
+// the components the extensions depend on: core keeps their registrations and generated secrets,
+// workloads the Roles and RoleBindings granting their rules
+var extensionsRequire = []string{"core", "workloads"}
+
+// ComponentNames returns the names of the components which can be removed selectively
+func ComponentNames() []string {
+	names := []string{}
+	for _, component := range components {
+		names = append(names, component.name)
+	}
+	return names
+}
+
+// uninstallComponents removes only the selected components, after checking that none of the remaining
+// installed components requires them
+func (c *InstallClient) uninstallComponents(ctx context.Context, selected []string, keepData bool) error {
+	details := c.Log.WithName("uninstallComponents").V(1)
+
+	problems := []string{}
+	for _, component := range components {
+		if helpers.StringInSlice(selected, component.name) {
+			continue
+		}
+		installed, err := c.kubeClient.NamespaceExists(ctx, component.namespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if %s is installed", component.name)
+		}
+		if !installed {
+			continue
+		}
+		for _, required := range component.requires {
+			if helpers.StringInSlice(selected, required) {
+				problems = append(problems, fmt.Sprintf("%s is required by %s", required, component.name))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(fmt.Sprintf("can't remove the components still in use (%s), remove them together",
+			strings.Join(problems, ", ")))
+	}
+
+	for _, required := range extensionsRequire {
+		if !helpers.StringInSlice(selected, required) {
+			continue
+		}
+		extensions, err := c.installedExtensions(ctx)
+		if err != nil {
+			return err
+		}
+		if len(extensions) > 0 {
+			return errors.New(fmt.Sprintf("can't remove %s, it is required by the extensions %s (remove them first with 'extensions --remove', or with 'cleanup --delete' when they are not registered)",
+				required, strings.Join(extensions, ", ")))
+		}
+		break
+	}
+
+	c.ui.Note().Msg(fmt.Sprintf("FuseML uninstalling components %s...", strings.Join(selected, ", ")))
+	for _, component := range components {
+		if !helpers.StringInSlice(selected, component.name) {
+			continue
+		}
+		deployment := component.deployment(keepData)
+		details.Info("remove", "Deployment", deployment.ID())
+		if err := deployment.Delete(ctx, c.kubeClient, c.ui); err != nil {
+			return checkInterrupted(ctx, err, "removing "+deployment.ID())
+		}
+	}
+
+	c.ui.Success().Msg(fmt.Sprintf("FuseML components %s uninstalled.", strings.Join(selected, ", ")))
+	return nil
+}
+
+// installedExtensions returns the names of the extensions registered in core, and of those owning
+// resources in the cluster (e.g. the ones not registered because their installation failed)
+func (c *InstallClient) installedExtensions(ctx context.Context) ([]string, error) {
+	registered, err := c.registeredExtensions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for name := range registered {
+		names = append(names, name)
+	}
+
+	resources, err := deployments.ListOwnedResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range resources {
+		if resource.Owner.IsExtension() && !helpers.StringInSlice(names, resource.Owner.Name) {
+			names = append(names, resource.Owner.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -15,15 +17,49 @@ var (
 	defaultExtensionsRepository = "https://raw.githubusercontent.com/fuseml/extensions/main/installer/"
 )
 
+// ProfileKeys are the settings a profile can hold: the cluster (kubeconfig and its context)
+// and the installation options used by the commands
+var ProfileKeys = []string{
+	"kubeconfig",
+	"context",
+	"system_domain",
+	"extensions_repository",
+	"extensions",
+	"tls",
+	"tls_cert_file",
+	"tls_key_file",
+	"tls_issuer",
+}
+
 // Config represents a fuseml config
 type Config struct {
-	GiteaProtocol            string `mapstructure:"gitea_protocol"`
-	FusemlWorkloadsNamespace string `mapstructure:"fuseml_workloads_namespace"`
-	Org                      string `mapstructure:"org"`
+	GiteaProtocol            string             `mapstructure:"gitea_protocol"`
+	FusemlWorkloadsNamespace string             `mapstructure:"fuseml_workloads_namespace"`
+	Org                      string             `mapstructure:"org"`
+	CurrentProfile           string             `mapstructure:"current_profile"`
+	Profiles                 map[string]Profile `mapstructure:"profiles"`
+	Client                   ClientInstallation `mapstructure:"client"`
 
 	v *viper.Viper
 }
 
+// Profile holds the settings for one of the clusters managed by the user, by the ProfileKeys
+type Profile map[string]interface{}
+
+// ClientInstallation records the fuseml client installed by the installer
+type ClientInstallation struct {
+	Path    string `mapstructure:"path"`
+	Version string `mapstructure:"version"`
+}
+
+// String returns the setting, empty when it is not set
+func (p Profile) String(key string) string {
+	if value, ok := p[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
 // DefaultExtensionsLocation returns the default location of extensions repository
 func DefaultExtensionsLocation() string {
 	return defaultExtensionsRepository
@@ -68,13 +104,126 @@ func Load(flags *pflag.FlagSet) (*Config, error) {
 		return nil, errors.Wrap(err, "failed to unmarshal config file")
 	}
 
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Profile{}
+	}
 	cfg.v = v
 	return cfg, nil
 }
 
+// Profile returns the profile selected by the --profile flag, or the current one.
+// Empty name is returned when no profile is selected.
+func (c *Config) Profile() (string, Profile, error) {
+	name := c.SelectedProfileName()
+	if name == "" {
+		return "", nil, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return "", nil, errors.New(fmt.Sprintf("profile '%s' does not exist (see 'config list')", name))
+	}
+	return name, profile, nil
+}
+
+// SelectedProfileName returns the name of the profile selected by the --profile flag, or the current one.
+// The profile does not have to exist.
+func (c *Config) SelectedProfileName() string {
+	if name := profileName(); name != "" {
+		return name
+	}
+	return c.CurrentProfile
+}
+
+// UseProfile makes the existing profile the current one
+func (c *Config) UseProfile(name string) error {
+	name = strings.ToLower(name)
+	if _, ok := c.Profiles[name]; !ok {
+		return errors.New(fmt.Sprintf("profile '%s' does not exist (see 'config list')", name))
+	}
+	c.CurrentProfile = name
+	return c.Save()
+}
+
+// SetProfileValue changes the setting of the profile selected by the --profile flag, or the current
+// one. Empty value removes the setting (and the profile with its last setting), extensions are separated
+// by commas. The profile selected by the flag is created when it does not exist, and becomes the current
+// one when there is none.
+func (c *Config) SetProfileValue(key, value string) error {
+	if err := checkProfileKey(key); err != nil {
+		return err
+	}
+	name := c.SelectedProfileName()
+	if name == "" {
+		return errors.New("no profile selected, use the --profile flag")
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		profile = Profile{}
+		c.Profiles[name] = profile
+	}
+	if c.CurrentProfile == "" {
+		c.CurrentProfile = name
+	}
+
+	switch {
+	case value == "":
+		delete(profile, key)
+		// the profile without settings can't be saved
+		if len(profile) == 0 {
+			delete(c.Profiles, name)
+			if c.CurrentProfile == name {
+				c.CurrentProfile = ""
+			}
+		}
+	case key == "extensions":
+		extensions := []string{}
+		for _, extension := range strings.Split(value, ",") {
+			if extension = strings.TrimSpace(extension); extension != "" {
+				extensions = append(extensions, extension)
+			}
+		}
+		profile[key] = extensions
+	default:
+		profile[key] = value
+	}
+	return c.Save()
+}
+
+// ProfileNames returns the names of all profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := []string{}
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func checkProfileKey(key string) error {
+	for _, k := range ProfileKeys {
+		if k == key {
+			return nil
+		}
+	}
+	return errors.New(fmt.Sprintf("unknown profile setting '%s' (use %s)", key, strings.Join(ProfileKeys, ", ")))
+}
+
+// File returns the path of the config file
+func (c *Config) File() string {
+	return c.v.ConfigFileUsed()
+}
+
 // Save saves the Fuseml config
 func (c *Config) Save() error {
 	c.v.Set("org", c.Org)
+	if len(c.Profiles) > 0 || c.v.IsSet("profiles") {
+		c.v.Set("current_profile", c.CurrentProfile)
+		c.v.Set("profiles", c.Profiles)
+	}
+	if c.Client.Path != "" {
+		c.v.Set("client", map[string]string{"path": c.Client.Path, "version": c.Client.Version})
+	}
 
 	err := os.MkdirAll(filepath.Dir(c.v.ConfigFileUsed()), 0700)
 	if err != nil {
@@ -93,6 +242,11 @@ func location() string {
 	return viper.GetString("config-file")
 }
 
+// profile names are case insensitive (as all keys of the config file)
+func profileName() string {
+	return strings.ToLower(viper.GetString("profile"))
+}
+
 func fileExists(path string) (bool, error) {
 	if _, err := os.Stat(path); err == nil {
 		return true, nil
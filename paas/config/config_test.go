@@ -0,0 +1,133 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	. "github.com/fuseml/fuseml/cli/paas/config"
+)
+
+var _ = Describe("Profiles", func() {
+	var dir string
+
+	load := func() *Config {
+		cfg, err := Load(nil)
+		Expect(err).ToNot(HaveOccurred())
+		return cfg
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fuseml-config")
+		Expect(err).ToNot(HaveOccurred())
+		viper.Set("config-file", filepath.Join(dir, "config.yaml"))
+		viper.Set("profile", "")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("selects no profile when there is none", func() {
+		name, profile, err := load().Profile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(name).To(BeEmpty())
+		Expect(profile).To(BeNil())
+	})
+
+	It("creates the profile selected by the flag and makes it current", func() {
+		viper.Set("profile", "Prod")
+		Expect(load().SetProfileValue("system_domain", "example.com")).To(Succeed())
+		Expect(load().SetProfileValue("extensions", "mlflow, minio")).To(Succeed())
+
+		viper.Set("profile", "")
+		cfg := load()
+		Expect(cfg.CurrentProfile).To(Equal("prod"))
+		name, profile, err := cfg.Profile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(name).To(Equal("prod"))
+		Expect(profile.String("system_domain")).To(Equal("example.com"))
+		Expect(profile["extensions"]).To(Equal([]interface{}{"mlflow", "minio"}))
+	})
+
+	It("keeps the current profile when another one is changed", func() {
+		viper.Set("profile", "prod")
+		Expect(load().SetProfileValue("system_domain", "example.com")).To(Succeed())
+		viper.Set("profile", "dev")
+		Expect(load().SetProfileValue("context", "kind-dev")).To(Succeed())
+
+		cfg := load()
+		Expect(cfg.CurrentProfile).To(Equal("prod"))
+		Expect(cfg.ProfileNames()).To(Equal([]string{"dev", "prod"}))
+
+		name, profile, err := cfg.Profile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(name).To(Equal("dev"))
+		Expect(profile.String("context")).To(Equal("kind-dev"))
+	})
+
+	It("removes the setting with empty value", func() {
+		viper.Set("profile", "prod")
+		Expect(load().SetProfileValue("tls", "self-signed")).To(Succeed())
+		Expect(load().SetProfileValue("system_domain", "example.com")).To(Succeed())
+		Expect(load().SetProfileValue("tls", "")).To(Succeed())
+
+		_, profile, err := load().Profile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(profile).ToNot(HaveKey("tls"))
+		Expect(profile).To(HaveKey("system_domain"))
+	})
+
+	It("removes the profile with its last setting", func() {
+		viper.Set("profile", "prod")
+		Expect(load().SetProfileValue("tls", "self-signed")).To(Succeed())
+		Expect(load().SetProfileValue("tls", "")).To(Succeed())
+
+		viper.Set("profile", "")
+		cfg := load()
+		Expect(cfg.ProfileNames()).To(BeEmpty())
+		Expect(cfg.CurrentProfile).To(BeEmpty())
+	})
+
+	It("selects the profile given by the flag, even when it does not exist", func() {
+		viper.Set("profile", "prod")
+		Expect(load().SetProfileValue("system_domain", "example.com")).To(Succeed())
+		Expect(load().SelectedProfileName()).To(Equal("prod"))
+
+		viper.Set("profile", "Dev")
+		Expect(load().SelectedProfileName()).To(Equal("dev"))
+		_, _, err := load().Profile()
+		Expect(err).To(MatchError("profile 'dev' does not exist (see 'config list')"))
+
+		viper.Set("profile", "")
+		Expect(load().SelectedProfileName()).To(Equal("prod"))
+	})
+
+	It("switches the current profile", func() {
+		viper.Set("profile", "prod")
+		Expect(load().SetProfileValue("system_domain", "example.com")).To(Succeed())
+		viper.Set("profile", "dev")
+		Expect(load().SetProfileValue("system_domain", "dev.example.com")).To(Succeed())
+		viper.Set("profile", "")
+
+		Expect(load().UseProfile("dev")).To(Succeed())
+		Expect(load().CurrentProfile).To(Equal("dev"))
+		Expect(load().UseProfile("test")).To(MatchError("profile 'test' does not exist (see 'config list')"))
+	})
+
+	It("rejects unknown settings", func() {
+		viper.Set("profile", "prod")
+		Expect(load().SetProfileValue("domain", "example.com")).To(MatchError(ContainSubstring("unknown profile setting 'domain'")))
+	})
+
+	It("fails when the selected profile does not exist", func() {
+		viper.Set("profile", "test")
+		_, _, err := load().Profile()
+		Expect(err).To(MatchError("profile 'test' does not exist (see 'config list')"))
+	})
+})
@@ -0,0 +1,311 @@
+// Package coreclient finds the releases of the fuseml command line client (distributed
+// with fuseml-core) compatible with the installer, and installs them.
+package coreclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/pkg/errors"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+)
+
+const (
+	// Name is the name of the client binary
+	Name = "fuseml"
+
+	githubReleasesURL = "https://api.github.com/repos/fuseml/fuseml-core/releases"
+	githubDownloadURL = "https://github.com/fuseml/fuseml-core/releases/download"
+
+	// suffix of the file with the SHA-256 checksum of the release archive
+	checksumSuffix = ".sha256"
+)
+
+var releaseVersionPattern = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.?[0-9]*$`)
+
+// Release covers tiny part of github release json information
+type Release struct {
+	URL        string `json:"html_url,omitempty"`
+	TagName    string `json:"tag_name,omitempty"`
+	Draft      bool   `json:"draft,omitempty"`
+	Prerelease bool   `json:"prerelease,omitempty"`
+}
+
+// Source is where the client releases are listed and downloaded from
+type Source struct {
+	// ReleasesURL returns the JSON list of releases (as the GitHub API does)
+	ReleasesURL string
+	// DownloadURL is the base of the release archive URLs: <DownloadURL>/<tag>/<archive>
+	DownloadURL string
+
+	HTTPClient *http.Client
+}
+
+// NewSource returns the GitHub releases of fuseml-core, or the mirror of them. The mirror serves
+// the list of releases at <mirror>/releases and the archives at <mirror>/download/<tag>/<archive>.
+func NewSource(mirror string) Source {
+	source := Source{
+		ReleasesURL: githubReleasesURL,
+		DownloadURL: githubDownloadURL,
+		HTTPClient:  &http.Client{Timeout: 5 * time.Minute},
+	}
+	if mirror != "" {
+		mirror = strings.TrimSuffix(mirror, "/")
+		source.ReleasesURL = mirror + "/releases"
+		source.DownloadURL = mirror + "/download"
+	}
+	return source
+}
+
+// Releases lists the client releases
+func (s Source) Releases(ctx context.Context) ([]Release, error) {
+	resp, err := s.get(ctx, s.ReleasesURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the client releases")
+	}
+	defer resp.Body.Close()
+
+	releases := []Release{}
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the releases from %s", s.ReleasesURL)
+	}
+	return releases, nil
+}
+
+// CompatibleVersion returns the latest client version compatible with the installer version
+func (s Source) CompatibleVersion(ctx context.Context, installerVersion string) (string, error) {
+	releases, err := s.Releases(ctx)
+	if err != nil {
+		return "", err
+	}
+	return CompatibleRelease(releases, installerVersion)
+}
+
+// get sends the GET request, failing on any status but OK
+func (s Source) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.New(fmt.Sprintf("%s returned %s", url, resp.Status))
+	}
+	return resp, nil
+}
+
+// IsRelease tells whether the installer version is a release (not a development build)
+func IsRelease(installerVersion string) bool {
+	return releaseVersionPattern.MatchString(installerVersion)
+}
+
+// CompatibleRelease returns the latest published release of the same major version as the released
+// installer, and not newer minor version. Any latest release is fine for the development build.
+func CompatibleRelease(releases []Release, installerVersion string) (string, error) {
+	var installer *utilversion.Version
+	if IsRelease(installerVersion) {
+		var err error
+		if installer, err = utilversion.ParseGeneric(installerVersion); err != nil {
+			return "", errors.Wrap(err, "failed to identify installer version")
+		}
+	}
+
+	var latest *utilversion.Version
+	latestTag := ""
+	for _, release := range releases {
+		if release.Draft || release.Prerelease {
+			continue
+		}
+		v, err := utilversion.ParseGeneric(release.TagName)
+		if err != nil {
+			// not a version tag
+			continue
+		}
+		if installer != nil && (v.Major() != installer.Major() || v.Minor() > installer.Minor()) {
+			continue
+		}
+		if latest == nil || latest.LessThan(v) {
+			latest, latestTag = v, release.TagName
+		}
+	}
+	if latest == nil {
+		return "", errors.New(fmt.Sprintf("failed to find client version compatible with installer version %s", installerVersion))
+	}
+	return latestTag, nil
+}
+
+// Installer downloads the client release archive for the platform, verifies its checksum
+// and installs the client binary from it
+type Installer struct {
+	Source Source
+	GOOS   string
+	GOARCH string
+	// SkipChecksum installs the client even without the checksum published
+	SkipChecksum bool
+}
+
+// NewInstaller returns the installer of the client for the current platform
+func NewInstaller(source Source) *Installer {
+	return &Installer{Source: source, GOOS: runtime.GOOS, GOARCH: runtime.GOARCH}
+}
+
+// ArchiveName returns the name of the release archive for the platform
+func (i *Installer) ArchiveName() string {
+	extension := "tar.gz"
+	if i.GOOS == "windows" {
+		extension = "zip"
+	}
+	return fmt.Sprintf("%s-%s-%s.%s", Name, i.GOOS, i.GOARCH, extension)
+}
+
+// BinaryName returns the name of the client binary for the platform
+func (i *Installer) BinaryName() string {
+	if i.GOOS == "windows" {
+		return Name + ".exe"
+	}
+	return Name
+}
+
+// Install installs the client version into the bin directory and returns the path of the binary
+func (i *Installer) Install(ctx context.Context, version, binDir string) (string, error) {
+	tmpDir, err := ioutil.TempDir("", "fuseml-client")
+	if err != nil {
+		return "", errors.Wrap(err, "can't create temp directory for download")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	url := fmt.Sprintf("%s/%s/%s", i.Source.DownloadURL, version, i.ArchiveName())
+	archive := filepath.Join(tmpDir, i.ArchiveName())
+	checksum, err := i.download(ctx, url, archive)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed downloading client from %s", url)
+	}
+	if err := i.verify(ctx, url, checksum); err != nil {
+		return "", err
+	}
+
+	extracted := filepath.Join(tmpDir, "extracted")
+	if err := os.MkdirAll(extracted, 0700); err != nil {
+		return "", err
+	}
+	if i.GOOS == "windows" {
+		err = helpers.Unzip(archive, extracted)
+	} else {
+		err = helpers.Untar(archive, extracted)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed extracting %s", i.ArchiveName())
+	}
+
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return "", errors.Wrapf(err, "failed to create bin directory '%s'", binDir)
+	}
+	path, err := filepath.Abs(filepath.Join(binDir, i.BinaryName()))
+	if err != nil {
+		return "", err
+	}
+	if err := installFile(filepath.Join(extracted, i.BinaryName()), path); err != nil {
+		return "", errors.Wrapf(err, "failed installing client as %s", path)
+	}
+	return path, nil
+}
+
+// download saves the url to the file and returns its SHA-256 checksum
+func (i *Installer) download(ctx context.Context, url, file string) (string, error) {
+	resp, err := i.Source.get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	out, err := os.Create(file)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), resp.Body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), out.Close()
+}
+
+// ChecksumMissingError means the checksum published next to the client archive could not be fetched.
+// The callers tell how to install the client without it.
+type ChecksumMissingError struct {
+	Err error
+}
+
+func (e *ChecksumMissingError) Error() string {
+	return fmt.Sprintf("failed to get the checksum of the client: %s", e.Err)
+}
+
+// verify compares the checksum with the one published next to the archive (as sha256sum writes it)
+func (i *Installer) verify(ctx context.Context, url, checksum string) error {
+	resp, err := i.Source.get(ctx, url+checksumSuffix)
+	if err != nil {
+		if i.SkipChecksum {
+			return nil
+		}
+		return &ChecksumMissingError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the checksum from %s", url+checksumSuffix)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return errors.New(fmt.Sprintf("no checksum found in %s", url+checksumSuffix))
+	}
+	if !strings.EqualFold(fields[0], checksum) {
+		return errors.New(fmt.Sprintf("checksum of %s does not match: expected %s, got %s", url, fields[0], checksum))
+	}
+	return nil
+}
+
+// installFile copies the executable to the target, replacing the existing one only when it is complete
+func installFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(target), "."+filepath.Base(target))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
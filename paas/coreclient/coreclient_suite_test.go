@@ -0,0 +1,13 @@
+package coreclient_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCoreClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Core Client Suite")
+}
@@ -0,0 +1,143 @@
+package coreclient_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/paas/coreclient"
+)
+
+var releases = []Release{
+	{TagName: "v0.4.0-rc1", Prerelease: true},
+	{TagName: "v0.3.10"},
+	{TagName: "v0.3.9"},
+	{TagName: "v0.2.1"},
+	{TagName: "nightly"},
+	{TagName: "v1.0.0", Draft: true},
+}
+
+var _ = Describe("CompatibleRelease", func() {
+	table.DescribeTable("selects the latest release of the same major and not newer minor version",
+		func(installer, expected string) {
+			Expect(CompatibleRelease(releases, installer)).To(Equal(expected))
+		},
+		table.Entry("same minor", "v0.3.0", "v0.3.10"),
+		table.Entry("newer installer", "v0.5", "v0.3.10"),
+		table.Entry("older installer", "v0.2.3", "v0.2.1"),
+		table.Entry("development build", "dev", "v0.3.10"),
+	)
+
+	It("fails when there is no compatible release", func() {
+		_, err := CompatibleRelease(releases, "v2.0.0")
+		Expect(err).To(MatchError("failed to find client version compatible with installer version v2.0.0"))
+	})
+})
+
+// archive returns the tar.gz with the client binary
+func archive(contents string) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	Expect(tw.WriteHeader(&tar.Header{Name: Name, Mode: 0755, Size: int64(len(contents)), Typeflag: tar.TypeReg})).To(Succeed())
+	_, err := tw.Write([]byte(contents))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(tw.Close()).To(Succeed())
+	Expect(gw.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+var _ = Describe("Installer", func() {
+	var (
+		server   *httptest.Server
+		files    map[string][]byte
+		binDir   string
+		releases = `[{"tag_name": "v0.3.1"}, {"tag_name": "v0.3.0"}]`
+	)
+
+	BeforeEach(func() {
+		data := archive("the client")
+		sum := sha256.Sum256(data)
+		files = map[string][]byte{
+			"/releases": []byte(releases),
+			"/download/v0.3.1/fuseml-linux-amd64.tar.gz":        data,
+			"/download/v0.3.1/fuseml-linux-amd64.tar.gz.sha256": []byte(hex.EncodeToString(sum[:]) + "  fuseml-linux-amd64.tar.gz\n"),
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, ok := files[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		}))
+
+		var err error
+		binDir, err = ioutil.TempDir("", "fuseml-bin")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(binDir)
+	})
+
+	installer := func() *Installer {
+		i := NewInstaller(NewSource(server.URL))
+		i.GOOS, i.GOARCH = "linux", "amd64"
+		return i
+	}
+
+	It("lists the releases of the mirror", func() {
+		Expect(NewSource(server.URL+"/").CompatibleVersion(context.Background(), "v0.3.0")).To(Equal("v0.3.1"))
+	})
+
+	It("installs the verified client into the bin directory", func() {
+		path, err := installer().Install(context.Background(), "v0.3.1", filepath.Join(binDir, "bin"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(path).To(Equal(filepath.Join(binDir, "bin", "fuseml")))
+
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("the client"))
+		info, err := os.Stat(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+	})
+
+	It("refuses the archive with wrong checksum", func() {
+		files["/download/v0.3.1/fuseml-linux-amd64.tar.gz.sha256"] = []byte("0123456789abcdef  fuseml-linux-amd64.tar.gz\n")
+		_, err := installer().Install(context.Background(), "v0.3.1", binDir)
+		Expect(err).To(MatchError(ContainSubstring("checksum of")))
+		Expect(filepath.Join(binDir, "fuseml")).ToNot(BeAnExistingFile())
+	})
+
+	It("requires the checksum unless skipped", func() {
+		delete(files, "/download/v0.3.1/fuseml-linux-amd64.tar.gz.sha256")
+		_, err := installer().Install(context.Background(), "v0.3.1", binDir)
+		Expect(err).To(BeAssignableToTypeOf(&ChecksumMissingError{}))
+		Expect(err).To(MatchError(ContainSubstring("failed to get the checksum of the client")))
+
+		i := installer()
+		i.SkipChecksum = true
+		_, err = i.Install(context.Background(), "v0.3.1", binDir)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("fails for the missing version", func() {
+		_, err := installer().Install(context.Background(), "v0.9.0", binDir)
+		Expect(err).To(MatchError(ContainSubstring("404 Not Found")))
+	})
+})
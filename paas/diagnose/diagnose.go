@@ -0,0 +1,382 @@
+// Package diagnose collects the state of FuseML installation into a support bundle,
+// a tarball which can be attached to issues.
+package diagnose
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/audit"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// number of the last lines collected from each container log
+	logTailLines = 1000
+	// number of the latest installer logs included in the bundle
+	installerLogs = 3
+
+	settingsNamespace = "fuseml-system"
+)
+
+// Collector gathers the diagnostic information from the cluster
+type Collector struct {
+	cluster *kubernetes.Cluster
+	// ConfigFile is the installer configuration file
+	ConfigFile string
+	// RunCommand runs the shell command and returns its output (replaceable in tests)
+	RunCommand func(ctx context.Context, command string) (string, error)
+	// RegisteredExtensions returns the extensions registered in FuseML core and the namespaces
+	// of their in-cluster endpoints (replaceable in tests)
+	RegisteredExtensions func(ctx context.Context) (interface{}, []string, error)
+
+	tw   *tar.Writer
+	root string
+	// time of the collection, used for the files in the bundle
+	now time.Time
+	// namespaces of the registered extensions, collected together with the ones owned by FuseML
+	extensionNamespaces []string
+}
+
+// NewCollector returns the collector for the cluster
+func NewCollector(cluster *kubernetes.Cluster, configFile string) *Collector {
+	return &Collector{
+		cluster:    cluster,
+		ConfigFile: configFile,
+		RunCommand: func(ctx context.Context, command string) (string, error) {
+			return helpers.RunProc(ctx, command, "", false)
+		},
+		RegisteredExtensions: func(ctx context.Context) (interface{}, []string, error) {
+			return registeredExtensions(ctx, cluster)
+		},
+	}
+}
+
+// Collect writes the gzipped tarball with the diagnostic information to w. Failures of the
+// individual parts are recorded in the bundle, only failures of writing it are returned.
+// All the collected texts are redacted.
+func (c *Collector) Collect(ctx context.Context, w io.Writer, now time.Time) error {
+	gw := gzip.NewWriter(w)
+	c.tw = tar.NewWriter(gw)
+	c.root = "fuseml-diagnose-" + now.UTC().Format("20060102-150405")
+	c.now = now
+
+	steps := []func(context.Context) error{
+		c.collectPlatform,
+		c.collectConfig,
+		c.collectExtensions,
+		c.collectNamespaces,
+	}
+	for _, step := range steps {
+		if err := step(ctx); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.tw.Close(); err != nil {
+		return errors.Wrap(err, "failed writing the tarball")
+	}
+	return gw.Close()
+}
+
+// add writes the redacted text to the file in the bundle
+func (c *Collector) add(name, content string) error {
+	content = audit.Redact(content)
+	header := &tar.Header{
+		Name:    path.Join(c.root, name),
+		Mode:    0600,
+		Size:    int64(len(content)),
+		ModTime: c.now,
+	}
+	if err := c.tw.WriteHeader(header); err != nil {
+		return errors.Wrapf(err, "failed writing %s to the tarball", name)
+	}
+	if _, err := io.WriteString(c.tw, content); err != nil {
+		return errors.Wrapf(err, "failed writing %s to the tarball", name)
+	}
+	return nil
+}
+
+// addResult writes the output of the collection, including its error
+func (c *Collector) addResult(name, content string, err error) error {
+	if err != nil {
+		content = fmt.Sprintf("failed collecting %s: %s\n%s", name, err, content)
+	}
+	return c.add(name, content)
+}
+
+// addCommand writes the output of the command
+func (c *Collector) addCommand(ctx context.Context, name, command string) error {
+	out, err := c.RunCommand(ctx, command)
+	return c.addResult(name, fmt.Sprintf("$ %s\n%s", command, out), err)
+}
+
+func (c *Collector) collectPlatform(ctx context.Context) error {
+	var b strings.Builder
+	if platform := c.cluster.GetPlatform(); platform != nil {
+		fmt.Fprintln(&b, platform.Describe())
+	}
+	if version, err := c.cluster.Kubectl.Discovery().ServerVersion(); err == nil {
+		fmt.Fprintf(&b, "Kubernetes version: %s\n", version.GitVersion)
+	} else {
+		fmt.Fprintf(&b, "Kubernetes version: unknown (%s)\n", err)
+	}
+
+	nodes, err := c.cluster.Kubectl.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err == nil {
+		fmt.Fprintln(&b, "\nNodes:")
+		for _, n := range nodes.Items {
+			fmt.Fprintf(&b, "  %s kubelet=%s os=%s cpu=%s memory=%s\n", n.Name, n.Status.NodeInfo.KubeletVersion,
+				n.Status.NodeInfo.OSImage, n.Status.Allocatable.Cpu(), n.Status.Allocatable.Memory())
+		}
+	}
+	if err := c.addResult("platform.txt", b.String(), err); err != nil {
+		return err
+	}
+
+	for _, tool := range []struct{ name, command string }{
+		{"kubectl", "kubectl version --client"},
+		{"helm", "helm version --short"},
+	} {
+		if err := c.addCommand(ctx, path.Join("tools", tool.name+".txt"), tool.command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the installer configuration, settings and checkpoints stored in the cluster and the latest installer logs
+func (c *Collector) collectConfig(ctx context.Context) error {
+	if c.ConfigFile != "" {
+		content, err := ioutil.ReadFile(c.ConfigFile)
+		if !os.IsNotExist(err) {
+			if err := c.addResult("config/config.yaml", string(content), err); err != nil {
+				return err
+			}
+		}
+	}
+
+	configMaps, err := c.cluster.Kubectl.CoreV1().ConfigMaps(settingsNamespace).List(ctx, metav1.ListOptions{})
+	if err == nil {
+		for _, cm := range configMaps.Items {
+			if !strings.HasPrefix(cm.Name, "fuseml-") {
+				continue
+			}
+			content, err := yaml.Marshal(cm.Data)
+			if err := c.addResult(path.Join("config", cm.Name+".yaml"), string(content), err); err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.ConfigFile == "" {
+		return nil
+	}
+	logs, _ := filepath.Glob(filepath.Join(filepath.Dir(c.ConfigFile), "logs", "*.log"))
+	// names start with the timestamp, the last ones are the latest
+	sort.Strings(logs)
+	if len(logs) > installerLogs {
+		logs = logs[len(logs)-installerLogs:]
+	}
+	for _, log := range logs {
+		if log == audit.Path() {
+			continue
+		}
+		content, err := ioutil.ReadFile(log)
+		if err := c.addResult(path.Join("installer-logs", filepath.Base(log)), string(content), err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Collector) collectExtensions(ctx context.Context) error {
+	extensions, namespaces, err := c.RegisteredExtensions(ctx)
+	c.extensionNamespaces = namespaces
+	content := []byte{}
+	if err == nil {
+		content, err = yaml.Marshal(extensions)
+	}
+	return c.addResult("extensions.yaml", string(content), err)
+}
+
+// everything in the namespaces owned by FuseML
+func (c *Collector) collectNamespaces(ctx context.Context) error {
+	namespaces, err := c.cluster.Kubectl.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", kubernetes.FusemlDeploymentLabelKey, kubernetes.FusemlDeploymentLabelValue),
+	})
+	if err != nil {
+		return c.addResult("namespaces.txt", "", err)
+	}
+
+	seen := map[string]bool{}
+	names := []string{}
+	for _, ns := range namespaces.Items {
+		seen[ns.Name] = true
+		names = append(names, ns.Name)
+	}
+	for _, ns := range c.extensionNamespaces {
+		if seen[ns] {
+			continue
+		}
+		if exists, _ := c.cluster.NamespaceExists(ctx, ns); exists {
+			seen[ns] = true
+			names = append(names, ns)
+		}
+	}
+	sort.Strings(names)
+	if err := c.add("namespaces.txt", strings.Join(names, "\n")+"\n"); err != nil {
+		return err
+	}
+
+	for _, ns := range names {
+		if err := c.collectPods(ctx, ns); err != nil {
+			return err
+		}
+		if err := c.collectHelmReleases(ctx, ns); err != nil {
+			return err
+		}
+		for _, objects := range []struct{ name, resources string }{
+			{"ingresses", "ingresses"},
+			{"istio", "virtualservices.networking.istio.io,gateways.networking.istio.io"},
+			{"taskruns", "taskruns.tekton.dev"},
+		} {
+			command := fmt.Sprintf("kubectl get %s --namespace %s --ignore-not-found -o yaml", objects.resources, ns)
+			if err := c.addCommand(ctx, path.Join("namespaces", ns, objects.name+".yaml"), command); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// status, events and logs of all containers of the pods
+func (c *Collector) collectPods(ctx context.Context, ns string) error {
+	pods, err := c.cluster.ListPods(ctx, ns, "")
+	if err != nil {
+		return c.addResult(path.Join("namespaces", ns, "pods.txt"), "", err)
+	}
+
+	var b strings.Builder
+	for _, pod := range pods.Items {
+		fmt.Fprintf(&b, "%s phase=%s\n", pod.Name, pod.Status.Phase)
+		for _, status := range pod.Status.ContainerStatuses {
+			fmt.Fprintf(&b, "  %s ready=%t restarts=%d\n", status.Name, status.Ready, status.RestartCount)
+		}
+	}
+	if err := c.add(path.Join("namespaces", ns, "pods.txt"), b.String()); err != nil {
+		return err
+	}
+
+	for _, pod := range pods.Items {
+		dir := path.Join("namespaces", ns, "pods", pod.Name)
+		events, err := c.cluster.GetPodEvents(ctx, ns, pod.Name)
+		if err := c.addResult(path.Join(dir, "events.txt"), events, err); err != nil {
+			return err
+		}
+
+		containers := append([]v1.Container{}, pod.Spec.InitContainers...)
+		containers = append(containers, pod.Spec.Containers...)
+		for _, container := range containers {
+			tail := int64(logTailLines)
+			logs, err := c.cluster.Kubectl.CoreV1().Pods(ns).GetLogs(pod.Name, &v1.PodLogOptions{
+				Container: container.Name,
+				TailLines: &tail,
+			}).DoRaw(ctx)
+			if err := c.addResult(path.Join(dir, container.Name+".log"), string(logs), err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Collector) collectHelmReleases(ctx context.Context, ns string) error {
+	out, err := c.RunCommand(ctx, fmt.Sprintf("helm list --all --namespace %s -q", ns))
+	if err != nil {
+		return c.addResult(path.Join("namespaces", ns, "helm.txt"), out, err)
+	}
+	for _, release := range strings.Fields(out) {
+		command := fmt.Sprintf("helm status %s --namespace %s", release, ns)
+		if err := c.addCommand(ctx, path.Join("namespaces", ns, "helm", release+".txt"), command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the extensions registered in FuseML core installed under the saved system domain, without
+// their credentials, and the namespaces of their in-cluster endpoints (http://<service>.<namespace>.svc...)
+func registeredExtensions(ctx context.Context, cluster *kubernetes.Cluster) (interface{}, []string, error) {
+	domain, err := deployments.SystemDomain(ctx, cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	if domain == "" {
+		return nil, nil, errors.New("system domain of FuseML is not known")
+	}
+	options := kubernetes.InstallationOptions{{
+		Name:  "system_domain",
+		Type:  kubernetes.StringType,
+		Value: domain,
+	}}
+	extensions, err := deployments.GetRegisteredExtensions(ctx, cluster, &options, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	namespaces := []string{}
+	for _, extension := range extensions {
+		for _, service := range extension.Services {
+			for _, credentials := range service.Credentials {
+				for key := range credentials.Configuration {
+					credentials.Configuration[key] = "<redacted>"
+				}
+			}
+			for _, endpoint := range service.Endpoints {
+				if endpoint.URL == nil {
+					continue
+				}
+				if ns := endpointNamespace(*endpoint.URL); ns != "" {
+					namespaces = append(namespaces, ns)
+				}
+			}
+		}
+	}
+	return extensions, namespaces, nil
+}
+
+// endpointNamespace returns the namespace of the in-cluster service URL, empty for other URLs
+func endpointNamespace(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(u.Hostname(), ".")
+	if len(parts) < 3 || parts[2] != "svc" {
+		return ""
+	}
+	return parts[1]
+}
@@ -0,0 +1,172 @@
+package diagnose_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	. "github.com/fuseml/fuseml/cli/paas/diagnose"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// the contents of the tarball by the file names, without the top directory
+func untar(data []byte) map[string]string {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	Expect(err).ToNot(HaveOccurred())
+	tr := tar.NewReader(gr)
+
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		Expect(err).ToNot(HaveOccurred())
+		Expect(header.Name).To(HavePrefix("fuseml-diagnose-20210601-120000/"))
+		Expect(header.ModTime.UTC()).To(Equal(time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)))
+		content, err := ioutil.ReadAll(tr)
+		Expect(err).ToNot(HaveOccurred())
+		files[strings.TrimPrefix(header.Name, "fuseml-diagnose-20210601-120000/")] = string(content)
+	}
+}
+
+func namespace(name string, owned bool) *v1.Namespace {
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if owned {
+		ns.Labels = map[string]string{kubernetes.FusemlDeploymentLabelKey: kubernetes.FusemlDeploymentLabelValue}
+	}
+	return ns
+}
+
+var _ = Describe("Collector", func() {
+	var (
+		ctx       context.Context
+		collector *Collector
+		commands  []string
+		configDir string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		commands = []string{}
+
+		var err error
+		configDir, err = ioutil.TempDir("", "fuseml-diagnose")
+		Expect(err).ToNot(HaveOccurred())
+		configFile := filepath.Join(configDir, "config.yaml")
+		Expect(ioutil.WriteFile(configFile, []byte("org: workspace\ngitea_password: s3cret\n"), 0600)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(configDir, "logs"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(configDir, "logs", "fuseml-installer-20210601-110000.log"), []byte("install log\n"), 0600)).To(Succeed())
+
+		cluster := &kubernetes.Cluster{Kubectl: fake.NewSimpleClientset(
+			namespace("fuseml-core", true),
+			namespace("mlflow", false),
+			namespace("kube-system", false),
+			&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "fuseml-core-0", Namespace: "fuseml-core"},
+				Spec: v1.PodSpec{
+					InitContainers: []v1.Container{{Name: "init"}},
+					Containers:     []v1.Container{{Name: "core"}},
+				},
+				Status: v1.PodStatus{Phase: v1.PodRunning},
+			},
+			&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"}},
+			&v1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Name: "fuseml-core-0.1", Namespace: "fuseml-core"},
+				InvolvedObject: v1.ObjectReference{Name: "fuseml-core-0"},
+				Message:        "Pulling image",
+			},
+			&v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "fuseml-settings", Namespace: "fuseml-system"},
+				Data:       map[string]string{"system_domain": "10.0.0.1.nip.io"},
+			},
+		)}
+
+		collector = NewCollector(cluster, configFile)
+		collector.RunCommand = func(ctx context.Context, command string) (string, error) {
+			commands = append(commands, command)
+			switch {
+			case strings.HasPrefix(command, "helm list") && strings.Contains(command, "fuseml-core"):
+				return "fuseml-core\n", nil
+			case strings.HasPrefix(command, "helm status"):
+				return "STATUS: deployed\n", nil
+			case strings.Contains(command, "taskruns"):
+				return "", errors.New("the server doesn't have a resource type taskruns")
+			}
+			return "", nil
+		}
+		collector.RegisteredExtensions = func(ctx context.Context) (interface{}, []string, error) {
+			return []map[string]string{{"id": "mlflow", "url": "http://mlflow.mlflow.svc.cluster.local", "token": "abc"}}, []string{"mlflow"}, nil
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(configDir)
+	})
+
+	collect := func() map[string]string {
+		var b bytes.Buffer
+		Expect(collector.Collect(ctx, &b, time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC))).To(Succeed())
+		return untar(b.Bytes())
+	}
+
+	It("collects the FuseML namespaces and the namespaces of registered extensions", func() {
+		files := collect()
+
+		Expect(files["namespaces.txt"]).To(Equal("fuseml-core\nmlflow\n"))
+		Expect(files).To(HaveKey("namespaces/fuseml-core/pods.txt"))
+		Expect(files).To(HaveKey("namespaces/mlflow/pods.txt"))
+		Expect(files).ToNot(HaveKey("namespaces/kube-system/pods.txt"))
+	})
+
+	It("collects the pod status, events and logs of all containers", func() {
+		files := collect()
+
+		Expect(files["namespaces/fuseml-core/pods.txt"]).To(Equal("fuseml-core-0 phase=Running\n"))
+		Expect(files["namespaces/fuseml-core/pods/fuseml-core-0/events.txt"]).To(ContainSubstring("Pulling image"))
+		Expect(files).To(HaveKey("namespaces/fuseml-core/pods/fuseml-core-0/init.log"))
+		Expect(files).To(HaveKey("namespaces/fuseml-core/pods/fuseml-core-0/core.log"))
+	})
+
+	It("collects helm releases and the cluster objects", func() {
+		files := collect()
+
+		Expect(files["namespaces/fuseml-core/helm/fuseml-core.txt"]).To(Equal("$ helm status fuseml-core --namespace fuseml-core\nSTATUS: deployed\n"))
+		Expect(files).ToNot(HaveKey("namespaces/mlflow/helm.txt"))
+		Expect(commands).To(ContainElement("kubectl get ingresses --namespace mlflow --ignore-not-found -o yaml"))
+		Expect(commands).To(ContainElement("kubectl get virtualservices.networking.istio.io,gateways.networking.istio.io --namespace fuseml-core --ignore-not-found -o yaml"))
+	})
+
+	It("records the failures in the bundle", func() {
+		files := collect()
+
+		Expect(files["namespaces/fuseml-core/taskruns.yaml"]).To(HavePrefix(
+			"failed collecting namespaces/fuseml-core/taskruns.yaml: the server doesn't have a resource type taskruns\n"))
+	})
+
+	It("collects the configuration, settings, installer logs and extensions", func() {
+		files := collect()
+
+		Expect(files["config/config.yaml"]).To(Equal("org: workspace\ngitea_password: <redacted>\n"))
+		Expect(files["config/fuseml-settings.yaml"]).To(Equal("system_domain: 10.0.0.1.nip.io\n"))
+		Expect(files["installer-logs/fuseml-installer-20210601-110000.log"]).To(Equal("install log\n"))
+		Expect(files["extensions.yaml"]).To(ContainSubstring("id: mlflow"))
+		Expect(files["extensions.yaml"]).To(ContainSubstring("token: <redacted>"))
+		Expect(files).To(HaveKey("platform.txt"))
+	})
+})
@@ -0,0 +1,348 @@
+// Package extensionregistry is the client of the extension registry API of fuseml-core, where the
+// installed extensions register their services, endpoints and credentials.
+package extensionregistry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+
+	"github.com/fuseml/fuseml/cli/paas/audit"
+)
+
+const (
+	// DefaultRetries is the number of retries of the requests failing with a connection error or a server error
+	DefaultRetries = 4
+	// DefaultRetryWait is the minimal wait before a retry, the wait grows exponentially up to 30 seconds
+	DefaultRetryWait = time.Second
+	// DefaultTimeout is the timeout of a single request
+	DefaultTimeout = 30 * time.Second
+
+	extensionsPath = "/extensions"
+)
+
+// Extension is the extension as registered in the registry
+// (mirrors the types of fuseml-core/gen/extension/service.go, with the JSON names of its HTTP API)
+type Extension struct {
+	ID            *string           `json:"id,omitempty"`
+	Product       *string           `json:"product,omitempty"`
+	Version       *string           `json:"version,omitempty"`
+	Description   *string           `json:"description,omitempty"`
+	Zone          *string           `json:"zone,omitempty"`
+	Configuration map[string]string `json:"configuration,omitempty"`
+	Status        *ExtensionStatus  `json:"status,omitempty"`
+	Services      []*Service        `json:"services,omitempty"`
+}
+
+// ExtensionStatus is set by the registry
+type ExtensionStatus struct {
+	Registered string `json:"registered,omitempty"`
+	Updated    string `json:"updated,omitempty"`
+}
+
+// Service is the service provided by the extension
+type Service struct {
+	ID           *string        `json:"id,omitempty"`
+	ExtensionID  *string        `json:"extension_id,omitempty"`
+	Resource     *string        `json:"resource,omitempty"`
+	Category     *string        `json:"category,omitempty"`
+	Description  *string        `json:"description,omitempty"`
+	AuthRequired *bool          `json:"auth_required,omitempty"`
+	Status       *ServiceStatus `json:"status,omitempty"`
+	Endpoints    []*Endpoint    `json:"endpoints,omitempty"`
+	Credentials  []*Credentials `json:"credentials,omitempty"`
+}
+
+// ServiceStatus is set by the registry
+type ServiceStatus struct {
+	Registered string `json:"registered,omitempty"`
+	Updated    string `json:"updated,omitempty"`
+}
+
+// Endpoint is the URL where the service is accessible
+type Endpoint struct {
+	URL           *string           `json:"url,omitempty"`
+	ExtensionID   *string           `json:"extension_id,omitempty"`
+	ServiceID     *string           `json:"service_id,omitempty"`
+	Type          *string           `json:"type,omitempty"`
+	Configuration map[string]string `json:"configuration,omitempty"`
+	Status        *EndpointStatus   `json:"status,omitempty"`
+}
+
+// EndpointStatus is set by the registry
+type EndpointStatus struct {
+}
+
+// Credentials of the service, available to the projects and users in the scope
+type Credentials struct {
+	ID            *string            `json:"id,omitempty"`
+	ExtensionID   *string            `json:"extension_id,omitempty"`
+	ServiceID     *string            `json:"service_id,omitempty"`
+	Default       *bool              `json:"default,omitempty"`
+	Scope         *string            `json:"scope,omitempty"`
+	Projects      []string           `json:"projects,omitempty"`
+	Users         []string           `json:"users,omitempty"`
+	Configuration map[string]string  `json:"configuration,omitempty"`
+	Status        *CredentialsStatus `json:"status,omitempty"`
+}
+
+// CredentialsStatus is set by the registry
+type CredentialsStatus struct {
+	Created string `json:"created,omitempty"`
+	Updated string `json:"updated,omitempty"`
+}
+
+// Query filters the listed extensions, the empty values match any extension
+type Query struct {
+	Product string
+	Version string
+	Zone    string
+	// resource and category of any service of the extension
+	ServiceResource string
+	ServiceCategory string
+}
+
+func (q Query) values() url.Values {
+	values := url.Values{}
+	for name, value := range map[string]string{
+		"product":          q.Product,
+		"version":          q.Version,
+		"zone":             q.Zone,
+		"service_resource": q.ServiceResource,
+		"service_category": q.ServiceCategory,
+	} {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	return values
+}
+
+// APIError is the unexpected response of the registry
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	// Message returned by the registry, if any
+	Message string
+}
+
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("%s %s returned %s", e.Method, e.URL, e.Status)
+	if e.Message != "" {
+		message = message + ": " + e.Message
+	}
+	return message
+}
+
+// IsNotFound tells whether the error is the response of the registry to the missing extension
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict tells whether the error is the response of the registry to the extension registered already
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+func hasStatus(err error, status int) bool {
+	apiErr, ok := errors.Cause(err).(*APIError)
+	return ok && apiErr.StatusCode == status
+}
+
+// Options of the client, the zero values are replaced by the defaults
+type Options struct {
+	// Retries of the failed requests, negative to disable the retries
+	Retries   int
+	RetryWait time.Duration
+	Timeout   time.Duration
+	// CA is the PEM encoded certificate of the CA trusted in addition to the system ones
+	CA []byte
+	// Debug logs the requests and their retries
+	Debug bool
+}
+
+// Client of the extension registry
+type Client struct {
+	// URL of fuseml-core, e.g. http://fuseml-core.<domain>
+	URL  string
+	http *retryablehttp.Client
+}
+
+// NewClient returns the client of the registry served by fuseml-core at the URL.
+// The requests are recorded in the audit log.
+func NewClient(coreURL string, options Options) *Client {
+	if options.Retries == 0 {
+		options.Retries = DefaultRetries
+	}
+	if options.RetryWait == 0 {
+		options.RetryWait = DefaultRetryWait
+	}
+	if options.Timeout == 0 {
+		options.Timeout = DefaultTimeout
+	}
+
+	client := retryablehttp.NewClient()
+	client.RetryMax = options.Retries
+	if options.Retries < 0 {
+		client.RetryMax = 0
+	}
+	client.RetryWaitMin = options.RetryWait
+	if client.RetryWaitMax < options.RetryWait {
+		client.RetryWaitMax = options.RetryWait
+	}
+	client.HTTPClient.Timeout = options.Timeout
+	if transport, ok := client.HTTPClient.Transport.(*http.Transport); ok && len(options.CA) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pool.AppendCertsFromPEM(options.CA)
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	client.HTTPClient.Transport = audit.Transport("http", false, client.HTTPClient.Transport)
+	// return the last response instead of the generic error when the retries are exhausted
+	client.ErrorHandler = retryablehttp.PassthroughErrorHandler
+	if !options.Debug {
+		// suppress the regular logger output (it logs all debug messages),
+		// it should suffice to return the final failure of http requests
+		client.Logger = log.New(ioutil.Discard, "", log.LstdFlags)
+	}
+
+	return &Client{URL: strings.TrimSuffix(coreURL, "/"), http: client}
+}
+
+// List returns the registered extensions matching the query
+func (c *Client) List(ctx context.Context, query Query) ([]Extension, error) {
+	extensions := []Extension{}
+	if err := c.do(ctx, http.MethodGet, extensionsPath, query.values(), nil, http.StatusOK, &extensions); err != nil {
+		return nil, errors.Wrap(err, "failed to list the registered extensions")
+	}
+	return extensions, nil
+}
+
+// Get returns the registered extension
+func (c *Client) Get(ctx context.Context, id string) (*Extension, error) {
+	extension := &Extension{}
+	if err := c.do(ctx, http.MethodGet, extensionPath(id), nil, nil, http.StatusOK, extension); err != nil {
+		return nil, errors.Wrapf(err, "failed to get extension %s", id)
+	}
+	return extension, nil
+}
+
+// Create registers the extension, and returns it as registered
+func (c *Client) Create(ctx context.Context, extension Extension) (*Extension, error) {
+	created := &Extension{}
+	if err := c.do(ctx, http.MethodPost, extensionsPath, nil, extension, http.StatusCreated, created); err != nil {
+		return nil, errors.Wrapf(err, "failed to register extension %s", stringValue(extension.ID))
+	}
+	return created, nil
+}
+
+// Update replaces the registered extension, and returns it as registered
+func (c *Client) Update(ctx context.Context, extension Extension) (*Extension, error) {
+	id := stringValue(extension.ID)
+	if id == "" {
+		return nil, errors.New("the ID of the extension to update is required")
+	}
+	updated := &Extension{}
+	if err := c.do(ctx, http.MethodPut, extensionPath(id), nil, extension, http.StatusOK, updated); err != nil {
+		return nil, errors.Wrapf(err, "failed to update extension %s", id)
+	}
+	return updated, nil
+}
+
+// Delete unregisters the extension
+func (c *Client) Delete(ctx context.Context, id string) error {
+	if err := c.do(ctx, http.MethodDelete, extensionPath(id), nil, nil, http.StatusNoContent, nil); err != nil {
+		return errors.Wrapf(err, "failed to unregister extension %s", id)
+	}
+	return nil
+}
+
+// do sends the request with the JSON body (if any), and decodes the response with the expected status
+// into the result (if any). Any other status is returned as APIError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}, expected int, result interface{}) error {
+	u := c.URL + path
+	if len(query) > 0 {
+		u = u + "?" + query.Encode()
+	}
+
+	var reqBody interface{}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode the request")
+		}
+		reqBody = data
+	}
+	req, err := retryablehttp.NewRequest(method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		return &APIError{
+			Method:     method,
+			URL:        u,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Message:    errorMessage(resp.Body),
+		}
+	}
+	if result == nil {
+		return nil
+	}
+	// the registry may respond with no content
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil && err != io.EOF {
+		return errors.Wrapf(err, "failed to parse the response of %s %s", method, u)
+	}
+	return nil
+}
+
+// errorMessage reads the message of the error response, given as the JSON error of fuseml-core or the plain text
+func errorMessage(body io.Reader) string {
+	data, _ := ioutil.ReadAll(io.LimitReader(body, 4096))
+	apiErr := struct {
+		Message string
+	}{}
+	if err := json.Unmarshal(data, &apiErr); err == nil && apiErr.Message != "" {
+		return apiErr.Message
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func extensionPath(id string) string {
+	return extensionsPath + "/" + url.PathEscape(id)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,13 @@
+package extensionregistry_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestExtensionRegistry(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Extension Registry Suite")
+}
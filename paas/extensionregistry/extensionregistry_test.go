@@ -0,0 +1,250 @@
+package extensionregistry_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/paas/extensionregistry"
+)
+
+func str(s string) *string {
+	return &s
+}
+
+// registry is the stand-in of the extension registry of fuseml-core
+type registry struct {
+	sync.Mutex
+	extensions map[string]Extension
+	// failures is the number of the requests failing with the server error before any is served
+	failures int
+	delay    time.Duration
+	requests []string
+	// bodies are the JSON objects sent by the client, as they are on the wire
+	bodies []map[string]interface{}
+}
+
+// decode reads the extension from the request body, recording the body
+func (r *registry) decode(req *http.Request, e *Extension) {
+	data, _ := ioutil.ReadAll(req.Body)
+	body := map[string]interface{}{}
+	json.Unmarshal(data, &body)
+	r.bodies = append(r.bodies, body)
+	json.Unmarshal(data, e)
+}
+
+func (r *registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+	r.requests = append(r.requests, req.Method+" "+req.URL.RequestURI())
+	if r.failures > 0 {
+		r.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	time.Sleep(r.delay)
+
+	id := strings.TrimPrefix(req.URL.Path, "/extensions/")
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/extensions":
+		result := []Extension{}
+		query := req.URL.Query()
+		for _, e := range r.extensions {
+			if p := query.Get("product"); p != "" && *e.Product != p {
+				continue
+			}
+			if c := query.Get("service_category"); c != "" && (len(e.Services) == 0 || *e.Services[0].Category != c) {
+				continue
+			}
+			result = append(result, e)
+		}
+		json.NewEncoder(w).Encode(result)
+	case req.Method == http.MethodPost && req.URL.Path == "/extensions":
+		e := Extension{}
+		r.decode(req, &e)
+		if _, ok := r.extensions[*e.ID]; ok {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"name":"conflict","message":"extension already registered"}`))
+			return
+		}
+		e.Status = &ExtensionStatus{Registered: "now"}
+		r.extensions[*e.ID] = e
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(e)
+	default:
+		e, ok := r.extensions[id]
+		if !ok {
+			http.Error(w, "extension not found", http.StatusNotFound)
+			return
+		}
+		switch req.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(e)
+		case http.MethodPut:
+			r.decode(req, &e)
+			e.Status = &ExtensionStatus{Registered: "now", Updated: "later"}
+			r.extensions[id] = e
+			json.NewEncoder(w).Encode(e)
+		case http.MethodDelete:
+			delete(r.extensions, id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+var _ = Describe("Client", func() {
+	var (
+		ctx     context.Context
+		stub    *registry
+		server  *httptest.Server
+		client  *Client
+		options Options
+	)
+	mlflow := Extension{
+		ID:      str("mlflow"),
+		Product: str("mlflow"),
+		Version: str("1.19.0"),
+		Services: []*Service{{
+			ID:       str("mlflow-tracking"),
+			Resource: str("mlflow-tracking"),
+			Category: str("experiment-tracking"),
+		}},
+	}
+	kfserving := Extension{
+		ID:       str("kfserving"),
+		Product:  str("kfserving"),
+		Version:  str("0.6.0"),
+		Services: []*Service{{ID: str("kfserving-api"), Category: str("prediction-serving")}},
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		stub = &registry{extensions: map[string]Extension{"mlflow": mlflow}}
+		server = httptest.NewServer(stub)
+		options = Options{RetryWait: time.Millisecond}
+		client = NewClient(server.URL+"/", options)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("registers, updates and unregisters the extensions", func() {
+		created, err := client.Create(ctx, kfserving)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created.Status.Registered).To(Equal("now"))
+
+		extension, err := client.Get(ctx, "kfserving")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*extension.Version).To(Equal("0.6.0"))
+
+		extension.Version = str("0.7.0")
+		updated, err := client.Update(ctx, *extension)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*updated.Version).To(Equal("0.7.0"))
+		Expect(updated.Status.Updated).To(Equal("later"))
+
+		Expect(client.Delete(ctx, "kfserving")).To(Succeed())
+		_, err = client.Get(ctx, "kfserving")
+		Expect(IsNotFound(err)).To(BeTrue())
+	})
+
+	It("uses the JSON names of the fuseml-core API", func() {
+		authRequired, isDefault := true, true
+		_, err := client.Create(ctx, Extension{
+			ID: str("minio"),
+			Services: []*Service{{
+				ID:           str("s3"),
+				ExtensionID:  str("minio"),
+				AuthRequired: &authRequired,
+				Endpoints:    []*Endpoint{{URL: str("http://minio:9000"), ServiceID: str("s3")}},
+				Credentials:  []*Credentials{{ID: str("default"), ExtensionID: str("minio"), Default: &isDefault}},
+			}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stub.bodies).To(HaveLen(1))
+		Expect(stub.bodies[0]).To(Equal(map[string]interface{}{
+			"id": "minio",
+			"services": []interface{}{map[string]interface{}{
+				"id":            "s3",
+				"extension_id":  "minio",
+				"auth_required": true,
+				"endpoints":     []interface{}{map[string]interface{}{"url": "http://minio:9000", "service_id": "s3"}},
+				"credentials":   []interface{}{map[string]interface{}{"id": "default", "extension_id": "minio", "default": true}},
+			}},
+		}))
+
+		core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte(`{"id":"minio","status":{"registered":"now"},"services":[{"id":"s3","extension_id":"minio",` +
+				`"auth_required":true,"endpoints":[{"url":"http://minio:9000","service_id":"s3"}],` +
+				`"credentials":[{"id":"default","extension_id":"minio","default":true,"status":{"created":"now"}}]}]}`))
+		}))
+		defer core.Close()
+		extension, err := NewClient(core.URL, options).Get(ctx, "minio")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extension.Status.Registered).To(Equal("now"))
+		service := extension.Services[0]
+		Expect(*service.ExtensionID).To(Equal("minio"))
+		Expect(*service.AuthRequired).To(BeTrue())
+		Expect(*service.Endpoints[0].ServiceID).To(Equal("s3"))
+		Expect(*service.Credentials[0].ExtensionID).To(Equal("minio"))
+		Expect(*service.Credentials[0].Default).To(BeTrue())
+		Expect(service.Credentials[0].Status.Created).To(Equal("now"))
+	})
+
+	It("lists the extensions matching the query", func() {
+		_, err := client.Create(ctx, kfserving)
+		Expect(err).ToNot(HaveOccurred())
+
+		extensions, err := client.List(ctx, Query{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extensions).To(HaveLen(2))
+
+		extensions, err = client.List(ctx, Query{Product: "mlflow", ServiceCategory: "experiment-tracking"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extensions).To(HaveLen(1))
+		Expect(*extensions[0].ID).To(Equal("mlflow"))
+		Expect(stub.requests).To(ContainElement("GET /extensions?product=mlflow&service_category=experiment-tracking"))
+	})
+
+	It("returns the typed errors of the registry", func() {
+		_, err := client.Create(ctx, mlflow)
+		Expect(IsConflict(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("returned 409 Conflict: extension already registered")))
+
+		err = client.Delete(ctx, "seldon")
+		Expect(IsNotFound(err)).To(BeTrue())
+		Expect(IsConflict(err)).To(BeFalse())
+		Expect(err).To(MatchError(ContainSubstring("failed to unregister extension seldon: DELETE " + server.URL + "/extensions/seldon returned 404 Not Found: extension not found")))
+
+		_, err = client.Update(ctx, Extension{})
+		Expect(err).To(MatchError("the ID of the extension to update is required"))
+	})
+
+	It("retries the requests failing with server errors", func() {
+		stub.failures = 2
+		extension, err := client.Get(ctx, "mlflow")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*extension.ID).To(Equal("mlflow"))
+		Expect(stub.requests).To(HaveLen(3))
+
+		stub.failures = 10
+		_, err = NewClient(server.URL, Options{Retries: 1, RetryWait: time.Millisecond}).Get(ctx, "mlflow")
+		Expect(err).To(MatchError(ContainSubstring("returned 503 Service Unavailable: unavailable")))
+		Expect(stub.requests).To(HaveLen(5))
+	})
+
+	It("times out the slow requests", func() {
+		stub.delay = 200 * time.Millisecond
+		_, err := NewClient(server.URL, Options{Retries: -1, Timeout: 50 * time.Millisecond}).List(ctx, Query{})
+		Expect(err).To(MatchError(ContainSubstring("Client.Timeout exceeded")))
+	})
+})
@@ -1,133 +1,151 @@
 package paas
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
 	"path/filepath"
-	"regexp"
-	"runtime"
-	"strings"
 
-	"github.com/fuseml/fuseml/cli/deployments"
-	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/paas/config"
+	"github.com/fuseml/fuseml/cli/paas/coreclient"
 	"github.com/fuseml/fuseml/cli/paas/ui"
 	"github.com/fuseml/fuseml/cli/paas/version"
 	"github.com/pkg/errors"
-)
 
-const (
-	coreAPIURL            = "https://api.github.com/repos/fuseml/fuseml-core/releases"
-	coreClientDownloadURL = "https://github.com/fuseml/fuseml-core/releases"
-	coreClientName        = "fuseml"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
 )
 
-// structure covering tiny part of github release json information
-type Release struct {
-	URL     string `json:"html_url,omitempty"`
-	TagName string `json:"tag_name,omitempty"`
+// CoreClientOptions are the settings of installing the fuseml command line client
+type CoreClientOptions struct {
+	// Version to install, the latest one compatible with the installer by default
+	Version string
+	// BinDir the client is installed into
+	BinDir string
+	// Mirror of the fuseml-core releases used instead of GitHub
+	Mirror string
+	// SkipChecksum installs the client even when its checksum is not published
+	SkipChecksum bool
+	// SkipChecksumFlag is the command line flag setting SkipChecksum, suggested when the checksum is missing
+	SkipChecksumFlag string
 }
 
-// Find out latest applicable version of the core client for the installer;
-// installer version provided as argument
-func latestClientForInstaller(version string) (string, error) {
+// InstallCoreClient installs the fuseml command line client and records it in the configuration
+func InstallCoreClient(ctx context.Context, ui *ui.UI, cfg *config.Config, opts CoreClientOptions) error {
+	source := coreclient.NewSource(opts.Mirror)
 
-	resp, err := http.Get(coreAPIURL)
+	clientVersion, err := selectClientVersion(ctx, ui, source, opts.Version)
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer resp.Body.Close()
+	return installCoreClient(ctx, ui, cfg, source, opts, clientVersion)
+}
 
-	var buf bytes.Buffer
-	_, _ = io.Copy(&buf, resp.Body)
+// UpgradeCoreClient replaces the fuseml client installed by the installer with the newer version
+func UpgradeCoreClient(ctx context.Context, ui *ui.UI, cfg *config.Config, opts CoreClientOptions) error {
+	if cfg.Client.Path == "" {
+		return errors.New("the fuseml client was not installed by the installer, use 'client install'")
+	}
+	source := coreclient.NewSource(opts.Mirror)
 
-	releases := []Release{}
-	err = json.Unmarshal(buf.Bytes(), &releases)
+	clientVersion, err := selectClientVersion(ctx, ui, source, opts.Version)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to parse description file")
+		return err
+	}
+	newer, err := isNewerVersion(clientVersion, cfg.Client.Version)
+	if err != nil {
+		return err
+	}
+	if !newer {
+		ui.Success().WithStringValue("Path", cfg.Client.Path).
+			Msg(fmt.Sprintf("The fuseml client %s is up to date", cfg.Client.Version))
+		return nil
 	}
 
-	installer := strings.Split(version, ".")
-	major := installer[0]
-	minor := installer[1]
-
-	clientRelease := ""
-
-	// The list of releases is already sorted from the latest one,
-	// so we do not need to check the patch version and just take the first one
-	for _, release := range releases {
-		tag := strings.Split(release.TagName, ".")
-		coreMajor := tag[0]
-		coreMinor := tag[1]
-		if major == coreMajor && minor >= coreMinor {
-			clientRelease = release.TagName
-			break
-		}
+	if opts.BinDir == "" {
+		opts.BinDir = filepath.Dir(cfg.Client.Path)
 	}
-	return clientRelease, nil
+	return installCoreClient(ctx, ui, cfg, source, opts, clientVersion)
 }
 
-// download platform specific fuseml command line client to current directory
-func downloadFuseMLCLI(ui *ui.UI, domain string) error {
+// ShowCoreClientVersion shows the version of the installed fuseml client and the one compatible with the installer
+func ShowCoreClientVersion(ctx context.Context, ui *ui.UI, cfg *config.Config, mirror string) error {
+	installed, path := cfg.Client.Version, cfg.Client.Path
+	if path == "" {
+		installed = "not installed by the installer"
+	}
 
-	ui.Note().KeeplineUnder(1).Msg("Downloading command line client...")
+	compatible, err := coreclient.NewSource(mirror).CompatibleVersion(ctx, version.Version)
+	if err != nil {
+		ui.Exclamation().Msg(fmt.Sprintf("Could not find the compatible client version: %s", err))
+		compatible = "unknown"
+	}
 
-	downloadURL := coreClientDownloadURL
-	installerVersion := version.Version
+	ui.Success().
+		WithStringValue("Installed", installed).
+		WithStringValue("Path", path).
+		WithStringValue("Compatible", compatible).
+		WithStringValue("Installer", version.Version).
+		Msg("FuseML command line client")
+	return nil
+}
 
-	isRelease, err := regexp.MatchString(`^v[0-9]+\.[0-9]+\.?[0-9]*$`, installerVersion)
+// selectClientVersion returns the explicitly requested version, or the latest one compatible with the installer
+func selectClientVersion(ctx context.Context, ui *ui.UI, source coreclient.Source, requested string) (string, error) {
+	if requested != "" {
+		return requested, nil
+	}
+	clientVersion, err := source.CompatibleVersion(ctx, version.Version)
 	if err != nil {
-		return errors.Wrap(err, "failed to identify installer version")
-	}
-
-	if isRelease {
-		clientVersion, err := latestClientForInstaller(installerVersion)
-		if err != nil {
-			return errors.Wrap(err, "failed to identify necessary client version")
-		}
-		if clientVersion == "" {
-			return errors.New(fmt.Sprintf("Failed to find correct client version for installer version %s", installerVersion))
-		}
-		ui.Note().Msg(fmt.Sprintf("For installer version %s, downloading client version %s", installerVersion, clientVersion))
-		downloadURL = downloadURL + "/download/" + clientVersion
+		return "", errors.Wrap(err, "failed to identify necessary client version")
+	}
+	if coreclient.IsRelease(version.Version) {
+		ui.Note().Msg(fmt.Sprintf("For installer version %s, using client version %s", version.Version, clientVersion))
 	} else {
-		downloadURL += "/latest/download"
-		ui.Note().Msg("Downloading latest stable version of the client")
+		ui.Note().Msg(fmt.Sprintf("Using latest stable version of the client (%s)", clientVersion))
 	}
+	return clientVersion, nil
+}
 
-	coreClientPlatform := fmt.Sprintf("%s-%s", runtime.GOOS, runtime.GOARCH)
-	name := fmt.Sprintf("%s-%s", coreClientName, coreClientPlatform)
-	extension := "tar.gz"
-	if runtime.GOOS == "windows" {
-		extension = "zip"
-	}
+func installCoreClient(ctx context.Context, ui *ui.UI, cfg *config.Config, source coreclient.Source, opts CoreClientOptions, clientVersion string) error {
+	ui.Note().KeeplineUnder(1).Msg(fmt.Sprintf("Downloading command line client %s...", clientVersion))
 
-	url := fmt.Sprintf("%s/%s.%s", downloadURL, name, extension)
-	dir, err := os.Getwd()
-	if err != nil {
-		return errors.New("Failed geting current directory")
+	binDir := opts.BinDir
+	if binDir == "" {
+		binDir = "."
 	}
-	path := filepath.Join(dir, coreClientName)
-
-	if runtime.GOOS == "windows" {
-		err = helpers.DownloadAndUnzip(url, dir)
-	} else {
-		err = helpers.DownloadAndUntar(url, dir)
+	installer := coreclient.NewInstaller(source)
+	installer.SkipChecksum = opts.SkipChecksum
+	path, err := installer.Install(ctx, clientVersion, binDir)
+	if _, missing := err.(*coreclient.ChecksumMissingError); missing && opts.SkipChecksumFlag != "" {
+		err = errors.New(fmt.Sprintf("%s (use --%s to install it anyway)", err, opts.SkipChecksumFlag))
 	}
 	if err != nil {
-		return errors.New(fmt.Sprintf("Failed downloading client from %s: %s", url, err.Error()))
+		return checkInterrupted(ctx, err, "installing the command line client")
 	}
 
-	ui.Success().Msg(fmt.Sprintf("FuseML command line client saved as %s.", path))
-	ui.Normal().Msg("    It is recommended to copy it to the location within your PATH (e.g. /usr/local/bin).")
-
-	fuseml_url := fmt.Sprintf("http://%s.%s", deployments.CoreDeploymentID, domain)
-	ui.Note().Msg(fmt.Sprintf(
-		"To use the FuseML CLI, you must point it to the FuseML server URL, e.g.:\n\n    export FUSEML_SERVER_URL=%s",
-		fuseml_url))
+	cfg.Client = config.ClientInstallation{Path: path, Version: clientVersion}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
 
+	ui.Success().Msg(fmt.Sprintf("FuseML command line client %s saved as %s.", clientVersion, path))
+	if opts.BinDir == "" {
+		ui.Normal().Msg("    It is recommended to copy it to the location within your PATH (e.g. /usr/local/bin), or use 'client install --bin-dir'.")
+	}
 	return nil
 }
+
+// isNewerVersion compares the client versions, unknown installed version is older than any
+func isNewerVersion(candidate, installed string) (bool, error) {
+	if installed == "" {
+		return true, nil
+	}
+	c, err := utilversion.ParseGeneric(candidate)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid client version %s", candidate)
+	}
+	i, err := utilversion.ParseGeneric(installed)
+	if err != nil {
+		return true, nil
+	}
+	return i.LessThan(c), nil
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/fuseml/fuseml/cli/helpers"
 	"github.com/fuseml/fuseml/cli/kubernetes"
 	"github.com/fuseml/fuseml/cli/paas/config"
+	"github.com/fuseml/fuseml/cli/paas/diagnose"
+	"github.com/fuseml/fuseml/cli/paas/preflight"
 	"github.com/fuseml/fuseml/cli/paas/ui"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -21,6 +23,9 @@ import (
 
 const (
 	DefaultTimeoutSec = 300
+
+	// installation steps recorded in checkpoints, besides the deployment IDs
+	meshCheckpoint = "mesh-and-ingress"
 )
 
 // InstallClient provides functionality for talking to Kubernetes for
@@ -33,57 +38,81 @@ type InstallClient struct {
 }
 
 // Install deploys fuseml to the cluster.
-func (c *InstallClient) Install(cmd *cobra.Command, options *kubernetes.InstallationOptions) error {
+func (c *InstallClient) Install(ctx context.Context, cmd *cobra.Command, options *kubernetes.InstallationOptions) (err error) {
 	log := c.Log.WithName("Install")
 	log.Info("start")
 	defer log.Info("return")
+	defer func() {
+		err = withResumeHint(ctx, err, "installing FuseML", resumeInstall)
+	}()
 	details := log.V(1) // NOTE: Increment of level, not absolute.
 
 	c.ui.Note().Msg("FuseML installing...")
 
-	var err error
 	details.Info("process cli options")
 	options, err = options.Populate(kubernetes.NewCLIOptionsReader(cmd))
 	if err != nil {
 		return err
 	}
 
-	interactive, err := cmd.Flags().GetBool("interactive")
+	// options are validated before anything is changed in the cluster
+	details.Info("read options")
+	options, err = c.readOptions(ctx, cmd, options, map[string]kubernetes.ChoicesFunc{
+		"extensions": c.extensionChoices(ctx),
+	})
 	if err != nil {
 		return err
 	}
 
-	if interactive {
-		details.Info("query user for options")
-		options, err = options.Populate(kubernetes.NewInteractiveOptionsReader(os.Stdout, os.Stdin))
-		if err != nil {
+	skipPreflight, err := cmd.Flags().GetBool("skip-preflight")
+	if err != nil {
+		return err
+	}
+	if !skipPreflight {
+		details.Info("run preflight checks")
+		if err := c.Preflight(ctx, *options); err != nil {
 			return err
 		}
-	} else {
-		details.Info("fill defaults into options")
-		options, err = options.Populate(kubernetes.NewDefaultOptionsReader())
-		if err != nil {
+	}
+	if err := c.checkCompatibility(ctx, options, deployments.Core{}.GetVersion(), nil); err != nil {
+		return err
+	}
+
+	resume, err := cmd.Flags().GetBool("resume")
+	if err != nil {
+		return err
+	}
+	checkpoints, err := deployments.LoadCheckpoints(ctx, c.kubeClient)
+	if err != nil {
+		return err
+	}
+	if !resume {
+		if err := checkpoints.Reset(ctx); err != nil {
 			return err
 		}
+	} else if checkpoints.Empty() {
+		c.ui.Exclamation().Msg("No completed installation steps found, installing from the beginning")
 	}
 
-	details.Info("show option configuration")
-	c.showInstallConfiguration(options)
-
-	// TODO (post MVP): Run a validation phase which perform
-	// additional checks on the values. For example range limits,
-	// proper syntax of the string, etc. do it as pghase, and late
-	// to report all problems at once, instead of early and
-	// piecemal.
-
-	deployment := deployments.Istio{Timeout: DefaultTimeoutSec}
-
-	details.Info("deploy", "Deployment", deployment.ID())
-	err = deployment.Deploy(c.kubeClient, c.ui, options.ForDeployment(deployment.ID()))
+	details.Info("choose service mesh and ingress provider")
+	mesh, provider, err := c.chooseMeshAndIngress(ctx, options, checkpoints)
 	if err != nil {
 		return err
 	}
 
+	for _, deployment := range []kubernetes.Deployment{
+		mesh.Controller(DefaultTimeoutSec),
+		provider.Controller(DefaultTimeoutSec),
+	} {
+		if deployment == nil {
+			continue
+		}
+		details.Info("deploy", "Deployment", deployment.ID())
+		if err := c.deploy(ctx, checkpoints, deployment, options); err != nil {
+			return err
+		}
+	}
+
 	// Try to give a nip.io domain if the user didn't specify one
 	domain, err := options.GetOpt("system_domain", "")
 	if err != nil {
@@ -91,15 +120,18 @@ func (c *InstallClient) Install(cmd *cobra.Command, options *kubernetes.Installa
 	}
 
 	details.Info("ensure system-domain")
-	err = c.fillInMissingSystemDomain(domain)
+	err = c.fillInMissingSystemDomain(ctx, domain)
 	if err != nil {
 		return err
 	}
 	if domain.Value.(string) == "" {
 		return errors.New("You didn't provide a system_domain and we were unable to setup a nip.io domain (couldn't find an ExternalIP)")
 	}
-	if c.kubeClient.HasKnative() {
-		err = c.setDomainForKnative(domain.Value.(string))
+	if err := deployments.SaveSystemDomain(ctx, c.kubeClient, domain.Value.(string)); err != nil {
+		return err
+	}
+	if c.kubeClient.HasKnative(ctx) {
+		err = c.setDomainForKnative(ctx, domain.Value.(string))
 		if err != nil {
 			return err
 		}
@@ -108,6 +140,7 @@ func (c *InstallClient) Install(cmd *cobra.Command, options *kubernetes.Installa
 	c.ui.Success().Msg("Created system_domain: " + domain.Value.(string))
 
 	for _, deployment := range []kubernetes.Deployment{
+		&deployments.TLS{Timeout: DefaultTimeoutSec},
 		&deployments.Workloads{Timeout: DefaultTimeoutSec},
 		&deployments.Gitea{Timeout: DefaultTimeoutSec},
 		&deployments.Registry{Timeout: DefaultTimeoutSec},
@@ -116,37 +149,176 @@ func (c *InstallClient) Install(cmd *cobra.Command, options *kubernetes.Installa
 	} {
 		details.Info("deploy", "Deployment", deployment.ID())
 
-		err := deployment.Deploy(c.kubeClient, c.ui, options.ForDeployment(deployment.ID()))
-		if err != nil {
+		if err := c.deploy(ctx, checkpoints, deployment, options); err != nil {
 			return err
 		}
 	}
 
-	if err := downloadFuseMLCLI(c.ui, domain.Value.(string)); err != nil {
-		return err
-	}
-
 	extensions, err := options.GetOpt("extensions", "")
 	if err != nil {
 		return err
 	}
 	details.Info("installing extensions")
-	if err := c.handleExtensions("install", extensions.Value.([]string), options, true); err != nil {
+	if err := c.handleExtensions(ctx, "install", extensions.Value.([]string), options, true, checkpoints); err != nil {
+		return err
+	}
+
+	// FuseML is usable without the client installed by the installer, failures are only reported
+	clientOptions := CoreClientOptions{}
+	if clientOptions.BinDir, err = cmd.Flags().GetString("client-bin-dir"); err != nil {
+		return err
+	}
+	if clientOptions.Mirror, err = cmd.Flags().GetString("client-mirror"); err != nil {
+		return err
+	}
+	if clientOptions.SkipChecksum, err = cmd.Flags().GetBool("client-skip-checksum"); err != nil {
 		return err
 	}
+	clientOptions.SkipChecksumFlag = "client-skip-checksum"
+	details.Info("installing command line client")
+	if err := InstallCoreClient(ctx, c.ui, c.config, clientOptions); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		c.ui.Exclamation().Msg(fmt.Sprintf("Failed installing the command line client: %s\n"+
+			"    Install it later with 'fuseml-installer client install'.", err))
+	}
+	coreURL := fmt.Sprintf("%s://%s.%s", deployments.URLScheme(ctx, c.kubeClient), deployments.CoreDeploymentID, domain.Value.(string))
+	c.ui.Note().Msg(fmt.Sprintf(
+		"To use the FuseML CLI, point it to the FuseML server (%s) with:\n\n    fuseml-installer login",
+		coreURL))
 
 	c.ui.Success().WithStringValue("System domain", domain.Value.(string)).Msg("FuseML installed.")
 
 	return nil
 }
 
+// chooseMeshAndIngress selects the service mesh and ingress provider and saves them. The ones
+// saved by the interrupted installation are used when it is resumed, so that e.g. Istio installed
+// by it is not mistaken for an existing one.
+func (c *InstallClient) chooseMeshAndIngress(ctx context.Context, options *kubernetes.InstallationOptions, checkpoints *deployments.Checkpoints) (*deployments.Mesh, deployments.IngressProvider, error) {
+	if checkpoints.Completed(meshCheckpoint) {
+		mesh, err := deployments.MeshFor(ctx, c.kubeClient)
+		if err != nil {
+			return nil, nil, err
+		}
+		provider, err := deployments.IngressProviderFor(ctx, c.kubeClient)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.ui.Note().
+			WithStringValue("Service mesh", mesh.Mode).
+			WithStringValue("Ingress", provider.Name()).
+			Msg("Using the service mesh and ingress chosen by the previous installation")
+		return mesh, provider, nil
+	}
+
+	mesh, err := deployments.NewMesh(ctx, c.kubeClient, *options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mesh.Mode == deployments.MeshExisting {
+		c.ui.Note().
+			WithStringValue("Revision", mesh.Revision).
+			WithStringValue("Ingress gateway", mesh.GatewayNamespace+"/"+mesh.GatewayName).
+			Msg("Using existing Istio installation")
+	}
+	provider, err := deployments.NewIngressProvider(ctx, c.kubeClient, *options, mesh)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := deployments.SaveMesh(ctx, c.kubeClient, mesh); err != nil {
+		return nil, nil, err
+	}
+	if err := deployments.SaveIngressProvider(ctx, c.kubeClient, provider); err != nil {
+		return nil, nil, err
+	}
+	if err := checkpoints.Record(ctx, meshCheckpoint); err != nil {
+		return nil, nil, err
+	}
+	return mesh, provider, nil
+}
+
+// deploy runs the deployment and records its checkpoint. Deployment completed by the interrupted
+// installation is verified instead, and deployed again only when the verification fails.
+func (c *InstallClient) deploy(ctx context.Context, checkpoints *deployments.Checkpoints, deployment kubernetes.Deployment, options *kubernetes.InstallationOptions) error {
+	if checkpoints.Completed(deployment.ID()) {
+		err := deployment.Verify(ctx, c.kubeClient)
+		if err == nil {
+			c.ui.Success().Msg(fmt.Sprintf("%s was deployed by the previous installation", deployment.ID()))
+			return nil
+		}
+		if ctx.Err() != nil {
+			return checkInterrupted(ctx, err, "verifying "+deployment.ID())
+		}
+		c.ui.Exclamation().Msg(fmt.Sprintf("%s was deployed by the previous installation, but its verification failed (%s), deploying it again", deployment.ID(), err))
+	}
+
+	err := deployment.Deploy(ctx, c.kubeClient, c.ui, options.ForDeployment(deployment.ID()))
+	if err != nil {
+		return checkInterrupted(ctx, err, "deploying "+deployment.ID())
+	}
+	return checkpoints.Record(ctx, deployment.ID())
+}
+
+// Preflight checks whether FuseML can be installed to the cluster and shows the report.
+// Error is returned when any of the checks failed, warnings are only shown.
+func (c *InstallClient) Preflight(ctx context.Context, options kubernetes.InstallationOptions) error {
+	log := c.Log.WithName("Preflight")
+	log.Info("start")
+	defer log.Info("return")
+
+	report := preflight.NewChecker(c.kubeClient, options).Run(ctx)
+
+	msg := c.ui.Normal().WithTable("Check", "Result", "Details")
+	for _, result := range report {
+		msg = msg.WithTableRow(result.Check, string(result.Status), result.Message)
+	}
+	msg.Msg("Preflight checks:")
+
+	if report.Failed() {
+		return errors.New(fmt.Sprintf("%d of the preflight checks failed", report.Count(preflight.StatusFail)))
+	}
+	if warnings := report.Count(preflight.StatusWarn); warnings > 0 {
+		c.ui.Exclamation().Msg(fmt.Sprintf("Preflight checks passed with %d warning(s)", warnings))
+	} else {
+		c.ui.Success().Msg("Preflight checks passed")
+	}
+	return nil
+}
+
+// Diagnose writes the support bundle with the state of FuseML installation to the output file
+func (c *InstallClient) Diagnose(ctx context.Context, output string) error {
+	log := c.Log.WithName("Diagnose")
+	log.Info("start")
+	defer log.Info("return")
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", output)
+	}
+	defer f.Close()
+
+	c.ui.Note().Msg("Collecting the diagnostic information...")
+	collector := diagnose.NewCollector(c.kubeClient, c.config.File())
+	if err := collector.Collect(ctx, f, time.Now()); err != nil {
+		return checkInterrupted(ctx, err, "collecting the diagnostic information")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %s", output)
+	}
+
+	c.ui.Success().WithStringValue("Support bundle", output).Msg("Diagnostic information collected. Attach the bundle to the issue.")
+	return nil
+}
+
 // find out the required extensions for an extension that is passed as an argument
 // return list of all requirements, including the given extension itself
-func (c *InstallClient) getRequirementsForExtension(extension *deployments.Extension, repo string) ([]*deployments.Extension, error) {
+func (c *InstallClient) getRequirementsForExtension(ctx context.Context, extension *deployments.Extension, repo string) ([]*deployments.Extension, error) {
 
 	ret := []*deployments.Extension{}
 	name := extension.Name
-	err := extension.LoadDescription()
+	err := extension.LoadDescription(ctx)
 	if err != nil {
 		return ret, errors.New(fmt.Sprintf("Failed to load description file of required extension %s: %s", name, err.Error()))
 	}
@@ -154,7 +326,7 @@ func (c *InstallClient) getRequirementsForExtension(extension *deployments.Exten
 	for _, req := range extension.Desc.Requires {
 
 		reqExt := deployments.NewExtension(req, repo, DefaultTimeoutSec, c.ui.Verbose())
-		sortedRequiredExtensions, _ := c.getRequirementsForExtension(reqExt, repo)
+		sortedRequiredExtensions, _ := c.getRequirementsForExtension(ctx, reqExt, repo)
 
 		for _, e := range sortedRequiredExtensions {
 			ret = append(ret, e)
@@ -164,8 +336,8 @@ func (c *InstallClient) getRequirementsForExtension(extension *deployments.Exten
 	return ret, nil
 }
 
-// install or uninstall given list of extensions
-func (c *InstallClient) handleExtensions(action string, extensions []string, options *kubernetes.InstallationOptions, withDeps bool) error {
+// install or uninstall given list of extensions, installed extensions are recorded in checkpoints (which could be nil)
+func (c *InstallClient) handleExtensions(ctx context.Context, action string, extensions []string, options *kubernetes.InstallationOptions, withDeps bool, checkpoints *deployments.Checkpoints) error {
 
 	if len(extensions) == 0 {
 		return nil
@@ -185,7 +357,7 @@ func (c *InstallClient) handleExtensions(action string, extensions []string, opt
 
 		extension := deployments.NewExtension(name, extensionRepo.Value.(string), DefaultTimeoutSec, c.ui.Verbose())
 
-		requiredExtensions, err := c.getRequirementsForExtension(extension, extensionRepo.Value.(string))
+		requiredExtensions, err := c.getRequirementsForExtension(ctx, extension, extensionRepo.Value.(string))
 		if err != nil {
 			return err
 		}
@@ -203,35 +375,67 @@ func (c *InstallClient) handleExtensions(action string, extensions []string, opt
 		}
 	}
 
+	if action == "install" {
+		// the core is already installed (or upgraded) when the extensions are installed
+		coreVersion, err := deployments.Core{}.InstalledVersion(ctx, c.kubeClient)
+		if err != nil {
+			coreVersion = ""
+		}
+		if err := c.checkCompatibility(ctx, options, coreVersion, sortedExtensions); err != nil {
+			return err
+		}
+	}
+
 	for _, extension := range sortedExtensions {
 
 		switch action {
 		case "install":
+			checkpoint := deployments.ExtensionCheckpoint(extension.Name)
+			if checkpoints.Completed(checkpoint) {
+				err = extension.Verify(ctx, c.kubeClient, options)
+				if err == nil {
+					c.ui.Success().Msg(fmt.Sprintf("Extension '%s' was installed by the previous installation", extension.Name))
+					continue
+				}
+				if ctx.Err() != nil {
+					return checkInterrupted(ctx, err, "verifying extension "+extension.Name)
+				}
+				c.ui.Exclamation().Msg(fmt.Sprintf("Extension '%s' was installed by the previous installation, but its verification failed (%s), installing it again", extension.Name, err))
+			}
+
 			c.ui.Note().KeeplineUnder(1).Msg(fmt.Sprintf("Installing extension '%s'...", extension.Name))
-			err = extension.Install(c.kubeClient, c.ui, options)
+			extension.Checkpoints = checkpoints
+			err = extension.Install(ctx, c.kubeClient, c.ui, options)
 			if err != nil {
-				return errors.New(fmt.Sprintf("Failed to install extension %s: %s", extension.Name, err.Error()))
+				return checkInterrupted(ctx, errors.New(fmt.Sprintf("Failed to install extension %s: %s", extension.Name, err.Error())),
+					"installing extension "+extension.Name)
 			}
 
 			c.ui.Note().Msg(fmt.Sprintf("Registering extension '%s'...", extension.Name))
-			err = extension.Register(c.kubeClient, c.ui, options)
+			err = extension.Register(ctx, c.kubeClient, c.ui, options)
 			if err != nil {
-				return errors.New(fmt.Sprintf("Failed to register extension %s: %s", extension.Name, err.Error()))
+				return checkInterrupted(ctx, errors.New(fmt.Sprintf("Failed to register extension %s: %s", extension.Name, err.Error())),
+					"registering extension "+extension.Name)
+			}
+			if err := checkpoints.Record(ctx, checkpoint); err != nil {
+				return err
 			}
 		case "uninstall":
 			// uninstall dependencies only when explicitly required on command line or with the command that uninstalls whole fuseml
 			// (https://github.com/fuseml/fuseml/issues/198)
 			if withDeps || helpers.StringInSlice(extensions, extension.Name) {
 				c.ui.Note().KeeplineUnder(1).Msg(fmt.Sprintf("Unregistering extension '%s'...", extension.Name))
-				err = extension.UnRegister(c.kubeClient, c.ui, options)
+				err = extension.UnRegister(ctx, c.kubeClient, c.ui, options)
 				if err != nil {
-					return errors.New(fmt.Sprintf("Failed to unregister extension %s: %s", extension.Name, err.Error()))
+					return checkInterrupted(ctx, errors.New(fmt.Sprintf("Failed to unregister extension %s: %s", extension.Name, err.Error())),
+						"unregistering extension "+extension.Name)
 				}
 
 				c.ui.Note().KeeplineUnder(1).Msg(fmt.Sprintf("Removing extension '%s'...", extension.Name))
-				err = extension.Uninstall(c.kubeClient, c.ui, options)
+				err = extension.Uninstall(ctx, c.kubeClient, c.ui, options)
 				if err != nil {
-					return errors.New(fmt.Sprintf("Failed to uninstall extension %s: %s", extension.Name, err.Error()))
+					return checkInterrupted(ctx, errors.New(fmt.Sprintf("Failed to uninstall extension %s: %s", extension.Name, err.Error())),
+						"uninstalling extension "+extension.Name)
 				}
 			} else {
 				c.ui.Note().Msg(fmt.Sprintf("Skipped removal of extension '%s'", extension.Name))
@@ -243,10 +447,9 @@ func (c *InstallClient) handleExtensions(action string, extensions []string, opt
 	return nil
 }
 
-func (c *InstallClient) listRegisteredExtensions(options *kubernetes.InstallationOptions) error {
+func (c *InstallClient) listRegisteredExtensions(ctx context.Context, options *kubernetes.InstallationOptions) error {
 
-	client := deployments.NewHttpClient(c.ui.Verbose())
-	exts, err := deployments.GetRegisteredExtensions(options, client)
+	exts, err := deployments.GetRegisteredExtensions(ctx, c.kubeClient, options, c.ui.Verbose())
 	if err != nil {
 		return err
 	}
@@ -260,26 +463,40 @@ func (c *InstallClient) listRegisteredExtensions(options *kubernetes.Installatio
 }
 
 // Uninstall removes fuseml from the cluster.
-func (c *InstallClient) Uninstall(cmd *cobra.Command, options *kubernetes.InstallationOptions) error {
+func (c *InstallClient) Uninstall(ctx context.Context, cmd *cobra.Command, options *kubernetes.InstallationOptions) (err error) {
 	log := c.Log.WithName("Uninstall")
 	log.Info("start")
 	defer log.Info("return")
-	details := log.V(1) // NOTE: Increment of level, not absolute.
+	defer func() {
+		err = withResumeHint(ctx, err, "uninstalling FuseML", resumeUninstall)
+	}()
 
-	var err error
+	details := log.V(1) // NOTE: Increment of level, not absolute.
 	details.Info("process cli options")
 	options, err = options.Populate(kubernetes.NewCLIOptionsReader(cmd))
 	if err != nil {
 		return err
 	}
-	details.Info("fill defaults into options")
-	options, err = options.Populate(kubernetes.NewDefaultOptionsReader())
+	details.Info("read options")
+	options, err = c.readOptions(ctx, cmd, options, map[string]kubernetes.ChoicesFunc{
+		"extensions": c.registeredExtensionChoices(ctx),
+	})
 	if err != nil {
 		return err
 	}
 
-	details.Info("show option configuration")
-	c.showInstallConfiguration(options)
+	keepData, err := options.GetBool("keep_data", "")
+	if err != nil {
+		return err
+	}
+	components, err := options.GetOpt("components", "")
+	if err != nil {
+		return err
+	}
+	if selected := components.Value.([]string); len(selected) > 0 {
+		details.Info("removing selected components", "Components", selected)
+		return c.uninstallComponents(ctx, selected, keepData)
+	}
 
 	domain, err := options.GetOpt("system_domain", "")
 	if err != nil {
@@ -287,7 +504,7 @@ func (c *InstallClient) Uninstall(cmd *cobra.Command, options *kubernetes.Instal
 	}
 
 	details.Info("ensure system-domain")
-	err = c.fillInMissingSystemDomain(domain)
+	err = c.fillInMissingSystemDomain(ctx, domain)
 	if err != nil {
 		return err
 	}
@@ -298,11 +515,10 @@ func (c *InstallClient) Uninstall(cmd *cobra.Command, options *kubernetes.Instal
 	}
 	exts := extensions.Value.([]string)
 	core := deployments.Core{}
-	if len(exts) == 0 && core.Installed(c.kubeClient) {
+	if len(exts) == 0 && core.Installed(ctx, c.kubeClient) {
 		details.Info("removing all registered extensions")
 
-		client := deployments.NewHttpClient(c.ui.Verbose())
-		registeredExts, err := deployments.GetRegisteredExtensions(options, client)
+		registeredExts, err := deployments.GetRegisteredExtensions(ctx, c.kubeClient, options, c.ui.Verbose())
 		if err != nil {
 			return err
 		}
@@ -312,51 +528,87 @@ func (c *InstallClient) Uninstall(cmd *cobra.Command, options *kubernetes.Instal
 	} else {
 		details.Info("removing selected extensions")
 	}
-	if err := c.handleExtensions("uninstall", exts, options, true); err != nil {
+	if err := c.handleExtensions(ctx, "uninstall", exts, options, true, nil); err != nil {
 		return err
 	}
 
 	c.ui.Note().Msg("FuseML uninstalling...")
 
-	for _, deployment := range []kubernetes.Deployment{
+	mesh, err := deployments.MeshFor(ctx, c.kubeClient)
+	if err != nil {
+		return err
+	}
+	provider, err := deployments.IngressProviderFor(ctx, c.kubeClient)
+	if err != nil {
+		return err
+	}
+	uninstallDeployments := []kubernetes.Deployment{
 		&deployments.Workloads{Timeout: DefaultTimeoutSec},
 		&deployments.Tekton{Timeout: DefaultTimeoutSec},
-		&deployments.Registry{Timeout: DefaultTimeoutSec},
-		&deployments.Gitea{Timeout: DefaultTimeoutSec},
+		&deployments.Registry{Timeout: DefaultTimeoutSec, KeepData: keepData},
+		&deployments.Gitea{Timeout: DefaultTimeoutSec, KeepData: keepData},
 		&deployments.Core{Timeout: DefaultTimeoutSec},
-		&deployments.Istio{Timeout: DefaultTimeoutSec},
+		&deployments.TLS{Timeout: DefaultTimeoutSec},
+	}
+	// existing Istio or ingress controller is never removed
+	for _, controller := range []kubernetes.Deployment{
+		provider.Controller(DefaultTimeoutSec),
+		mesh.Controller(DefaultTimeoutSec),
 	} {
+		if controller != nil {
+			uninstallDeployments = append(uninstallDeployments, controller)
+		}
+	}
+
+	for _, deployment := range uninstallDeployments {
 		details.Info("remove", "Deployment", deployment.ID())
-		err := deployment.Delete(c.kubeClient, c.ui)
+		err := deployment.Delete(ctx, c.kubeClient, c.ui)
 		if err != nil {
-			return err
+			return checkInterrupted(ctx, err, "removing "+deployment.ID())
 		}
 	}
 
+	if err := deployments.DeleteSettings(ctx, c.kubeClient, c.ui); err != nil {
+		return err
+	}
+
 	c.ui.Success().Msg("FuseML uninstalled.")
 
 	return nil
 }
 
-func (c *InstallClient) Upgrade(cmd *cobra.Command, options *kubernetes.InstallationOptions) error {
+func (c *InstallClient) Upgrade(ctx context.Context, cmd *cobra.Command, options *kubernetes.InstallationOptions) (err error) {
 	log := c.Log.WithName("Upgrade")
 	log.Info("start")
 	defer log.Info("return")
+	defer func() {
+		err = withResumeHint(ctx, err, "upgrading FuseML", resumeUpgrade)
+	}()
 	details := log.V(1)
 
 	c.ui.Note().Msg("FuseML upgrading...")
 
-	options, err := options.Populate(kubernetes.NewCLIOptionsReader(cmd))
+	options, err = options.Populate(kubernetes.NewCLIOptionsReader(cmd))
 	if err != nil {
 		return err
 	}
+	options, err = c.readSavedOptions(cmd, options)
+	if err != nil {
+		return err
+	}
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	if err := c.checkCompatibility(ctx, options, deployments.Core{}.GetVersion(), nil); err != nil {
+		return err
+	}
 
 	domain, err := options.GetOpt("system_domain", "")
 	if err != nil {
 		return err
 	}
 	details.Info("ensure system-domain")
-	err = c.fillInMissingSystemDomain(domain)
+	err = c.fillInMissingSystemDomain(ctx, domain)
 	if err != nil {
 		return err
 	}
@@ -365,9 +617,9 @@ func (c *InstallClient) Upgrade(cmd *cobra.Command, options *kubernetes.Installa
 		&deployments.Core{Timeout: DefaultTimeoutSec},
 	} {
 		details.Info("upgrade", "Deployment", deployment.ID())
-		err := deployment.Upgrade(c.kubeClient, c.ui, options.ForDeployment(deployment.ID()))
+		err := deployment.Upgrade(ctx, c.kubeClient, c.ui, options.ForDeployment(deployment.ID()))
 		if err != nil {
-			return err
+			return checkInterrupted(ctx, err, "upgrading "+deployment.ID())
 		}
 	}
 
@@ -376,21 +628,27 @@ func (c *InstallClient) Upgrade(cmd *cobra.Command, options *kubernetes.Installa
 	return nil
 }
 
-func (c *InstallClient) Extensions(cmd *cobra.Command, options *kubernetes.InstallationOptions) error {
+func (c *InstallClient) Extensions(ctx context.Context, cmd *cobra.Command, options *kubernetes.InstallationOptions) (err error) {
 	log := c.Log.WithName("Extensions")
 	log.Info("start")
 	defer log.Info("return")
+	defer func() {
+		err = withResumeHint(ctx, err, "handling the extensions", resumeExtensions)
+	}()
 	details := log.V(1)
 
 	c.ui.Note().Msg("FuseML handling the extensions...")
 
 	details.Info("process cli options")
-	options, err := options.Populate(kubernetes.NewCLIOptionsReader(cmd))
+	options, err = options.Populate(kubernetes.NewCLIOptionsReader(cmd))
 	if err != nil {
 		return err
 	}
-	details.Info("fill defaults into options")
-	options, err = options.Populate(kubernetes.NewDefaultOptionsReader())
+	details.Info("read options")
+	options, err = c.readOptions(ctx, cmd, options, map[string]kubernetes.ChoicesFunc{
+		"add":    c.extensionChoices(ctx),
+		"remove": c.registeredExtensionChoices(ctx),
+	})
 	if err != nil {
 		return err
 	}
@@ -401,7 +659,7 @@ func (c *InstallClient) Extensions(cmd *cobra.Command, options *kubernetes.Insta
 	}
 
 	details.Info("ensure system-domain")
-	err = c.fillInMissingSystemDomain(domain)
+	err = c.fillInMissingSystemDomain(ctx, domain)
 	if err != nil {
 		return err
 	}
@@ -412,7 +670,7 @@ func (c *InstallClient) Extensions(cmd *cobra.Command, options *kubernetes.Insta
 	}
 
 	details.Info("installing extensions")
-	if err := c.handleExtensions("install", addExtensions.Value.([]string), options, true); err != nil {
+	if err := c.handleExtensions(ctx, "install", addExtensions.Value.([]string), options, true, nil); err != nil {
 		return err
 	}
 
@@ -427,7 +685,7 @@ func (c *InstallClient) Extensions(cmd *cobra.Command, options *kubernetes.Insta
 	}
 
 	details.Info("removing extensions")
-	if err := c.handleExtensions("uninstall", removeExtensions.Value.([]string), options, withDeps); err != nil {
+	if err := c.handleExtensions(ctx, "uninstall", removeExtensions.Value.([]string), options, withDeps, nil); err != nil {
 		return err
 	}
 
@@ -437,7 +695,7 @@ func (c *InstallClient) Extensions(cmd *cobra.Command, options *kubernetes.Insta
 	}
 	if doList {
 		details.Info("listing extensions")
-		if err := c.listRegisteredExtensions(options); err != nil {
+		if err := c.listRegisteredExtensions(ctx, options); err != nil {
 			return err
 		}
 	}
@@ -458,28 +716,44 @@ func (c *InstallClient) showInstallConfiguration(opts *kubernetes.InstallationOp
 			m = m.WithStringValue(name, opt.Value.(string))
 		case kubernetes.IntType:
 			m = m.WithIntValue(name, opt.Value.(int))
+		case kubernetes.ListType:
+			m = m.WithStringValue(name, strings.Join(opt.Value.([]string), ", "))
 		}
 	}
 	m.Msg("Configuration...")
 }
 
-func (c *InstallClient) fillInMissingSystemDomain(domain *kubernetes.InstallationOption) error {
+func (c *InstallClient) fillInMissingSystemDomain(ctx context.Context, domain *kubernetes.InstallationOption) error {
 	if domain.Value.(string) == "" {
-		coreDomain := c.fetchExistingDomain()
+		coreDomain := c.fetchExistingDomain(ctx)
 		if coreDomain != "" {
 			domain.Value = coreDomain
 			return nil
 		}
-		service := "traefik"
-		if c.kubeClient.HasIstio() {
-			service = "istio-ingressgateway"
+		// saved by the installation which was interrupted before deploying core
+		savedDomain, err := deployments.SystemDomain(ctx, c.kubeClient)
+		if err != nil {
+			return err
+		}
+		if savedDomain != "" {
+			domain.Value = savedDomain
+			return nil
+		}
+		provider, err := deployments.IngressProviderFor(ctx, c.kubeClient)
+		if err != nil {
+			return err
+		}
+		service := provider.LoadBalancerService()
+		if service == "" {
+			return errors.New("system_domain has to be provided when the ingress controller service is not known (use ingress_service option)")
 		}
 		ip := ""
+		hostname := ""
 		s := c.ui.Progressf(fmt.Sprintf("Waiting for LoadBalancer IP on %s service.", service))
 		defer s.Stop()
-		err := helpers.RunToSuccessWithTimeout(
+		err = helpers.RunToSuccessWithTimeout(ctx,
 			func() error {
-				return c.fetchIP(&ip, service)
+				return c.fetchIP(ctx, &ip, &hostname, service)
 			}, time.Duration(2)*time.Minute, 3*time.Second)
 		if err != nil {
 			if strings.Contains(err.Error(), "Timed out after") {
@@ -494,6 +768,12 @@ func (c *InstallClient) fillInMissingSystemDomain(domain *kubernetes.Installatio
 		if ip != "" {
 			domain.Value = fmt.Sprintf("%s.nip.io", ip)
 		}
+		if ip != "" && hostname != "" {
+			s.Stop()
+			c.ui.Exclamation().Msg(fmt.Sprintf("The load balancer of %s service only provides the host name %s, "+
+				"%s is based on one of the addresses it resolves to, which may change. "+
+				"Provide a system_domain pointing to the host name for a stable domain.", service, hostname, domain.Value))
+		}
 
 	}
 
@@ -501,50 +781,51 @@ func (c *InstallClient) fillInMissingSystemDomain(domain *kubernetes.Installatio
 }
 
 /* Check if core service is already installed; if so, fetch its its address
- * from VirtualService
+ * from its VirtualService or Ingress
  */
-func (c *InstallClient) fetchExistingDomain() string {
-	if !c.kubeClient.HasIstio() {
-		return ""
-	}
-
+func (c *InstallClient) fetchExistingDomain(ctx context.Context) string {
 	core := deployments.Core{}
-	if core.Installed(c.kubeClient) {
-		coreURL, err := helpers.Kubectl(fmt.Sprintf("get VirtualService -n fuseml-core fuseml-core -o jsonpath='{.spec.hosts[0]}'"))
-		if err == nil {
-			return coreURL[len("fuseml-core."):]
+	if core.Installed(ctx, c.kubeClient) {
+		coreURL, err := core.Host(ctx, c.kubeClient)
+		if err == nil && strings.HasPrefix(coreURL, deployments.CoreDeploymentID+".") {
+			return coreURL[len(deployments.CoreDeploymentID+"."):]
 		}
 		// no reason to fail on error, we can use other means for fetching ip...
 	}
 	return ""
 }
 
-func (c *InstallClient) fetchIP(ip *string, service string) error {
-	serviceList, err := c.kubeClient.Kubectl.CoreV1().Services("").List(context.Background(), metav1.ListOptions{
+// fetchIP reads the IP of the load balancer of the service, and its host name when the IP had to be resolved from it
+func (c *InstallClient) fetchIP(ctx context.Context, ip, hostname *string, service string) error {
+	serviceList, err := c.kubeClient.Kubectl.CoreV1().Services("").List(ctx, metav1.ListOptions{
 		FieldSelector: "metadata.name=" + service,
 	})
-	if len(serviceList.Items) == 0 {
-		return errors.New(fmt.Sprintf("couldn't find the %s service", service))
-	}
 	if err != nil {
 		return err
 	}
+	if len(serviceList.Items) == 0 {
+		return errors.New(fmt.Sprintf("couldn't find the %s service", service))
+	}
 	ingress := serviceList.Items[0].Status.LoadBalancer.Ingress
 	if len(ingress) <= 0 {
 		return errors.New(fmt.Sprintf("ingress list is empty in %s service", service))
 	}
-	*ip = ingress[0].IP
+	// some platforms only provide host name of the load balancer
+	*ip, err = c.kubeClient.GetPlatform().LoadBalancerIP(ingress[0])
+	if ingress[0].IP == "" {
+		*hostname = ingress[0].Hostname
+	}
 
-	return nil
+	return err
 }
 
-func (c *InstallClient) setDomainForKnative(domain string) error {
-	knDomainConfig, err := c.kubeClient.Kubectl.CoreV1().ConfigMaps("knative-serving").Get(context.Background(), "config-domain", metav1.GetOptions{})
+func (c *InstallClient) setDomainForKnative(ctx context.Context, domain string) error {
+	knDomainConfig, err := c.kubeClient.Kubectl.CoreV1().ConfigMaps("knative-serving").Get(ctx, "config-domain", metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
 	knDomainConfig.Data[domain] = ""
-	_, err = c.kubeClient.Kubectl.CoreV1().ConfigMaps("knative-serving").Update(context.Background(), knDomainConfig, metav1.UpdateOptions{})
+	_, err = c.kubeClient.Kubectl.CoreV1().ConfigMaps("knative-serving").Update(ctx, knDomainConfig, metav1.UpdateOptions{})
 	if err != nil {
 		return errors.New("could not update Knative domain configuration")
 	}
@@ -0,0 +1,48 @@
+package paas
+
+import (
+	"context"
+	"fmt"
+)
+
+// InterruptedError is returned when the command was interrupted (e.g. by Ctrl-C)
+// while running one of its steps
+type InterruptedError struct {
+	// Step which was running when the command was interrupted
+	Step string
+	// Resume describes how to continue after the interruption
+	Resume string
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("interrupted while %s. %s", e.Step, e.Resume)
+}
+
+// checkInterrupted turns the error of a step into InterruptedError, when it was caused by
+// cancelling the context. Other errors are returned unchanged.
+func checkInterrupted(ctx context.Context, err error, step string) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	if _, ok := err.(*InterruptedError); ok {
+		return err
+	}
+	return &InterruptedError{Step: step}
+}
+
+// withResumeHint adds the hint to the interruption of the command, the step
+// defaults to the whole command when it was interrupted outside of any step
+func withResumeHint(ctx context.Context, err error, command, resume string) error {
+	err = checkInterrupted(ctx, err, command)
+	if interrupted, ok := err.(*InterruptedError); ok && interrupted.Resume == "" {
+		interrupted.Resume = resume
+	}
+	return err
+}
+
+const (
+	resumeInstall    = "Run `fuseml-installer install --resume` to finish the installation, or `fuseml-installer uninstall` to remove what was installed."
+	resumeUninstall  = "Run `fuseml-installer uninstall` again to finish removing FuseML."
+	resumeUpgrade    = "Run `fuseml-installer upgrade` again to finish the upgrade."
+	resumeExtensions = "Run the `fuseml-installer extensions` command again to finish handling the extensions."
+)
@@ -0,0 +1,110 @@
+package paas
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/paas/login"
+	"github.com/pkg/errors"
+)
+
+const loginTimeout = 10 * time.Second
+
+// LoginOptions are the settings of the login command
+type LoginOptions struct {
+	// ConfigFile is the path of the fuseml client configuration file
+	ConfigFile string
+	// Name the server is stored under, the profile name or the system domain by default
+	Name string
+	// URL of the server, discovered in the cluster when empty
+	URL string
+	// Insecure skips the verification of the server certificate
+	Insecure bool
+}
+
+// Login points the fuseml client to the FuseML server installed in the cluster: it finds
+// the URL of the core service, checks that it responds and stores it (with the Gitea URL
+// and organization) as the current server in the client configuration file.
+func (c *InstallClient) Login(ctx context.Context, opts LoginOptions) error {
+	log := c.Log.WithName("Login")
+	log.Info("start")
+	defer log.Info("return")
+	details := log.V(1)
+
+	domain := c.fetchExistingDomain(ctx)
+	if domain == "" {
+		var err error
+		if domain, err = deployments.SystemDomain(ctx, c.kubeClient); err != nil {
+			return err
+		}
+	}
+
+	server := login.Server{URL: opts.URL, Org: c.config.Org}
+	scheme := deployments.URLScheme(ctx, c.kubeClient)
+	if server.URL == "" {
+		if domain == "" {
+			return errors.New("FuseML core was not found in the cluster, is FuseML installed? (use --url to give the server URL)")
+		}
+		server.URL = fmt.Sprintf("%s://%s.%s", scheme, deployments.CoreDeploymentID, domain)
+	}
+	if host, err := (deployments.Gitea{}).Host(ctx, c.kubeClient); err == nil && host != "" {
+		server.GiteaURL = fmt.Sprintf("%s://%s", scheme, host)
+	} else {
+		details.Info("gitea host not found", "error", err)
+	}
+
+	// the CA of the self-signed certificate, nil for the other TLS modes
+	ca, err := deployments.TrustedCA(ctx, c.kubeClient)
+	if err != nil {
+		return err
+	}
+
+	details.Info("check server", "URL", server.URL)
+	if err := login.CheckServer(ctx, login.HTTPClient(opts.Insecure, ca, loginTimeout), server.URL); err != nil {
+		return checkInterrupted(ctx, err, "checking the FuseML server")
+	}
+
+	name := opts.Name
+	if name == "" {
+		profile, _, err := c.config.Profile()
+		if err != nil {
+			return err
+		}
+		name = profile
+	}
+	if name == "" {
+		name = domain
+	}
+	if name == "" {
+		name = "default"
+	}
+
+	cfg, err := login.Load(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+	if len(ca) > 0 {
+		if server.CAFile, err = cfg.WriteCA(name, ca); err != nil {
+			return err
+		}
+	}
+	cfg.AddServer(name, server)
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	c.ui.Success().
+		WithStringValue("Server", server.URL).
+		WithStringValue("Gitea", server.GiteaURL).
+		WithStringValue("Organization", server.Org).
+		WithStringValue("Client config", cfg.File()).
+		Msg(fmt.Sprintf("Logged in, the fuseml client uses the server %s", name))
+	if server.CAFile != "" {
+		c.ui.Normal().Msg(fmt.Sprintf("    The server uses the self-signed certificate, its CA is saved as %s.\n"+
+			"    Add it to your trust store, or point the fuseml client to it with:\n\n"+
+			"    export SSL_CERT_FILE=%s", server.CAFile, server.CAFile))
+	}
+	return nil
+}
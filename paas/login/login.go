@@ -0,0 +1,189 @@
+// Package login manages the configuration file of the fuseml client, pointing it
+// to the FuseML servers installed by the installer.
+package login
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+var defaultConfigFilePath = os.ExpandEnv("${HOME}/.fuseml/config.yaml")
+
+// the keys of the client configuration file managed here, the others (written by the fuseml client) are kept
+var managedKeys = []string{"server_url", "gitea_url", "org", "ca_file", "current_server", "servers"}
+
+// Server holds the settings of the fuseml client for one FuseML server
+type Server struct {
+	URL      string `json:"server_url"`
+	GiteaURL string `json:"gitea_url,omitempty"`
+	Org      string `json:"org,omitempty"`
+	// CAFile is the CA the server certificate is signed by, written for the self-signed certificates
+	CAFile string `json:"ca_file,omitempty"`
+}
+
+// ClientConfig is the configuration file of the fuseml client. The settings of the current
+// server are at its top level, where the client reads them, the other servers are kept under
+// their names, so that the client can be switched to them.
+type ClientConfig struct {
+	Server
+	CurrentServer string            `json:"current_server,omitempty"`
+	Servers       map[string]Server `json:"servers,omitempty"`
+
+	path  string
+	other map[string]interface{}
+}
+
+// DefaultLocation returns the standard location of the fuseml client configuration file
+func DefaultLocation() string {
+	return defaultConfigFilePath
+}
+
+// Load reads the client configuration file, missing file is an empty configuration
+func Load(path string) (*ClientConfig, error) {
+	cfg := &ClientConfig{
+		Servers: map[string]Server{},
+		path:    path,
+		other:   map[string]interface{}{},
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read client config file '%s'", path)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse client config file '%s'", path)
+	}
+	if err := yaml.Unmarshal(data, &cfg.other); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse client config file '%s'", path)
+	}
+	if cfg.other == nil {
+		cfg.other = map[string]interface{}{}
+	}
+	for _, key := range managedKeys {
+		delete(cfg.other, key)
+	}
+	if cfg.Servers == nil {
+		cfg.Servers = map[string]Server{}
+	}
+	return cfg, nil
+}
+
+// File returns the path of the client configuration file
+func (c *ClientConfig) File() string {
+	return c.path
+}
+
+// AddServer stores the server under the name (replacing the one of the same name) and makes it the current one
+func (c *ClientConfig) AddServer(name string, server Server) {
+	c.Servers[name] = server
+	c.CurrentServer = name
+	c.Server = server
+}
+
+// Use makes the stored server the current one
+func (c *ClientConfig) Use(name string) error {
+	server, ok := c.Servers[name]
+	if !ok {
+		return errors.New(fmt.Sprintf("server '%s' is not known (see 'login list')", name))
+	}
+	c.CurrentServer = name
+	c.Server = server
+	return nil
+}
+
+// ServerNames returns the names of all stored servers, sorted
+func (c *ClientConfig) ServerNames() []string {
+	names := []string{}
+	for name := range c.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save writes the client configuration file, keeping the settings not managed by the installer
+func (c *ClientConfig) Save() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	for key, value := range c.other {
+		values[key] = value
+	}
+	if data, err = yaml.Marshal(values); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return errors.Wrapf(err, "failed to create client config dir '%s'", filepath.Dir(c.path))
+	}
+	if err := ioutil.WriteFile(c.path, data, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write client config file '%s'", c.path)
+	}
+	return nil
+}
+
+// WriteCA saves the CA of the server stored under the name next to the configuration file
+// and returns its path
+func (c *ClientConfig) WriteCA(name string, ca []byte) (string, error) {
+	path := filepath.Join(filepath.Dir(c.path), fmt.Sprintf("%s-ca.crt", name))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", errors.Wrapf(err, "failed to create client config dir '%s'", filepath.Dir(path))
+	}
+	if err := ioutil.WriteFile(path, ca, 0600); err != nil {
+		return "", errors.Wrapf(err, "failed to write CA file '%s'", path)
+	}
+	return path, nil
+}
+
+// HTTPClient returns the client used for checking the servers. The certificates signed by the CA
+// (e.g. the self-signed ones) are trusted besides the system ones, and none is verified with insecure.
+func HTTPClient(insecure bool, ca []byte, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	} else if len(ca) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pool.AppendCertsFromPEM(ca)
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// CheckServer verifies that the server responds at the URL. Any response, but a server error, is fine.
+func CheckServer(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrapf(err, "invalid server URL '%s'", url)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "server %s does not respond", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return errors.New(fmt.Sprintf("server %s responds with %s", url, resp.Status))
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package login_test
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/fuseml/fuseml/cli/paas/login"
+)
+
+var _ = Describe("ClientConfig", func() {
+	var dir, path string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fuseml-login")
+		Expect(err).ToNot(HaveOccurred())
+		path = filepath.Join(dir, ".fuseml", "config.yaml")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("starts empty when the file does not exist", func() {
+		cfg, err := Load(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.CurrentServer).To(BeEmpty())
+		Expect(cfg.ServerNames()).To(BeEmpty())
+		Expect(cfg.File()).To(Equal(path))
+	})
+
+	It("writes the current server at the top level and keeps the others", func() {
+		cfg, err := Load(path)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.AddServer("dev", Server{URL: "http://fuseml-core.dev.example.com", GiteaURL: "http://gitea.dev.example.com", Org: "workspace"})
+		cfg.AddServer("prod", Server{URL: "https://fuseml-core.example.com", Org: "team"})
+		Expect(cfg.Save()).To(Succeed())
+
+		data, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("server_url: https://fuseml-core.example.com\n"))
+		Expect(string(data)).To(ContainSubstring("current_server: prod\n"))
+
+		cfg, err = Load(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.ServerNames()).To(Equal([]string{"dev", "prod"}))
+		Expect(cfg.Org).To(Equal("team"))
+
+		Expect(cfg.Use("dev")).To(Succeed())
+		Expect(cfg.URL).To(Equal("http://fuseml-core.dev.example.com"))
+		Expect(cfg.GiteaURL).To(Equal("http://gitea.dev.example.com"))
+		Expect(cfg.CurrentServer).To(Equal("dev"))
+	})
+
+	It("writes the CA of the server next to the configuration file", func() {
+		cfg, err := Load(path)
+		Expect(err).ToNot(HaveOccurred())
+		caFile, err := cfg.WriteCA("prod", []byte("ca"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(caFile).To(Equal(filepath.Join(dir, ".fuseml", "prod-ca.crt")))
+
+		cfg.AddServer("prod", Server{URL: "https://fuseml-core.example.com", CAFile: caFile})
+		Expect(cfg.Save()).To(Succeed())
+		data, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("ca_file: " + caFile + "\n"))
+	})
+
+	It("fails to use unknown server", func() {
+		cfg, err := Load(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Use("missing")).To(MatchError("server 'missing' is not known (see 'login list')"))
+	})
+
+	It("keeps the settings written by the fuseml client", func() {
+		Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte("server_url: http://old\nCurrentProject: mlflow\n"), 0600)).To(Succeed())
+
+		cfg, err := Load(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.URL).To(Equal("http://old"))
+		cfg.AddServer("new", Server{URL: "http://new"})
+		Expect(cfg.Save()).To(Succeed())
+
+		data, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("CurrentProject: mlflow\n"))
+		Expect(string(data)).To(ContainSubstring("server_url: http://new\n"))
+		Expect(string(data)).ToNot(ContainSubstring("http://old"))
+	})
+})
+
+var _ = Describe("CheckServer", func() {
+	client := HTTPClient(false, nil, 5*time.Second)
+
+	It("accepts any response but a server error", func() {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+		Expect(CheckServer(context.Background(), client, server.URL)).To(Succeed())
+	})
+
+	It("fails on server error", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		Expect(CheckServer(context.Background(), client, server.URL)).To(MatchError(ContainSubstring("responds with 503")))
+	})
+
+	It("fails when the server does not respond", func() {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		Expect(CheckServer(context.Background(), client, server.URL)).To(MatchError(ContainSubstring("does not respond")))
+	})
+
+	It("verifies the certificates unless insecure", func() {
+		server := httptest.NewTLSServer(http.NotFoundHandler())
+		defer server.Close()
+		Expect(CheckServer(context.Background(), client, server.URL)).ToNot(Succeed())
+		Expect(CheckServer(context.Background(), HTTPClient(true, nil, 5*time.Second), server.URL)).To(Succeed())
+	})
+
+	It("trusts the certificates signed by the CA", func() {
+		server := httptest.NewTLSServer(http.NotFoundHandler())
+		defer server.Close()
+		ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		Expect(CheckServer(context.Background(), HTTPClient(false, ca, 5*time.Second), server.URL)).To(Succeed())
+	})
+})
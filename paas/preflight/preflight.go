@@ -0,0 +1,472 @@
+// Package preflight checks whether the cluster and the local tools are able to
+// run FuseML, before anything gets installed.
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/helpers"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// Status is the outcome of a single check
+type Status string
+
+const (
+	// StatusPass means the check succeeded
+	StatusPass Status = "pass"
+	// StatusWarn means the installation could work, but likely needs attention
+	StatusWarn Status = "warn"
+	// StatusFail means the installation is not going to work
+	StatusFail Status = "fail"
+)
+
+const (
+	// MinKubernetesVersion is the oldest Kubernetes FuseML can be installed on
+	MinKubernetesVersion = "1.18.0"
+	// MaxKubernetesVersion is the newest minor version of Kubernetes FuseML was tested with,
+	// any patch release of it is supported
+	MaxKubernetesVersion = "1.22"
+
+	minHelmVersion = "3.0.0"
+	minGitVersion  = "2.0.0"
+)
+
+// Minimal free capacity of the cluster needed by the FuseML core components
+var (
+	MinFreeCPU    = resource.MustParse("2")
+	MinFreeMemory = resource.MustParse("4Gi")
+)
+
+// Result is the outcome of a single check
+type Result struct {
+	Check   string
+	Status  Status
+	Message string
+}
+
+// Report holds the results of all checks
+type Report []Result
+
+// Failed returns true when at least one of the checks failed
+func (r Report) Failed() bool {
+	return r.Count(StatusFail) > 0
+}
+
+// Count returns the number of checks with given status
+func (r Report) Count(status Status) int {
+	count := 0
+	for _, result := range r {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// permission is a set of verbs FuseML deployments need on a resource
+type permission struct {
+	group    string
+	resource string
+	verbs    []string
+}
+
+// crud are all verbs needed to manage a resource, including watch for waiting on it
+var crud = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+
+// requiredPermissions are the cluster-wide permissions used by the deployments (and the helm charts they install)
+var requiredPermissions = []permission{
+	{group: "", resource: "namespaces", verbs: crud},
+	{group: "", resource: "services", verbs: crud},
+	{group: "", resource: "secrets", verbs: crud},
+	{group: "", resource: "configmaps", verbs: crud},
+	{group: "", resource: "serviceaccounts", verbs: crud},
+	{group: "", resource: "persistentvolumeclaims", verbs: crud},
+	{group: "", resource: "pods", verbs: []string{"get", "list", "watch"}},
+	{group: "apps", resource: "deployments", verbs: crud},
+	{group: "apps", resource: "statefulsets", verbs: crud},
+	{group: "batch", resource: "jobs", verbs: crud},
+	{group: "networking.k8s.io", resource: "ingresses", verbs: crud},
+	{group: "rbac.authorization.k8s.io", resource: "clusterroles", verbs: crud},
+	{group: "rbac.authorization.k8s.io", resource: "clusterrolebindings", verbs: crud},
+	{group: "rbac.authorization.k8s.io", resource: "roles", verbs: crud},
+	{group: "rbac.authorization.k8s.io", resource: "rolebindings", verbs: crud},
+	{group: "apiextensions.k8s.io", resource: "customresourcedefinitions", verbs: crud},
+	{group: "admissionregistration.k8s.io", resource: "mutatingwebhookconfigurations", verbs: crud},
+	{group: "admissionregistration.k8s.io", resource: "validatingwebhookconfigurations", verbs: crud},
+	{group: "tekton.dev", resource: "pipelines", verbs: crud},
+	{group: "tekton.dev", resource: "tasks", verbs: crud},
+	{group: "tekton.dev", resource: "pipelineruns", verbs: crud},
+	{group: "triggers.tekton.dev", resource: "eventlisteners", verbs: crud},
+	{group: "triggers.tekton.dev", resource: "triggertemplates", verbs: crud},
+	{group: "triggers.tekton.dev", resource: "triggerbindings", verbs: crud},
+	{group: "serving.knative.dev", resource: "services", verbs: crud},
+	{group: "operator.knative.dev", resource: "knativeservings", verbs: crud},
+}
+
+// meshPermissions are needed unless the installation runs without the service mesh
+var meshPermissions = []permission{
+	{group: "networking.istio.io", resource: "gateways", verbs: crud},
+	{group: "networking.istio.io", resource: "virtualservices", verbs: crud},
+	{group: "networking.istio.io", resource: "destinationrules", verbs: crud},
+	{group: "security.istio.io", resource: "peerauthentications", verbs: crud},
+}
+
+// Checker runs the preflight checks
+type Checker struct {
+	cluster *kubernetes.Cluster
+	// options of the installation, the checks of the unset options assume they are going to be detected
+	options kubernetes.InstallationOptions
+	// RunCommand runs a local command and returns its output (replaceable in tests)
+	RunCommand func(ctx context.Context, command string) (string, error)
+}
+
+// NewChecker constructs a checker for the installation to the given cluster with the options
+func NewChecker(cluster *kubernetes.Cluster, options kubernetes.InstallationOptions) *Checker {
+	return &Checker{
+		cluster: cluster,
+		options: options,
+		RunCommand: func(ctx context.Context, command string) (string, error) {
+			return helpers.RunProc(ctx, command, "", false)
+		},
+	}
+}
+
+// Run executes all checks. Problems with running a check are reported as its failure.
+func (p *Checker) Run(ctx context.Context) Report {
+	report := Report{}
+	serverVersion, result := p.checkKubernetesVersion()
+	report = append(report, result)
+	report = append(report,
+		p.checkPermissions(ctx),
+		p.checkPlatform(),
+		p.checkStorageClass(),
+		p.checkLoadBalancer(ctx),
+		p.checkIstio(ctx),
+		p.checkIngress(ctx),
+		p.checkKnative(ctx),
+		p.checkCapacity(ctx),
+	)
+	report = append(report, p.checkTools(ctx, serverVersion)...)
+	return report
+}
+
+func (p *Checker) checkKubernetesVersion() (*version.Version, Result) {
+	result := Result{Check: "Kubernetes version"}
+	info, err := p.cluster.Kubectl.Discovery().ServerVersion()
+	if err != nil {
+		return nil, result.fail("failed to get server version: %s", err)
+	}
+	serverVersion, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return nil, result.fail("failed to parse server version %s: %s", info.GitVersion, err)
+	}
+	if serverVersion.LessThan(version.MustParseGeneric(MinKubernetesVersion)) {
+		return serverVersion, result.fail("%s is not supported, at least %s is required", info.GitVersion, MinKubernetesVersion)
+	}
+	if newerMinor(serverVersion, version.MustParseGeneric(MaxKubernetesVersion)) {
+		return serverVersion, result.warn("%s is newer than the tested versions (up to %s)", info.GitVersion, MaxKubernetesVersion)
+	}
+	return serverVersion, result.pass("%s", info.GitVersion)
+}
+
+// checkPermissions asks the API server about every required verb, as there is no way
+// to find out whether the user is cluster-admin
+func (p *Checker) checkPermissions(ctx context.Context) Result {
+	result := Result{Check: "Permissions"}
+	denied := []string{}
+	permissions := append([]permission{}, requiredPermissions...)
+	if p.option("mesh") != deployments.MeshNone {
+		permissions = append(permissions, meshPermissions...)
+	}
+	for _, permission := range permissions {
+		for _, verb := range permission.verbs {
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Group:    permission.group,
+						Resource: permission.resource,
+						Verb:     verb,
+					},
+				},
+			}
+			review, err := p.cluster.Kubectl.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+			if err != nil {
+				return result.fail("failed to review access: %s", err)
+			}
+			if !review.Status.Allowed {
+				denied = append(denied, fmt.Sprintf("%s %s", verb, qualifiedResource(permission)))
+			}
+		}
+	}
+	if len(denied) > 0 {
+		return result.fail("missing permissions (cluster-admin is required): %s", strings.Join(denied, ", "))
+	}
+	return result.pass("all required permissions granted")
+}
+
+func qualifiedResource(permission permission) string {
+	if permission.group == "" {
+		return permission.resource
+	}
+	return permission.resource + "." + permission.group
+}
+
+// checkPlatform warns about the platforms that are detected, but FuseML does not support
+func (p *Checker) checkPlatform() Result {
+	result := Result{Check: "Platform"}
+	platform := kubernetes.DetectPlatform(p.cluster.Kubectl)
+	if platform.String() == "openshift" {
+		return result.warn("OpenShift is not supported, FuseML pods are not granted security context constraints " +
+			"and the services are not exposed through Routes (see docs/install.md)")
+	}
+	return result.pass("%s", platform.String())
+}
+
+func (p *Checker) checkStorageClass() Result {
+	result := Result{Check: "Default StorageClass"}
+	platform := kubernetes.DetectPlatform(p.cluster.Kubectl)
+	if err := platform.Load(p.cluster.Kubectl); err != nil {
+		return result.fail("failed to inspect the cluster: %s", err)
+	}
+	if platform.DefaultStorageClass() == "" {
+		return result.fail("no default StorageClass, volumes of Gitea and the registry can't be provisioned")
+	}
+	return result.pass("%s", platform.DefaultStorageClass())
+}
+
+// checkLoadBalancer looks at existing LoadBalancer services, as the support can't be
+// verified without creating one
+func (p *Checker) checkLoadBalancer(ctx context.Context) Result {
+	result := Result{Check: "LoadBalancer"}
+	services, err := p.cluster.Kubectl.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return result.fail("failed to list services: %s", err)
+	}
+	pending := []string{}
+	for _, service := range services.Items {
+		if service.Spec.Type != v1.ServiceTypeLoadBalancer {
+			continue
+		}
+		if len(service.Status.LoadBalancer.Ingress) > 0 {
+			return result.pass("service %s/%s has an external address", service.Namespace, service.Name)
+		}
+		pending = append(pending, service.Namespace+"/"+service.Name)
+	}
+	if domain := p.option("system_domain"); domain != "" {
+		return result.pass("not required, system_domain %s is provided", domain)
+	}
+	if len(pending) > 0 {
+		return result.warn("services without an external address: %s (system_domain has to be provided)", strings.Join(pending, ", "))
+	}
+	return result.warn("no LoadBalancer services found, make sure the cluster can provide external IPs (see docs/install.md)")
+}
+
+// checkIstio verifies the Istio installation requested by the mesh option
+func (p *Checker) checkIstio(ctx context.Context) Result {
+	result := Result{Check: "Istio"}
+	istio, err := p.cluster.DetectIstio(ctx)
+	if err != nil {
+		return result.fail("%s", err)
+	}
+	mesh := p.option("mesh")
+	if istio == nil {
+		switch mesh {
+		case deployments.MeshExisting:
+			return result.fail("existing mesh was requested, but no Istio installation was found")
+		case deployments.MeshNone:
+			return result.pass("not installed, not used")
+		}
+		return result.pass("not installed")
+	}
+	if mesh == deployments.MeshNone {
+		return result.pass("found in %s (revision %s), not used", istio.Namespace, istio.Revision)
+	}
+	if istio.GatewayName == "" {
+		if mesh == deployments.MeshExisting && p.option("ingress") == deployments.IngressIstio {
+			return result.fail("found in %s (revision %s), but without an ingress gateway", istio.Namespace, istio.Revision)
+		}
+		return result.warn("found in %s (revision %s), but without an ingress gateway", istio.Namespace, istio.Revision)
+	}
+	return result.pass("found in %s (revision %s)", istio.Namespace, istio.Revision)
+}
+
+// checkIngress verifies the ingress requested by the ingress options
+func (p *Checker) checkIngress(ctx context.Context) Result {
+	result := Result{Check: "Ingress"}
+	ingress := p.option("ingress")
+	switch ingress {
+	case "":
+		return result.pass("detected at installation")
+	case deployments.IngressIstio:
+		if p.option("mesh") == deployments.MeshNone {
+			return result.fail("istio ingress can't be used without the service mesh")
+		}
+		return result.pass("istio")
+	case deployments.IngressExisting:
+		classes, defaultClass, err := p.cluster.IngressClasses(ctx)
+		if err != nil {
+			return result.fail("%s", err)
+		}
+		class := p.option("ingress_class")
+		if class == "" && defaultClass == "" && len(classes) != 1 {
+			return result.fail("no default IngressClass in the cluster, ingress_class has to be provided")
+		}
+		if p.option("ingress_service") == "" && p.option("system_domain") == "" {
+			return result.warn("existing ingress controller without ingress_service, system_domain has to be provided")
+		}
+		return result.pass("existing")
+	}
+	return result.pass("%s (installed by FuseML)", ingress)
+}
+
+// checkKnative reports whether Knative is installed, the installer then configures it for the system domain
+func (p *Checker) checkKnative(ctx context.Context) Result {
+	result := Result{Check: "Knative"}
+	if p.cluster.HasKnative(ctx) {
+		return result.pass("found")
+	}
+	return result.pass("not installed")
+}
+
+// checkCapacity compares the allocatable resources of schedulable nodes to the requests of running pods
+func (p *Checker) checkCapacity(ctx context.Context) Result {
+	result := Result{Check: "Node capacity"}
+	nodes, err := p.cluster.Kubectl.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return result.fail("failed to list nodes: %s", err)
+	}
+	cpu := resource.Quantity{}
+	memory := resource.Quantity{}
+	schedulable := map[string]bool{}
+	for _, node := range nodes.Items {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		schedulable[node.Name] = true
+		cpu.Add(node.Status.Allocatable[v1.ResourceCPU])
+		memory.Add(node.Status.Allocatable[v1.ResourceMemory])
+	}
+
+	pods, err := p.cluster.Kubectl.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return result.fail("failed to list pods: %s", err)
+	}
+	for _, pod := range pods.Items {
+		if !schedulable[pod.Spec.NodeName] || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			cpu.Sub(container.Resources.Requests[v1.ResourceCPU])
+			memory.Sub(container.Resources.Requests[v1.ResourceMemory])
+		}
+	}
+
+	message := fmt.Sprintf("%s CPU and %s memory free", cpu.String(), memory.String())
+	if cpu.Cmp(MinFreeCPU) < 0 || memory.Cmp(MinFreeMemory) < 0 {
+		return result.warn("%s, at least %s CPU and %s memory are recommended", message, MinFreeCPU.String(), MinFreeMemory.String())
+	}
+	return result.pass("%s", message)
+}
+
+// checkTools verifies the versions of the commands required by checkDependencies
+func (p *Checker) checkTools(ctx context.Context, serverVersion *version.Version) []Result {
+	return []Result{
+		p.checkKubectl(ctx, serverVersion),
+		p.checkTool(ctx, "helm", "helm version --short", minHelmVersion),
+		p.checkTool(ctx, "git", "git --version", minGitVersion),
+	}
+}
+
+// option returns the value of the string option, empty when it is not set
+func (p *Checker) option(name string) string {
+	value, _ := p.options.GetString(name, "")
+	return value
+}
+
+// newerMinor tells whether the version has a newer minor version than max
+func newerMinor(v, max *version.Version) bool {
+	if v.Major() != max.Major() {
+		return v.Major() > max.Major()
+	}
+	return v.Minor() > max.Minor()
+}
+
+// kubectl is supported within one minor version of the server
+func (p *Checker) checkKubectl(ctx context.Context, serverVersion *version.Version) Result {
+	result := Result{Check: "kubectl"}
+	out, err := p.RunCommand(ctx, "kubectl version --client -o json")
+	if err != nil {
+		return result.fail("failed to run kubectl: %s", err)
+	}
+	info := struct {
+		ClientVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"clientVersion"`
+	}{}
+	if err := json.Unmarshal([]byte(out), &info); err != nil {
+		return result.fail("failed to parse kubectl version: %s", err)
+	}
+	clientVersion, err := version.ParseGeneric(info.ClientVersion.GitVersion)
+	if err != nil {
+		return result.fail("failed to parse kubectl version %s: %s", info.ClientVersion.GitVersion, err)
+	}
+	if serverVersion != nil && clientVersion.Major() == serverVersion.Major() {
+		skew := int(clientVersion.Minor()) - int(serverVersion.Minor())
+		if skew > 1 || skew < -1 {
+			return result.warn("%s is more than one minor version away from the server", info.ClientVersion.GitVersion)
+		}
+	}
+	return result.pass("%s", info.ClientVersion.GitVersion)
+}
+
+var versionRegexp = regexp.MustCompile(`v?\d+\.\d+(\.\d+)?`)
+
+func (p *Checker) checkTool(ctx context.Context, name, command, minVersion string) Result {
+	result := Result{Check: name}
+	out, err := p.RunCommand(ctx, command)
+	if err != nil {
+		return result.fail("failed to run %s: %s", name, err)
+	}
+	found := versionRegexp.FindString(out)
+	if found == "" {
+		return result.fail("failed to find the version in %q", strings.TrimSpace(out))
+	}
+	toolVersion, err := version.ParseGeneric(found)
+	if err != nil {
+		return result.fail("failed to parse %s version %s: %s", name, found, err)
+	}
+	if toolVersion.LessThan(version.MustParseGeneric(minVersion)) {
+		return result.fail("%s is not supported, at least %s is required", found, minVersion)
+	}
+	return result.pass("%s", found)
+}
+
+func (r Result) pass(format string, a ...interface{}) Result {
+	return r.with(StatusPass, format, a...)
+}
+
+func (r Result) warn(format string, a ...interface{}) Result {
+	return r.with(StatusWarn, format, a...)
+}
+
+func (r Result) fail(format string, a ...interface{}) Result {
+	return r.with(StatusFail, format, a...)
+}
+
+func (r Result) with(status Status, format string, a ...interface{}) Result {
+	r.Status = status
+	r.Message = fmt.Sprintf(format, a...)
+	return r
+}
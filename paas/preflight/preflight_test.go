@@ -0,0 +1,263 @@
+package preflight_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	. "github.com/fuseml/fuseml/cli/paas/preflight"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func node(cpu, memory string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-0"},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse(cpu),
+				v1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+}
+
+func pod(cpu, memory string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: "default"},
+		Spec: v1.PodSpec{
+			NodeName: "node-0",
+			Containers: []v1.Container{{
+				Name: "main",
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse(cpu),
+					v1.ResourceMemory: resource.MustParse(memory),
+				}},
+			}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+var defaultStorageClass = &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{
+	Name:        "standard",
+	Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"},
+}}
+
+var loadBalancer = &v1.Service{
+	ObjectMeta: metav1.ObjectMeta{Name: "traefik", Namespace: "kube-system"},
+	Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+	Status: v1.ServiceStatus{LoadBalancer: v1.LoadBalancerStatus{
+		Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+	}},
+}
+
+var tools = map[string]string{
+	"kubectl version --client -o json": `{"clientVersion": {"gitVersion": "v1.21.0"}}`,
+	"helm version --short":             "v3.6.3+gd506314\n",
+	"git --version":                    "git version 2.31.1\n",
+}
+
+// options returns the string options from the name and value pairs
+func options(pairs ...string) kubernetes.InstallationOptions {
+	opts := kubernetes.InstallationOptions{}
+	for i := 0; i < len(pairs); i += 2 {
+		opts = append(opts, kubernetes.InstallationOption{Name: pairs[i], Type: kubernetes.StringType, Value: pairs[i+1]})
+	}
+	return opts
+}
+
+func result(report Report, check string) Result {
+	for _, r := range report {
+		if r.Check == check {
+			return r
+		}
+	}
+	Fail("no result for " + check)
+	return Result{}
+}
+
+var _ = Describe("Checker", func() {
+	var kube *fake.Clientset
+	var checker *Checker
+	var allowed func(*authorizationv1.ResourceAttributes) bool
+
+	run := func() Report {
+		return checker.Run(context.Background())
+	}
+
+	BeforeEach(func() {
+		kube = fake.NewSimpleClientset([]runtime.Object{
+			node("4", "8Gi"),
+			pod("500m", "1Gi"),
+			defaultStorageClass,
+			loadBalancer,
+		}...)
+		kube.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.21.2+k3s1"}
+		allowed = func(*authorizationv1.ResourceAttributes) bool { return true }
+		kube.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			review.Status.Allowed = allowed(review.Spec.ResourceAttributes)
+			return true, review, nil
+		})
+
+		checker = NewChecker(&kubernetes.Cluster{Kubectl: kube}, nil)
+		checker.RunCommand = func(ctx context.Context, command string) (string, error) {
+			out, ok := tools[command]
+			if !ok {
+				return "", errors.New("command not found")
+			}
+			return out, nil
+		}
+	})
+
+	It("passes on a suitable cluster", func() {
+		report := run()
+		Expect(report.Failed()).To(BeFalse())
+		Expect(report.Count(StatusWarn)).To(Equal(0))
+		Expect(result(report, "Node capacity").Message).To(Equal("3500m CPU and 7Gi memory free"))
+		Expect(result(report, "helm").Message).To(Equal("v3.6.3"))
+	})
+
+	It("fails on old kubernetes", func() {
+		kube.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.17.4"}
+		Expect(result(run(), "Kubernetes version")).To(MatchFields(IgnoreExtras, Fields{
+			"Status":  Equal(StatusFail),
+			"Message": ContainSubstring("at least 1.18.0 is required"),
+		}))
+	})
+
+	It("warns about kubernetes newer than tested", func() {
+		kube.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.23.0"}
+		Expect(result(run(), "Kubernetes version").Status).To(Equal(StatusWarn))
+	})
+
+	It("supports all patch releases of the newest tested kubernetes", func() {
+		kube.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.22.117"}
+		Expect(result(run(), "Kubernetes version").Status).To(Equal(StatusPass))
+	})
+
+	It("requires watching and the mesh resources", func() {
+		allowed = func(attributes *authorizationv1.ResourceAttributes) bool {
+			return attributes.Verb != "watch" || attributes.Resource != "virtualservices"
+		}
+		Expect(result(run(), "Permissions").Message).To(HaveSuffix(": watch virtualservices.networking.istio.io"))
+	})
+
+	It("does not require the mesh resources without the mesh", func() {
+		allowed = func(attributes *authorizationv1.ResourceAttributes) bool {
+			return attributes.Group != "networking.istio.io"
+		}
+		checker = NewChecker(&kubernetes.Cluster{Kubectl: kube}, options("mesh", "none"))
+		checker.RunCommand = func(ctx context.Context, command string) (string, error) { return tools[command], nil }
+		Expect(result(run(), "Permissions").Status).To(Equal(StatusPass))
+	})
+
+	It("fails when the existing mesh is requested without Istio", func() {
+		checker = NewChecker(&kubernetes.Cluster{Kubectl: kube}, options("mesh", "existing"))
+		Expect(result(run(), "Istio")).To(Equal(Result{
+			Check:   "Istio",
+			Status:  StatusFail,
+			Message: "existing mesh was requested, but no Istio installation was found",
+		}))
+	})
+
+	It("fails for istio ingress without the mesh", func() {
+		checker = NewChecker(&kubernetes.Cluster{Kubectl: kube}, options("mesh", "none", "ingress", "istio"))
+		Expect(result(run(), "Ingress").Status).To(Equal(StatusFail))
+	})
+
+	It("fails for existing ingress without any ingress class", func() {
+		kube.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+			{GroupVersion: "networking.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "ingresses"}, {Name: "ingressclasses"}}},
+		}
+		checker = NewChecker(&kubernetes.Cluster{Kubectl: kube}, options("ingress", "existing"))
+		Expect(result(run(), "Ingress")).To(Equal(Result{
+			Check:   "Ingress",
+			Status:  StatusFail,
+			Message: "no default IngressClass in the cluster, ingress_class has to be provided",
+		}))
+	})
+
+	It("lists all missing permissions", func() {
+		allowed = func(attributes *authorizationv1.ResourceAttributes) bool {
+			return attributes.Resource != "clusterroles" || attributes.Verb != "create"
+		}
+		report := run()
+		Expect(report.Failed()).To(BeTrue())
+		Expect(result(report, "Permissions").Message).To(HaveSuffix(": create clusterroles.rbac.authorization.k8s.io"))
+	})
+
+	It("warns about OpenShift", func() {
+		kube.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+			{GroupVersion: "route.openshift.io/v1", APIResources: []metav1.APIResource{{Name: "routes"}}},
+		}
+		Expect(result(run(), "Platform").Status).To(Equal(StatusWarn))
+	})
+
+	It("fails without default storage class", func() {
+		Expect(kube.StorageV1().StorageClasses().Delete(context.Background(), "standard", metav1.DeleteOptions{})).To(Succeed())
+		Expect(result(run(), "Default StorageClass").Status).To(Equal(StatusFail))
+	})
+
+	It("warns about load balancer services without an address", func() {
+		kube = fake.NewSimpleClientset(node("4", "8Gi"), defaultStorageClass, &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "default"},
+			Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+		})
+		checker = NewChecker(&kubernetes.Cluster{Kubectl: kube}, nil)
+		Expect(checker.Run(context.Background())).To(ContainElement(Result{
+			Check:   "LoadBalancer",
+			Status:  StatusWarn,
+			Message: "services without an external address: default/gateway (system_domain has to be provided)",
+		}))
+	})
+
+	It("does not require the load balancer address with system domain", func() {
+		kube = fake.NewSimpleClientset(node("4", "8Gi"), defaultStorageClass)
+		checker = NewChecker(&kubernetes.Cluster{Kubectl: kube}, options("system_domain", "example.com"))
+		Expect(checker.Run(context.Background())).To(ContainElement(Result{
+			Check:   "LoadBalancer",
+			Status:  StatusPass,
+			Message: "not required, system_domain example.com is provided",
+		}))
+	})
+
+	It("warns about too little free capacity", func() {
+		busy := pod("3", "1Gi")
+		busy.Name = "busy"
+		Expect(kube.Tracker().Add(busy)).To(Succeed())
+		Expect(result(run(), "Node capacity")).To(MatchFields(IgnoreExtras, Fields{
+			"Status":  Equal(StatusWarn),
+			"Message": HavePrefix("500m CPU and 6Gi memory free"),
+		}))
+	})
+
+	It("fails with helm 2", func() {
+		tools["helm version --short"] = "Client: v2.16.1+gbbdfe5e"
+		defer func() { tools["helm version --short"] = "v3.6.3+gd506314\n" }()
+		Expect(result(run(), "helm")).To(Equal(Result{
+			Check:   "helm",
+			Status:  StatusFail,
+			Message: "v2.16.1 is not supported, at least 3.0.0 is required",
+		}))
+	})
+
+	It("warns about kubectl version skew", func() {
+		kube.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.19.0"}
+		Expect(result(run(), "kubectl").Status).To(Equal(StatusWarn))
+	})
+})
@@ -0,0 +1,55 @@
+package paas
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fuseml/fuseml/cli/deployments"
+	"github.com/pkg/errors"
+)
+
+// Status shows which FuseML components are installed and the permissions granted to the workloads
+// by FuseML and the extensions
+func (c *InstallClient) Status(ctx context.Context) error {
+	log := c.Log.WithName("Status")
+	log.Info("start")
+	defer log.Info("return")
+
+	msg := c.ui.Success().WithTable("Component", "Namespace", "Installed")
+	for _, component := range components {
+		installed, err := c.kubeClient.NamespaceExists(ctx, component.namespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if %s is installed", component.name)
+		}
+		state := "no"
+		if installed {
+			state = "yes"
+		}
+		msg = msg.WithTableRow(component.name, component.namespace, state)
+	}
+	msg.Msg("FuseML components:")
+
+	grants, err := deployments.Workloads{}.WorkloadsGrants(ctx, c.kubeClient)
+	if err != nil {
+		return errors.Wrap(err, "failed to list the permissions of the workloads")
+	}
+	if len(grants) == 0 {
+		c.ui.Note().Msg("No permissions granted to the workloads.")
+		return nil
+	}
+	msg = c.ui.Success().WithTable("Granted by", "API groups", "Resources", "Verbs")
+	for _, grant := range grants {
+		groups := []string{}
+		for _, group := range grant.Rule.APIGroups {
+			if group == "" {
+				group = "core"
+			}
+			groups = append(groups, group)
+		}
+		msg = msg.WithTableRow(grant.GrantedBy, strings.Join(groups, ", "),
+			strings.Join(grant.Rule.Resources, ", "), strings.Join(grant.Rule.Verbs, ", "))
+	}
+	msg.Msg("Permissions of the workloads service account (" + deployments.WorkloadsDeploymentID + "):")
+
+	return nil
+}
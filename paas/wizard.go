@@ -0,0 +1,183 @@
+package paas
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/fuseml/fuseml/cli/deployments"
+	"github.com/fuseml/fuseml/cli/kubernetes"
+	"github.com/fuseml/fuseml/cli/paas/config"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// readOptions fills in the options which were not given on the command line: from the
+// answers file and the profile, then either by the wizard (interactive mode) or with the defaults.
+// The wizard offers the values returned by the choices functions of the options (e.g. the extensions).
+// All the problems found by the option validators are returned together.
+func (c *InstallClient) readOptions(ctx context.Context, cmd *cobra.Command, options *kubernetes.InstallationOptions, choices map[string]kubernetes.ChoicesFunc) (*kubernetes.InstallationOptions, error) {
+	details := c.Log.WithName("readOptions").V(1)
+
+	options, err := c.readSavedOptions(cmd, options)
+	if err != nil {
+		return nil, err
+	}
+
+	interactive := false
+	if cmd.Flags().Lookup("interactive") != nil {
+		if interactive, err = cmd.Flags().GetBool("interactive"); err != nil {
+			return nil, err
+		}
+	}
+	if interactive {
+		details.Info("query user for options")
+		return c.runWizard(ctx, options, choices)
+	}
+
+	details.Info("fill defaults into options")
+	options, err = options.Populate(kubernetes.NewDefaultOptionsReader())
+	if err != nil {
+		return nil, err
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	c.showInstallConfiguration(options)
+	return options, nil
+}
+
+// readSavedOptions fills in the options from the answers file (--answers-file) and then from the profile
+func (c *InstallClient) readSavedOptions(cmd *cobra.Command, options *kubernetes.InstallationOptions) (*kubernetes.InstallationOptions, error) {
+	details := c.Log.WithName("readSavedOptions").V(1)
+
+	if cmd.Flags().Lookup("answers-file") != nil {
+		answersFile, err := cmd.Flags().GetString("answers-file")
+		if err != nil {
+			return nil, err
+		}
+		if answersFile != "" {
+			details.Info("read answers file", "File", answersFile)
+			reader, err := kubernetes.NewFileOptionsReader(answersFile)
+			if err != nil {
+				return nil, err
+			}
+			if options, err = options.Populate(reader); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	name, profile, err := c.config.Profile()
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		details.Info("read profile", "Profile", name)
+		c.ui.Note().Msg(fmt.Sprintf("Using profile %s", name))
+		return options.Populate(kubernetes.NewValuesOptionsReader(fmt.Sprintf("profile '%s'", name), profile))
+	}
+	return options, nil
+}
+
+// runWizard asks the user for the options, lets them confirm the configuration and save
+// the answers for a non-interactive run
+func (c *InstallClient) runWizard(ctx context.Context, options *kubernetes.InstallationOptions, choices map[string]kubernetes.ChoicesFunc) (*kubernetes.InstallationOptions, error) {
+	reader := kubernetes.NewInteractiveOptionsReader(os.Stdout, os.Stdin)
+	for name, choicesFunc := range choices {
+		reader = reader.WithChoices(name, choicesFunc)
+	}
+
+	options, err := options.Populate(reader)
+	if err != nil {
+		return nil, err
+	}
+	// rules involving options asked for later
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	c.showInstallConfiguration(options)
+	confirmed, err := reader.Confirm("Continue with this configuration?")
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, errors.New("configuration was not confirmed, nothing was changed")
+	}
+
+	answersFile, err := reader.Ask("Save the answers for a non-interactive run with --answers-file (path, leave empty to skip): ")
+	if err != nil {
+		return nil, err
+	}
+	if answersFile != "" {
+		if err := kubernetes.WriteOptionsFile(answersFile, *options); err != nil {
+			return nil, err
+		}
+		c.ui.Note().Msg(fmt.Sprintf("Answers saved, repeat the run with --answers-file %s", answersFile))
+	}
+	return options, nil
+}
+
+// extensionChoices lists the extensions of the repository chosen in the previous answers.
+// The values are read as free text when the remote repository can't be listed.
+func (c *InstallClient) extensionChoices(ctx context.Context) kubernetes.ChoicesFunc {
+	return func(answered kubernetes.InstallationOptions) ([]kubernetes.Choice, error) {
+		repository := config.DefaultExtensionsLocation()
+		if opt, err := answered.GetOpt("extensions_repository", ""); err == nil && opt.Value != "" {
+			repository = opt.Value.(string)
+		}
+
+		extensions, err := deployments.ListExtensions(ctx, repository, DefaultTimeoutSec, c.ui.Verbose())
+		if errors.Cause(err) == deployments.ErrNoExtensionIndex {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		choices := []kubernetes.Choice{}
+		for _, extension := range extensions {
+			choices = append(choices, kubernetes.Choice{
+				Value:       extension.Name,
+				Description: extension.Desc.Description,
+				Requires:    extension.Desc.Requires,
+			})
+		}
+		return choices, nil
+	}
+}
+
+// registeredExtensionChoices lists the extensions registered in FuseML core, for the options removing them
+func (c *InstallClient) registeredExtensionChoices(ctx context.Context) kubernetes.ChoicesFunc {
+	return func(answered kubernetes.InstallationOptions) ([]kubernetes.Choice, error) {
+		domain := ""
+		if opt, err := answered.GetOpt("system_domain", ""); err == nil && opt.Value != "" {
+			domain = opt.Value.(string)
+		}
+		if domain == "" {
+			domain = c.fetchExistingDomain(ctx)
+		}
+		if domain == "" {
+			return nil, errors.New("FuseML core is not installed")
+		}
+		options := kubernetes.InstallationOptions{
+			{Name: "system_domain", Type: kubernetes.StringType, Value: domain},
+		}
+		registered, err := deployments.GetRegisteredExtensions(ctx, c.kubeClient, &options, c.ui.Verbose())
+		if err != nil {
+			return nil, err
+		}
+		choices := []kubernetes.Choice{}
+		for _, extension := range registered {
+			if extension.ID == nil {
+				continue
+			}
+			choice := kubernetes.Choice{Value: *extension.ID}
+			if extension.Description != nil {
+				choice.Description = *extension.Description
+			}
+			choices = append(choices, choice)
+		}
+		return choices, nil
+	}
+}